@@ -0,0 +1,317 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"image"
+	"image/color"
+)
+
+// ROP3 applies the ternary raster operation rop bitwise to pattern,
+// source and destination values. Bit p<<2|s<<1|d of the operation
+// index, bits 16 to 23 of rop, is the result for pattern bit p, source
+// bit s and destination bit d.
+func ROP3(rop DWORD, p, s, d uint32) uint32 {
+	code := uint8(rop >> 16)
+
+	var r uint32
+	for i := uint(0); i < 8; i++ {
+		if code&(1<<i) == 0 {
+			continue
+		}
+
+		m := ^uint32(0)
+		m &= ropTerm(i&4 != 0, p)
+		m &= ropTerm(i&2 != 0, s)
+		m &= ropTerm(i&1 != 0, d)
+		r |= m
+	}
+
+	return r
+}
+
+func ropTerm(set bool, v uint32) uint32 {
+	if set {
+		return v
+	}
+
+	return ^v
+}
+
+// ropUsesSource reports whether the result of rop depends on the source.
+func ropUsesSource(rop DWORD) bool {
+	code := uint8(rop >> 16)
+
+	return code>>2&0x33 != code&0x33
+}
+
+// ropUsesPattern reports whether the result of rop depends on the
+// pattern.
+func ropUsesPattern(rop DWORD) bool {
+	code := uint8(rop >> 16)
+
+	return code>>4 != code&0x0F
+}
+
+// MAKEROP4 combines the foreground and background raster operations of
+// MaskBlt.
+func MAKEROP4(fore, back DWORD) DWORD {
+	return back<<8&0xFF000000 | fore
+}
+
+// BltImage applies rop to the pixels of dst in r, combining them with
+// the pixels of src from sp and with pat, which is tiled from the
+// origin of dst. src and pat may be nil if rop does not use them. The
+// operation applies to the color channels; the result is opaque.
+func BltImage(dst *image.RGBA, r image.Rectangle, src image.Image, sp image.Point, pat image.Image, rop DWORD) {
+	MaskBltImage(dst, r, src, sp, nil, image.Point{}, pat, MAKEROP4(rop, rop))
+}
+
+// MaskBltImage is like BltImage but uses the foreground operation of
+// rop, made with MAKEROP4, where mask has a non-black pixel and the
+// background operation elsewhere. mask is aligned at mp with r.Min and
+// may be nil if both operations are the same.
+func MaskBltImage(dst *image.RGBA, r image.Rectangle, src image.Image, sp image.Point, mask image.Image, mp image.Point, pat image.Image, rop DWORD) {
+	fore, back := rop&0x00FFFFFF, rop>>8&0x00FF0000
+	org := r.Min
+	r = r.Intersect(dst.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			op := fore
+			if mask != nil {
+				m := rgbaAt(mask, mp.X+x-org.X, mp.Y+y-org.Y)
+				if m.R|m.G|m.B == 0 {
+					op = back
+				}
+			}
+
+			var p, s uint32
+			if pat != nil && ropUsesPattern(op) {
+				p = rgbaBits(patternAt(pat, x, y))
+			}
+			if src != nil && ropUsesSource(op) {
+				s = rgbaBits(rgbaAt(src, sp.X+x-org.X, sp.Y+y-org.Y))
+			}
+			d := rgbaBits(dst.RGBAAt(x, y))
+			dst.SetRGBA(x, y, bitsRGBA(ROP3(op, p, s, d)))
+		}
+	}
+}
+
+// StretchImage scales sr of src to dr of dst using mode, one of the
+// stretch modes of SetStretchBltMode, and combines it with dst and pat
+// like BltImage.
+//
+// COLORONCOLOR samples the source pixel under the center of each
+// destination pixel. BLACKONWHITE and WHITEONBLACK combine the source
+// pixels that are dropped when shrinking with a bitwise AND or OR,
+// preserving black or white detail. HALFTONE averages the source area
+// each destination pixel covers.
+func StretchImage(dst *image.RGBA, dr image.Rectangle, src image.Image, sr image.Rectangle, pat image.Image, rop DWORD, mode int32) {
+	scaled := stretchRGBA(src, sr, dr.Dx(), dr.Dy(), mode, false, false)
+	BltImage(dst, dr, scaled, image.Point{}, pat, rop)
+}
+
+// stretchRGBA scales sr of src to a w by h image, optionally mirrored.
+func stretchRGBA(src image.Image, sr image.Rectangle, w, h int, mode int32, flipX, flipY bool) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	if w <= 0 || h <= 0 || sr.Empty() {
+		return out
+	}
+
+	xs := stretchSpans(sr.Dx(), w, mode, flipX)
+	ys := stretchSpans(sr.Dy(), h, mode, flipY)
+	for y, ry := range ys {
+		for x, rx := range xs {
+			var c color.RGBA
+			switch mode {
+			case HALFTONE:
+				c = halftonePixel(src, sr.Min, rx, ry)
+			case BLACKONWHITE, WHITEONBLACK:
+				c = combinePixels(src, sr.Min, rx, ry, mode == BLACKONWHITE)
+			default:
+				c = rgbaAt(src, sr.Min.X+rx.center, sr.Min.Y+ry.center)
+			}
+			out.SetRGBA(x, y, c)
+		}
+	}
+
+	return out
+}
+
+// stretchSpan describes the source pixels used for one destination
+// pixel: [lo, hi) and the pixel sampled by COLORONCOLOR. In units of
+// 1/unit source pixels the destination pixel covers [a, b).
+type stretchSpan struct {
+	lo, hi, center int
+	a, b, unit     int
+}
+
+func stretchSpans(src, dst int, mode int32, flip bool) []stretchSpan {
+	spans := make([]stretchSpan, dst)
+	for i := range spans {
+		j := i
+		if flip {
+			j = dst - 1 - i
+		}
+
+		lo := j * src / dst
+		hi := (j + 1) * src / dst
+		if mode == HALFTONE {
+			hi = ((j+1)*src + dst - 1) / dst
+		}
+		if hi <= lo {
+			hi = lo + 1
+		}
+		spans[i] = stretchSpan{lo, hi, (2*j + 1) * src / (2 * dst), j * src, (j + 1) * src, dst}
+	}
+
+	return spans
+}
+
+func combinePixels(src image.Image, o image.Point, rx, ry stretchSpan, and bool) color.RGBA {
+	acc := uint32(0)
+	if and {
+		acc = ^uint32(0)
+	}
+	for y := ry.lo; y < ry.hi; y++ {
+		for x := rx.lo; x < rx.hi; x++ {
+			v := rgbaBits(rgbaAt(src, o.X+x, o.Y+y))
+			if and {
+				acc &= v
+			} else {
+				acc |= v
+			}
+		}
+	}
+
+	return bitsRGBA(acc)
+}
+
+// halftonePixel averages the source pixels weighted by how much of each
+// the destination pixel covers.
+func halftonePixel(src image.Image, o image.Point, rx, ry stretchSpan) color.RGBA {
+	var sum [4]uint64
+	var total uint64
+	for y := ry.lo; y < ry.hi; y++ {
+		wy := uint64(overlap(ry, y))
+		for x := rx.lo; x < rx.hi; x++ {
+			w := wy * uint64(overlap(rx, x))
+			c := rgbaAt(src, o.X+x, o.Y+y)
+			sum[0] += w * uint64(c.R)
+			sum[1] += w * uint64(c.G)
+			sum[2] += w * uint64(c.B)
+			sum[3] += w * uint64(c.A)
+			total += w
+		}
+	}
+	if total == 0 {
+		return rgbaAt(src, o.X+rx.center, o.Y+ry.center)
+	}
+
+	avg := func(v uint64) uint8 { return uint8((v + total/2) / total) }
+
+	return color.RGBA{avg(sum[0]), avg(sum[1]), avg(sum[2]), avg(sum[3])}
+}
+
+// overlap returns how much of source pixel k the span covers.
+func overlap(s stretchSpan, k int) int {
+	return min(s.b, (k+1)*s.unit) - max(s.a, k*s.unit)
+}
+
+// AlphaBlendImage composites sr of src onto dr of dst as AlphaBlend
+// does, scaling with COLORONCOLOR. With AC_SRC_ALPHA the source must
+// be premultiplied, as image.RGBA is, and is scaled by
+// SourceConstantAlpha; otherwise only the constant alpha is used.
+func AlphaBlendImage(dst *image.RGBA, dr image.Rectangle, src image.Image, sr image.Rectangle, bf BLENDFUNCTION) {
+	scaled := stretchRGBA(src, sr, dr.Dx(), dr.Dy(), COLORONCOLOR, false, false)
+	r := dr.Intersect(dst.Bounds())
+	sca := uint32(bf.SourceConstantAlpha)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s := scaled.RGBAAt(x-dr.Min.X, y-dr.Min.Y)
+			d := dst.RGBAAt(x, y)
+			switch {
+			case bf.AlphaFormat&AC_SRC_ALPHA == 0:
+				d = color.RGBA{blendConst(d.R, s.R, sca), blendConst(d.G, s.G, sca), blendConst(d.B, s.B, sca), blendConst(d.A, s.A, sca)}
+			case sca == 0xFF:
+				d = blendOver(d, s)
+			default:
+				s = color.RGBA{scaleAlpha(s.R, sca), scaleAlpha(s.G, sca), scaleAlpha(s.B, sca), scaleAlpha(s.A, sca)}
+				d = blendOver(d, s)
+			}
+			dst.SetRGBA(x, y, d)
+		}
+	}
+}
+
+func blendConst(d, s uint8, alpha uint32) uint8 {
+	return uint8((uint32(s)*alpha + uint32(d)*(0xFF-alpha) + 127) / 0xFF)
+}
+
+func blendOver(d, s color.RGBA) color.RGBA {
+	inv := 0xFF - uint32(s.A)
+	over := func(d, s uint8) uint8 { return s + uint8((uint32(d)*inv+127)/0xFF) }
+
+	return color.RGBA{over(d.R, s.R), over(d.G, s.G), over(d.B, s.B), over(d.A, s.A)}
+}
+
+func scaleAlpha(v uint8, alpha uint32) uint8 {
+	return uint8((uint32(v)*alpha + 127) / 0xFF)
+}
+
+// TransparentBltImage copies sr of src to dr of dst, scaled with
+// COLORONCOLOR, skipping source pixels of the color transparent.
+func TransparentBltImage(dst *image.RGBA, dr image.Rectangle, src image.Image, sr image.Rectangle, transparent COLORREF) {
+	scaled := stretchRGBA(src, sr, dr.Dx(), dr.Dy(), COLORONCOLOR, false, false)
+	key := colorRGBA(transparent)
+	r := dr.Intersect(dst.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s := scaled.RGBAAt(x-dr.Min.X, y-dr.Min.Y)
+			if s.R != key.R || s.G != key.G || s.B != key.B {
+				dst.SetRGBA(x, y, s)
+			}
+		}
+	}
+}
+
+// patternAt returns the pattern pixel for device pixel x, y, tiling
+// finite patterns from the origin.
+func patternAt(pat image.Image, x, y int) color.RGBA {
+	b := pat.Bounds()
+	if !image.Pt(x, y).In(b) {
+		x = b.Min.X + mod(x-b.Min.X, b.Dx())
+		y = b.Min.Y + mod(y-b.Min.Y, b.Dy())
+	}
+
+	return rgbaAt(pat, x, y)
+}
+
+func mod(a, n int) int {
+	a %= n
+	if a < 0 {
+		a += n
+	}
+
+	return a
+}
+
+func rgbaAt(img image.Image, x, y int) color.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba.RGBAAt(x, y)
+	}
+
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
+
+// rgbaBits packs the color channels of c like a COLORREF.
+func rgbaBits(c color.RGBA) uint32 {
+	return uint32(c.R) | uint32(c.G)<<8 | uint32(c.B)<<16
+}
+
+func bitsRGBA(v uint32) color.RGBA {
+	return color.RGBA{uint8(v), uint8(v >> 8), uint8(v >> 16), 0xFF}
+}
@@ -0,0 +1,252 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// The named raster operations with their documented boolean functions
+// of pattern, source and destination.
+var namedROPs = []struct {
+	name string
+	rop  DWORD
+	fn   func(p, s, d bool) bool
+}{
+	{"BLACKNESS", BLACKNESS, func(p, s, d bool) bool { return false }},
+	{"NOTSRCERASE", NOTSRCERASE, func(p, s, d bool) bool { return !(s || d) }},
+	{"NOTSRCCOPY", NOTSRCCOPY, func(p, s, d bool) bool { return !s }},
+	{"SRCERASE", SRCERASE, func(p, s, d bool) bool { return s && !d }},
+	{"DSTINVERT", DSTINVERT, func(p, s, d bool) bool { return !d }},
+	{"PATINVERT", PATINVERT, func(p, s, d bool) bool { return p != d }},
+	{"SRCINVERT", SRCINVERT, func(p, s, d bool) bool { return s != d }},
+	{"SRCAND", SRCAND, func(p, s, d bool) bool { return s && d }},
+	{"MERGEPAINT", MERGEPAINT, func(p, s, d bool) bool { return !s || d }},
+	{"MERGECOPY", MERGECOPY, func(p, s, d bool) bool { return p && s }},
+	{"SRCCOPY", SRCCOPY, func(p, s, d bool) bool { return s }},
+	{"SRCPAINT", SRCPAINT, func(p, s, d bool) bool { return s || d }},
+	{"PATCOPY", PATCOPY, func(p, s, d bool) bool { return p }},
+	{"PATPAINT", PATPAINT, func(p, s, d bool) bool { return p || !s || d }},
+	{"WHITENESS", WHITENESS, func(p, s, d bool) bool { return true }},
+}
+
+func TestROP3Named(t *testing.T) {
+	for _, tt := range namedROPs {
+		for i := uint32(0); i < 8; i++ {
+			p, s, d := i&4 != 0, i&2 != 0, i&1 != 0
+			bit := func(b bool) uint32 {
+				if b {
+					return 1
+				}
+				return 0
+			}
+			got := ROP3(tt.rop, bit(p), bit(s), bit(d))&1 != 0
+			if want := tt.fn(p, s, d); got != want {
+				t.Errorf("%s(p=%v, s=%v, d=%v) = %v, want %v", tt.name, p, s, d, got, want)
+			}
+		}
+
+		// Applied to the operand columns of the truth table, an operation
+		// yields its index.
+		if got := ROP3(tt.rop, 0xF0, 0xCC, 0xAA) & 0xFF; got != uint32(tt.rop>>16) {
+			t.Errorf("%s truth table = %#02x, want %#02x", tt.name, got, tt.rop>>16)
+		}
+	}
+}
+
+func TestROPUses(t *testing.T) {
+	tests := []struct {
+		rop             DWORD
+		source, pattern bool
+	}{
+		{BLACKNESS, false, false},
+		{DSTINVERT, false, false},
+		{PATCOPY, false, true},
+		{PATINVERT, false, true},
+		{SRCCOPY, true, false},
+		{SRCAND, true, false},
+		{MERGECOPY, true, true},
+		{PATPAINT, true, true},
+	}
+	for _, tt := range tests {
+		if got := ropUsesSource(tt.rop); got != tt.source {
+			t.Errorf("ropUsesSource(%#08x) = %v, want %v", tt.rop, got, tt.source)
+		}
+		if got := ropUsesPattern(tt.rop); got != tt.pattern {
+			t.Errorf("ropUsesPattern(%#08x) = %v, want %v", tt.rop, got, tt.pattern)
+		}
+	}
+}
+
+func grayRow(v ...uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, len(v), 1))
+	for x, g := range v {
+		img.SetRGBA(x, 0, color.RGBA{g, g, g, 0xFF})
+	}
+
+	return img
+}
+
+func rowValues(img *image.RGBA) []uint8 {
+	var v []uint8
+	b := img.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		v = append(v, img.RGBAAt(x, b.Min.Y).R)
+	}
+
+	return v
+}
+
+func TestStretchImage(t *testing.T) {
+	tests := []struct {
+		name string
+		src  []uint8
+		w    int
+		mode int32
+		want []uint8
+	}{
+		{"COLORONCOLOR shrink", []uint8{10, 20, 30, 40}, 2, COLORONCOLOR, []uint8{20, 40}},
+		{"COLORONCOLOR grow", []uint8{10, 20}, 4, COLORONCOLOR, []uint8{10, 10, 20, 20}},
+		{"COLORONCOLOR third", []uint8{10, 20, 30, 40, 50, 60}, 2, COLORONCOLOR, []uint8{20, 50}},
+		{"HALFTONE halve", []uint8{0, 100, 200, 50}, 2, HALFTONE, []uint8{50, 125}},
+		{"HALFTONE partial", []uint8{0, 90, 180}, 2, HALFTONE, []uint8{30, 150}},
+		{"HALFTONE grow", []uint8{10, 20}, 4, HALFTONE, []uint8{10, 10, 20, 20}},
+		{"HALFTONE same", []uint8{7, 8, 9}, 3, HALFTONE, []uint8{7, 8, 9}},
+	}
+	for _, tt := range tests {
+		src := grayRow(tt.src...)
+		dst := image.NewRGBA(image.Rect(0, 0, tt.w, 1))
+		StretchImage(dst, dst.Bounds(), src, src.Bounds(), nil, SRCCOPY, tt.mode)
+		if got := rowValues(dst); string(got) != string(tt.want) {
+			t.Errorf("%s: StretchImage = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStretchImageOffset(t *testing.T) {
+	// Only dr of dst is drawn, from sr of src.
+	src := grayRow(1, 2, 3, 4, 5, 6)
+	dst := grayRow(9, 9, 9, 9)
+	StretchImage(dst, image.Rect(1, 0, 3, 1), src, image.Rect(2, 0, 6, 1), nil, SRCCOPY, HALFTONE)
+	if got, want := rowValues(dst), []uint8{9, 4, 6, 9}; string(got) != string(want) {
+		t.Errorf("StretchImage = %v, want %v", got, want)
+	}
+}
+
+func newTestSoftDC(t *testing.T, w, h int, fill color.RGBA) *SoftDC {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = fill.R, fill.G, fill.B, fill.A
+	}
+	dc := NewSoftDC(img)
+	if dc == nil {
+		t.Fatal("NewSoftDC returned nil")
+	}
+	t.Cleanup(dc.Close)
+
+	return dc
+}
+
+func TestSoftDCBitBlt(t *testing.T) {
+	red, blue := color.RGBA{0xFF, 0, 0, 0xFF}, color.RGBA{0, 0, 0xFF, 0xFF}
+	white := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	src := newTestSoftDC(t, 4, 4, red)
+	src.Image().SetRGBA(1, 1, blue)
+	dst := newTestSoftDC(t, 8, 8, white)
+
+	if !BitBlt(dst.HDC(), 2, 3, 3, 2, src.HDC(), 0, 1, SRCCOPY) {
+		t.Fatal("BitBlt SRCCOPY failed")
+	}
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := white
+			switch {
+			case x == 3 && y == 3:
+				want = blue
+			case x >= 2 && x < 5 && y >= 3 && y < 5:
+				want = red
+			}
+			if got := dst.Image().RGBAAt(x, y); got != want {
+				t.Errorf("after SRCCOPY pixel %d,%d = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// SRCINVERT twice restores the destination.
+	before := append([]uint8(nil), dst.Image().Pix...)
+	BitBlt(dst.HDC(), 0, 0, 8, 8, src.HDC(), 0, 0, SRCINVERT)
+	if got := dst.Image().RGBAAt(0, 0); got != (color.RGBA{0, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("after SRCINVERT pixel 0,0 = %v, want cyan", got)
+	}
+	BitBlt(dst.HDC(), 0, 0, 8, 8, src.HDC(), 0, 0, SRCINVERT)
+	if string(dst.Image().Pix) != string(before) {
+		t.Error("SRCINVERT twice changed the destination")
+	}
+
+	// Operations without a source ignore hdcSrc; the others need it.
+	if !BitBlt(dst.HDC(), 0, 0, 1, 1, 0, 0, 0, BLACKNESS) {
+		t.Error("BitBlt BLACKNESS without a source failed")
+	}
+	if got := dst.Image().RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 0xFF}) {
+		t.Errorf("after BLACKNESS pixel 0,0 = %v, want black", got)
+	}
+	if BitBlt(dst.HDC(), 0, 0, 1, 1, 0, 0, 0, SRCCOPY) {
+		t.Error("BitBlt SRCCOPY without a source succeeded")
+	}
+}
+
+func TestSoftDCPatBlt(t *testing.T) {
+	dc := newTestSoftDC(t, 4, 4, color.RGBA{0xFF, 0xFF, 0xFF, 0xFF})
+	dc.SelectBrush(SoftBrush{Color: RGB(0, 0x80, 0)})
+
+	if !PatBlt(dc.HDC(), 1, 1, 2, 2, PATCOPY) {
+		t.Fatal("PatBlt PATCOPY failed")
+	}
+	if got := dc.Image().RGBAAt(1, 2); got != (color.RGBA{0, 0x80, 0, 0xFF}) {
+		t.Errorf("pixel 1,2 = %v, want the brush color", got)
+	}
+	if got := dc.Image().RGBAAt(3, 3); got != (color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}) {
+		t.Errorf("pixel 3,3 = %v, want white", got)
+	}
+
+	if PatBlt(dc.HDC(), 0, 0, 1, 1, SRCCOPY) {
+		t.Error("PatBlt with a source operation succeeded")
+	}
+}
+
+func TestSoftDCStretchBlt(t *testing.T) {
+	src := newTestSoftDC(t, 4, 1, color.RGBA{})
+	copy(src.Image().Pix, grayRow(10, 20, 30, 40).Pix)
+	dst := newTestSoftDC(t, 2, 1, color.RGBA{})
+
+	SetStretchBltMode(dst.HDC(), COLORONCOLOR)
+	if !StretchBlt(dst.HDC(), 0, 0, 2, 1, src.HDC(), 0, 0, 4, 1, SRCCOPY) {
+		t.Fatal("StretchBlt failed")
+	}
+	if got, want := rowValues(dst.Image()), []uint8{20, 40}; string(got) != string(want) {
+		t.Errorf("COLORONCOLOR StretchBlt = %v, want %v", got, want)
+	}
+
+	// A negative source width mirrors the image.
+	SetStretchBltMode(dst.HDC(), HALFTONE)
+	StretchBlt(dst.HDC(), 0, 0, 2, 1, src.HDC(), 4, 0, -4, 1, SRCCOPY)
+	if got, want := rowValues(dst.Image()), []uint8{35, 15}; string(got) != string(want) {
+		t.Errorf("mirrored HALFTONE StretchBlt = %v, want %v", got, want)
+	}
+}
+
+func TestDIBitsEmpty(t *testing.T) {
+	var bmi BITMAPINFO
+	if SetDIBitsToDevice(0, 0, 0, 1, 1, 0, 0, 0, 1, nil, &bmi, DIB_RGB_COLORS) != 0 || LastError() != ERROR_INVALID_PARAMETER {
+		t.Errorf("SetDIBitsToDevice without bits: %v, want ERROR_INVALID_PARAMETER", LastError())
+	}
+	if StretchDIBits(0, 0, 0, 1, 1, 0, 0, 1, 1, []byte{}, &bmi, DIB_RGB_COLORS, SRCCOPY) != 0 || LastError() != ERROR_INVALID_PARAMETER {
+		t.Errorf("StretchDIBits without bits: %v, want ERROR_INVALID_PARAMETER", LastError())
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"os"
+	"sync"
+)
+
+// OpenConsole gives a GUI process a console for diagnostics. It attaches
+// to the console of the parent process if there is one, so output shows
+// up in the terminal the program was started from, and allocates a new
+// console otherwise. The standard streams are then bound to it with
+// RedirectStdio.
+func OpenConsole() error {
+	if !AttachConsole(ATTACH_PARENT_PROCESS) && !AllocConsole() {
+		return lastError
+	}
+
+	return RedirectStdio()
+}
+
+// RedirectStdio binds os.Stdin, os.Stdout and os.Stderr, and the
+// matching standard handles, to the console. Streams that already have
+// a valid handle, such as a pipe or file the process was started with,
+// are left alone.
+func RedirectStdio() error {
+	streams := []struct {
+		f    **os.File
+		std  DWORD
+		name string
+	}{
+		{&os.Stdin, STD_INPUT_HANDLE, "CONIN$"},
+		{&os.Stdout, STD_OUTPUT_HANDLE, "CONOUT$"},
+		{&os.Stderr, STD_ERROR_HANDLE, "CONOUT$"},
+	}
+
+	for _, s := range streams {
+		if h := (*s.f).Fd(); h != 0 && h != uintptr(INVALID_HANDLE_VALUE) {
+			continue
+		}
+
+		f, err := os.OpenFile(s.name, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		if !SetStdHandle(s.std, HANDLE(f.Fd())) {
+			f.Close()
+			return lastError
+		}
+		*s.f = f
+	}
+
+	return nil
+}
+
+// EnableVirtualTerminal turns on ANSI escape sequence processing for
+// the console output handle h. It requires Windows 10 or later.
+func EnableVirtualTerminal(h HANDLE) error {
+	var mode DWORD
+	if !GetConsoleMode(h, &mode) {
+		return lastError
+	}
+	if !SetConsoleMode(h, mode|ENABLE_PROCESSED_OUTPUT|ENABLE_VIRTUAL_TERMINAL_PROCESSING) {
+		return lastError
+	}
+
+	return nil
+}
+
+// SetConsoleColor sets the colours used for text written to the console
+// output handle h from here on. fg and bg are combinations of the
+// FOREGROUND_* constants; bg is shifted into the background bits. The
+// returned function restores the previous attributes.
+func SetConsoleColor(h HANDLE, fg, bg WORD) (restore func(), err error) {
+	var info CONSOLE_SCREEN_BUFFER_INFO
+	if !GetConsoleScreenBufferInfo(h, &info) {
+		return nil, lastError
+	}
+	if !SetConsoleTextAttribute(h, fg&0x0f|(bg&0x0f)<<4) {
+		return nil, lastError
+	}
+
+	return func() { SetConsoleTextAttribute(h, info.Attributes) }, nil
+}
+
+var (
+	consoleCtrlMutex    sync.Mutex
+	consoleCtrlChannels []chan<- DWORD
+	consoleCtrlHandler  uintptr
+)
+
+func consoleCtrlProc(event uintptr) uintptr {
+	consoleCtrlMutex.Lock()
+	defer consoleCtrlMutex.Unlock()
+
+	handled := false
+	for _, c := range consoleCtrlChannels {
+		select {
+		case c <- DWORD(event):
+			handled = true
+		default:
+		}
+	}
+
+	return BoolToPtr(handled)
+}
+
+// NotifyConsoleCtrl relays console control events (CTRL_C_EVENT,
+// CTRL_CLOSE_EVENT, ...) to c. Like os/signal, it does not block
+// sending to c, so c should be buffered. An event delivered to at least
+// one channel counts as handled and does not reach the default handler,
+// but the system still ends the process shortly after a close, logoff
+// or shutdown event.
+func NotifyConsoleCtrl(c chan<- DWORD) error {
+	consoleCtrlMutex.Lock()
+	defer consoleCtrlMutex.Unlock()
+
+	if consoleCtrlHandler == 0 {
+		consoleCtrlHandler = newCallback(consoleCtrlProc)
+	}
+	if len(consoleCtrlChannels) == 0 && !SetConsoleCtrlHandler(consoleCtrlHandler, true) {
+		return lastError
+	}
+	consoleCtrlChannels = append(consoleCtrlChannels, c)
+
+	return nil
+}
+
+// StopConsoleCtrl stops relaying events to c. The handler is removed
+// with the last channel; removing a null handler would instead turn
+// CTRL+C processing back on, so nothing is done if none was installed.
+func StopConsoleCtrl(c chan<- DWORD) {
+	consoleCtrlMutex.Lock()
+	defer consoleCtrlMutex.Unlock()
+
+	if consoleCtrlHandler == 0 {
+		return
+	}
+
+	for i, v := range consoleCtrlChannels {
+		if v == c {
+			consoleCtrlChannels = append(consoleCtrlChannels[:i], consoleCtrlChannels[i+1:]...)
+			if len(consoleCtrlChannels) == 0 {
+				SetConsoleCtrlHandler(consoleCtrlHandler, false)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/bits"
+	"unsafe"
+)
+
+var ErrDIBFormat = errors.New("winapi: unsupported DIB format")
+
+const bitmapV5HeaderSize = 124
+
+// DIBStride returns the size in bytes of a row of a DIB, which is padded
+// to a multiple of 32 bits.
+func DIBStride(width, bitCount int) int {
+	return (width*bitCount + 31) / 32 * 4
+}
+
+// NewDIBHeader returns the header of a top-down 32 bpp DIB with
+// premultiplied alpha in the low to high byte order blue, green, red,
+// alpha, which is what EncodeDIB produces and AlphaBlend expects.
+func NewDIBHeader(width, height int) BITMAPV5HEADER {
+	return BITMAPV5HEADER{
+		BV5Size:        bitmapV5HeaderSize,
+		BV5Width:       int32(width),
+		BV5Height:      -int32(height),
+		BV5Planes:      1,
+		BV5BitCount:    32,
+		BV5Compression: BI_BITFIELDS,
+		BV5SizeImage:   DWORD(width * height * 4),
+		BV5RedMask:     0x00FF0000,
+		BV5GreenMask:   0x0000FF00,
+		BV5BlueMask:    0x000000FF,
+		BV5AlphaMask:   0xFF000000,
+		BV5CSType:      LCS_sRGB,
+		BV5Intent:      LCS_GM_IMAGES,
+	}
+}
+
+// V5Header extends hdr to a BITMAPV5HEADER with the given color masks,
+// which are only used for BI_BITFIELDS.
+func V5Header(hdr *BITMAPINFOHEADER, red, green, blue, alpha DWORD) BITMAPV5HEADER {
+	return BITMAPV5HEADER{
+		BV5Size:          bitmapV5HeaderSize,
+		BV5Width:         hdr.BiWidth,
+		BV5Height:        hdr.BiHeight,
+		BV5Planes:        hdr.BiPlanes,
+		BV5BitCount:      hdr.BiBitCount,
+		BV5Compression:   hdr.BiCompression,
+		BV5SizeImage:     hdr.BiSizeImage,
+		BV5XPelsPerMeter: hdr.BiXPelsPerMeter,
+		BV5YPelsPerMeter: hdr.BiYPelsPerMeter,
+		BV5ClrUsed:       hdr.BiClrUsed,
+		BV5ClrImportant:  hdr.BiClrImportant,
+		BV5RedMask:       red,
+		BV5GreenMask:     green,
+		BV5BlueMask:      blue,
+		BV5AlphaMask:     alpha,
+		BV5CSType:        LCS_sRGB,
+	}
+}
+
+// EncodeDIB converts img to the pixel data of the DIB described by
+// NewDIBHeader.
+func EncodeDIB(img image.Image) (BITMAPV5HEADER, []byte) {
+	b := img.Bounds()
+	hdr := NewDIBHeader(b.Dx(), b.Dy())
+	out := make([]byte, 0, b.Dx()*b.Dy()*4)
+
+	switch src := img.(type) {
+	case *image.RGBA:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := src.Pix[src.PixOffset(b.Min.X, y):src.PixOffset(b.Max.X, y)]
+			for i := 0; i < len(row); i += 4 {
+				out = append(out, row[i+2], row[i+1], row[i], row[i+3])
+			}
+		}
+	default:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+				out = append(out, c.B, c.G, c.R, c.A)
+			}
+		}
+	}
+
+	return hdr, out
+}
+
+// DecodeDIB converts uncompressed 16, 24 or 32 bpp pixel data described
+// by hdr to an image. Rows are bottom-up when the height is positive and
+// padded to 32 bits. BI_RGB data and data without an alpha mask are
+// opaque; alpha is otherwise taken to be premultiplied, as GDI uses it.
+func DecodeDIB(hdr *BITMAPV5HEADER, data []byte) (*image.RGBA, error) {
+	var img *image.RGBA
+	err := decodeDIB(hdr, data, func(w, h int) {
+		img = image.NewRGBA(image.Rect(0, 0, w, h))
+	}, func(x, y int, c color.RGBA) {
+		if c.R > c.A {
+			c.R = c.A
+		}
+		if c.G > c.A {
+			c.G = c.A
+		}
+		if c.B > c.A {
+			c.B = c.A
+		}
+		img.SetRGBA(x, y, c)
+	})
+
+	return img, err
+}
+
+// DecodeDIBNRGBA is like DecodeDIB but returns colors that are not
+// premultiplied by alpha.
+func DecodeDIBNRGBA(hdr *BITMAPV5HEADER, data []byte) (*image.NRGBA, error) {
+	var img *image.NRGBA
+	err := decodeDIB(hdr, data, func(w, h int) {
+		img = image.NewNRGBA(image.Rect(0, 0, w, h))
+	}, func(x, y int, c color.RGBA) {
+		img.SetNRGBA(x, y, unpremultiply(c))
+	})
+
+	return img, err
+}
+
+func unpremultiply(c color.RGBA) color.NRGBA {
+	switch c.A {
+	case 0:
+		return color.NRGBA{}
+	case 0xFF:
+		return color.NRGBA{c.R, c.G, c.B, 0xFF}
+	}
+
+	un := func(v uint8) uint8 {
+		n := (uint32(v)*0xFF + uint32(c.A)/2) / uint32(c.A)
+		if n > 0xFF {
+			n = 0xFF
+		}
+		return uint8(n)
+	}
+
+	return color.NRGBA{un(c.R), un(c.G), un(c.B), c.A}
+}
+
+func decodeDIB(hdr *BITMAPV5HEADER, data []byte, alloc func(w, h int), set func(x, y int, c color.RGBA)) error {
+	w := int(hdr.BV5Width)
+	h := int(hdr.BV5Height)
+	topDown := h < 0
+	if topDown {
+		h = -h
+	}
+	if w <= 0 || h == 0 {
+		return ErrDIBFormat
+	}
+
+	bpp := int(hdr.BV5BitCount)
+	var masks [4]uint32
+	switch {
+	case hdr.BV5Compression == BI_RGB && bpp == 16:
+		masks = [4]uint32{0x7C00, 0x03E0, 0x001F, 0}
+	case hdr.BV5Compression == BI_RGB && (bpp == 24 || bpp == 32):
+		masks = [4]uint32{0xFF0000, 0x00FF00, 0x0000FF, 0}
+	case hdr.BV5Compression == BI_BITFIELDS && (bpp == 16 || bpp == 32):
+		masks = [4]uint32{uint32(hdr.BV5RedMask), uint32(hdr.BV5GreenMask), uint32(hdr.BV5BlueMask), uint32(hdr.BV5AlphaMask)}
+	default:
+		return ErrDIBFormat
+	}
+
+	stride := DIBStride(w, bpp)
+	if len(data) < stride*h {
+		return ErrDIBFormat
+	}
+
+	alloc(w, h)
+	size := bpp / 8
+	for y := 0; y < h; y++ {
+		row := y
+		if !topDown {
+			row = h - 1 - y
+		}
+		line := data[row*stride:]
+		for x := 0; x < w; x++ {
+			var v uint32
+			for i := size - 1; i >= 0; i-- {
+				v = v<<8 | uint32(line[x*size+i])
+			}
+
+			c := color.RGBA{
+				R: maskChannel(v, masks[0]),
+				G: maskChannel(v, masks[1]),
+				B: maskChannel(v, masks[2]),
+				A: 0xFF,
+			}
+			if masks[3] != 0 {
+				c.A = maskChannel(v, masks[3])
+			}
+			set(x, y, c)
+		}
+	}
+
+	return nil
+}
+
+// maskChannel extracts the bits of v selected by mask, scaled to 8 bits.
+func maskChannel(v, mask uint32) uint8 {
+	if mask == 0 {
+		return 0
+	}
+
+	shift := bits.TrailingZeros32(mask)
+	max := mask >> shift
+	n := (v & mask) >> shift
+
+	return uint8((uint64(n)*0xFF + uint64(max)/2) / uint64(max))
+}
+
+// HBITMAPFromImage creates a DIB section holding img with premultiplied
+// alpha.
+func HBITMAPFromImage(img image.Image) (HBITMAP, error) {
+	hdr, data := EncodeDIB(img)
+
+	var bits uintptr
+	hbm := CreateDIBSection(0, (*BITMAPINFO)(unsafe.Pointer(&hdr)), DIB_RGB_COLORS, &bits, 0, 0)
+	if hbm == 0 {
+		return 0, lastError
+	}
+	copy(bytesAt(bits, len(data)), data)
+
+	return hbm, nil
+}
+
+// ImageFromHBITMAP copies the pixels of a bitmap. Bitmaps whose alpha
+// channel is entirely zero, which includes all device dependent bitmaps,
+// are returned as opaque.
+func ImageFromHBITMAP(hbm HBITMAP) (*image.RGBA, error) {
+	hdr, data, err := hbitmapBits(hbm)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeDIB(&hdr, data)
+}
+
+// NRGBAFromHBITMAP is like ImageFromHBITMAP but returns colors that are
+// not premultiplied by alpha.
+func NRGBAFromHBITMAP(hbm HBITMAP) (*image.NRGBA, error) {
+	hdr, data, err := hbitmapBits(hbm)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeDIBNRGBA(&hdr, data)
+}
+
+// hbitmapBits reads hbm as top-down 32 bpp pixels with the header that
+// describes them.
+func hbitmapBits(hbm HBITMAP) (BITMAPV5HEADER, []byte, error) {
+	bm, _, err := GetObjectBitmap(hbm)
+	if err != nil {
+		return BITMAPV5HEADER{}, nil, err
+	}
+
+	h := bm.BmHeight
+	if h < 0 {
+		h = -h
+	}
+
+	var bmi BITMAPINFO
+	bmi.BmiHeader = BITMAPINFOHEADER{
+		BiSize:        DWORD(unsafe.Sizeof(bmi.BmiHeader)),
+		BiWidth:       bm.BmWidth,
+		BiHeight:      -h,
+		BiPlanes:      1,
+		BiBitCount:    32,
+		BiCompression: BI_RGB,
+	}
+	data := make([]byte, int(bm.BmWidth)*int(h)*4)
+
+	hdc := GetDC(0)
+	defer ReleaseDC(0, hdc)
+	if GetDIBits(hdc, hbm, 0, UINT(h), data, &bmi, DIB_RGB_COLORS) == 0 {
+		return BITMAPV5HEADER{}, nil, lastError
+	}
+
+	var alpha DWORD
+	for i := 3; i < len(data); i += 4 {
+		if data[i] != 0 {
+			alpha = 0xFF000000
+			break
+		}
+	}
+
+	bmi.BmiHeader.BiCompression = BI_BITFIELDS
+
+	return V5Header(&bmi.BmiHeader, 0x00FF0000, 0x0000FF00, 0x000000FF, alpha), data, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+
+package winapi
+
+// On other systems the package builds so that its pure Go parts, such as
+// the metafile and DIB codecs, Region, Path and SoftDC, can be used. The
+// DLLs are never loaded: every call into them fails with
+// ErrNotSupported and callbacks are never called.
+
+type lazyDLL struct {
+	Name string
+}
+
+type lazyProc struct {
+	Name string
+}
+
+func newLazyDLL(name string) *lazyDLL {
+	return &lazyDLL{Name: name}
+}
+
+func (d *lazyDLL) NewProc(name string) *lazyProc {
+	return &lazyProc{Name: name}
+}
+
+func (p *lazyProc) Find() error {
+	return ErrNotSupported
+}
+
+func (p *lazyProc) Call(a ...uintptr) (r1, r2 uintptr, err error) {
+	return 0, 0, ErrNotSupported
+}
+
+func newCallback(fn interface{}) uintptr {
+	return 0
+}
@@ -0,0 +1,21 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package winapi
+
+import (
+	"syscall"
+)
+
+// newLazyDLL and newCallback are the only ties of the package to the
+// Windows loader. dll_other.go replaces them elsewhere.
+func newLazyDLL(name string) *syscall.LazyDLL {
+	return syscall.NewLazyDLL(name)
+}
+
+func newCallback(fn interface{}) uintptr {
+	return syscall.NewCallback(fn)
+}
@@ -0,0 +1,368 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// TextMeasure supplies the font measurements LayoutText needs.
+type TextMeasure struct {
+	// Advance returns the advance width of r.
+	Advance func(r rune) int32
+	// Height and ExternalLeading are the tmHeight and
+	// tmExternalLeading of the font; the latter is added between lines
+	// with DT_EXTERNALLEADING.
+	Height          int32
+	ExternalLeading int32
+	// AveCharWidth is the unit of tab stops.
+	AveCharWidth int32
+}
+
+// TextLine is one line of a TextLayout.
+type TextLine struct {
+	// Text is the text of the line with prefix characters removed and
+	// ellipses inserted, and Dx the advance of each of its UTF-16 code
+	// units, with tabs expanded, as ExtTextOut takes it.
+	Text string
+	Dx   []int32
+	// X, Y is the top left corner of the line and Width its advance.
+	X, Y  int32
+	Width int32
+	// Prefix is the index of the UTF-16 code unit to underline, or -1.
+	Prefix int
+}
+
+// TextLayout is text laid out as DrawText lays it out.
+type TextLayout struct {
+	Lines []TextLine
+	// Rect is the rectangle DT_CALCRECT returns.
+	Rect RECT
+	// Height is the value DrawText returns: the height of the text, or
+	// with DT_VCENTER or DT_BOTTOM the offset from the top of the
+	// rectangle to the bottom of the text.
+	Height int32
+	// Drawn is the number of UTF-16 code units of the text processed,
+	// which DrawTextEx stores in DRAWTEXTPARAMS.UiLengthDrawn.
+	Drawn int
+}
+
+// textChar is a character of a logical line after prefix processing.
+type textChar struct {
+	r      rune
+	prefix bool
+	// units is the number of UTF-16 code units the character and its
+	// prefix character take in the text.
+	units int
+}
+
+const textEllipsis = "..."
+
+// LayoutText lays out s in rc with the DT_ flags of format and the
+// margins and tab length of dtp, which may be nil, the way DrawTextEx
+// does:
+//
+//   - Lines end at CR, LF or CR LF unless DT_SINGLELINE is set.
+//   - With DT_WORDBREAK a line is broken after the last space that
+//     fits, and the spaces at the break are dropped. A word wider than
+//     the rectangle is kept whole unless DT_EDITCONTROL is set, in which
+//     case it is broken between characters.
+//   - Unless DT_NOPREFIX is set, "&&" is shown as "&" and "&" before
+//     another character removes itself and marks that character, the
+//     last one marked in a line, for underlining. DT_HIDEPREFIX removes
+//     the mark.
+//   - With DT_EXPANDTABS tabs advance to the next multiple of 8, or the
+//     tab length from the high byte of format with DT_TABSTOP or from
+//     dtp, times the average character width.
+//   - A line still wider than the rectangle is shortened to fit with an
+//     ellipsis: DT_END_ELLIPSIS cuts its end between any two characters,
+//     DT_WORD_ELLIPSIS after the last whole word that fits, or like
+//     DT_END_ELLIPSIS if no word does, and DT_PATH_ELLIPSIS the end of
+//     the part before the last backslash.
+//   - DT_VCENTER and DT_BOTTOM apply to DT_SINGLELINE text. Unless
+//     DT_NOCLIP or DT_CALCRECT is set, layout stops at the first line
+//     reaching the bottom of the rectangle.
+func LayoutText(s string, rc RECT, format UINT, dtp *DRAWTEXTPARAMS, m TextMeasure) TextLayout {
+	tabs := int32(8)
+	if format&DT_TABSTOP != 0 {
+		tabs = int32(format >> 8 & 0xFF)
+		format &^= 0xFF00
+	}
+
+	var lmargin, rmargin int32
+	if dtp != nil {
+		lmargin, rmargin = dtp.ILeftMargin, dtp.IRightMargin
+		if dtp.ITabLength > 0 {
+			tabs = dtp.ITabLength
+		}
+	}
+
+	lh := m.Height
+	if format&DT_EXTERNALLEADING != 0 {
+		lh += m.ExternalLeading
+	}
+	width := rc.Right - rc.Left - lmargin - rmargin
+
+	lo := &textLayouter{m: m, format: format, width: width, tabWidth: tabs * m.AveCharWidth}
+
+	var out TextLayout
+	y := rc.Top
+	if format&DT_SINGLELINE != 0 {
+		switch {
+		case format&DT_VCENTER != 0:
+			y = rc.Top + (rc.Bottom-rc.Top)/2 - lh/2
+		case format&DT_BOTTOM != 0:
+			y = rc.Bottom - lh
+		}
+	}
+
+	var maxWidth int32
+	unit := 0
+	for _, para := range splitTextLines(s, format&DT_SINGLELINE != 0) {
+		chars := lo.prefixChars(para.text)
+		for start := 0; ; {
+			last := format&(DT_NOCLIP|DT_CALCRECT) == 0 && y+lh > rc.Bottom
+			end, next := lo.breakLine(chars, start)
+			line := lo.line(chars[start:end])
+
+			switch {
+			case format&DT_RIGHT != 0:
+				line.X = rc.Right - rmargin - line.Width
+			case format&DT_CENTER != 0:
+				line.X = (rc.Left + lmargin + rc.Right - rmargin - line.Width) / 2
+			default:
+				line.X = rc.Left + lmargin
+			}
+			line.Y = y
+			out.Lines = append(out.Lines, line)
+			maxWidth = max(maxWidth, line.Width)
+			y += lh
+
+			if last {
+				out.Drawn = unit + para.units
+				if next < len(chars) {
+					out.Drawn = unit
+					for _, c := range chars[:next] {
+						out.Drawn += c.units
+					}
+				}
+				return finishLayout(out, rc, y, maxWidth, lmargin, rmargin)
+			}
+			if next >= len(chars) {
+				break
+			}
+			start = next
+		}
+
+		unit += para.units
+		out.Drawn = unit
+	}
+
+	return finishLayout(out, rc, y, maxWidth, lmargin, rmargin)
+}
+
+type textLayouter struct {
+	m        TextMeasure
+	format   UINT
+	width    int32
+	tabWidth int32
+}
+
+func finishLayout(out TextLayout, rc RECT, y, maxWidth, lmargin, rmargin int32) TextLayout {
+	out.Rect = RECT{rc.Left, rc.Top, rc.Left + maxWidth + lmargin + rmargin, y}
+	out.Height = y - rc.Top
+
+	return out
+}
+
+// textPara is a logical line and the number of UTF-16 code units it
+// takes in the text, including its line break.
+type textPara struct {
+	text  string
+	units int
+}
+
+func splitTextLines(s string, single bool) []textPara {
+	if single {
+		return []textPara{{s, len(utf16Units(s))}}
+	}
+
+	var paras []textPara
+	for {
+		i := strings.IndexAny(s, "\r\n")
+		if i < 0 {
+			return append(paras, textPara{s, len(utf16Units(s))})
+		}
+
+		brk := 1
+		if strings.HasPrefix(s[i:], "\r\n") {
+			brk = 2
+		}
+		paras = append(paras, textPara{s[:i], len(utf16Units(s[:i])) + brk})
+		s = s[i+brk:]
+		if s == "" {
+			return paras
+		}
+	}
+}
+
+// prefixChars removes the prefix characters of a logical line.
+func (lo *textLayouter) prefixChars(s string) []textChar {
+	rs := []rune(s)
+	chars := make([]textChar, 0, len(rs))
+	last := -1
+	for i := 0; i < len(rs); i++ {
+		units := 0
+		if rs[i] == '&' && lo.format&DT_NOPREFIX == 0 && i+1 < len(rs) {
+			i++
+			units++
+			if rs[i] != '&' {
+				last = len(chars)
+			}
+		}
+		units += len(utf16.Encode(rs[i : i+1]))
+		chars = append(chars, textChar{r: rs[i], units: units})
+	}
+	if last >= 0 && lo.format&DT_HIDEPREFIX == 0 {
+		chars[last].prefix = true
+	}
+
+	return chars
+}
+
+// advance returns the advance of c at offset x from the start of the
+// line.
+func (lo *textLayouter) advance(c rune, x int32) int32 {
+	if c == '\t' && lo.format&DT_EXPANDTABS != 0 && lo.tabWidth > 0 {
+		return (x/lo.tabWidth+1)*lo.tabWidth - x
+	}
+
+	return lo.m.Advance(c)
+}
+
+// breakLine returns the end of the line starting at chars[start] and
+// the start of the next one.
+func (lo *textLayouter) breakLine(chars []textChar, start int) (end, next int) {
+	if lo.format&DT_WORDBREAK == 0 {
+		return len(chars), len(chars)
+	}
+
+	var x int32
+	space := -1
+	for i := start; i < len(chars); i++ {
+		c := chars[i].r
+		if c == ' ' {
+			space = i
+		}
+
+		w := lo.advance(c, x)
+		if c != ' ' && x+w > lo.width && i > start {
+			switch {
+			case space >= start:
+				end = space
+				for end > start && chars[end-1].r == ' ' {
+					end--
+				}
+				next = space + 1
+			case lo.format&DT_EDITCONTROL != 0:
+				end, next = i, i
+			default:
+				// The word is kept whole and overflows the line.
+				x += w
+				continue
+			}
+			for next < len(chars) && chars[next].r == ' ' {
+				next++
+			}
+			return end, next
+		}
+		x += w
+	}
+
+	return len(chars), len(chars)
+}
+
+// line measures chars, shortening them with an ellipsis if asked to and
+// they do not fit.
+func (lo *textLayouter) line(chars []textChar) TextLine {
+	line := lo.measure(chars)
+	if line.Width <= lo.width || lo.format&(DT_END_ELLIPSIS|DT_WORD_ELLIPSIS|DT_PATH_ELLIPSIS) == 0 {
+		return line
+	}
+
+	dots := make([]textChar, len(textEllipsis))
+	for i, r := range textEllipsis {
+		dots[i] = textChar{r: r}
+	}
+	fits := func(cs []textChar) bool { return lo.measure(cs).Width <= lo.width }
+
+	if lo.format&DT_PATH_ELLIPSIS != 0 {
+		sep := -1
+		for i, c := range chars {
+			if c.r == '\\' {
+				sep = i
+			}
+		}
+		if sep > 0 {
+			head, tail := chars[:sep], chars[sep:]
+			for n := len(head) - 1; n >= 0; n-- {
+				cs := append(append(append([]textChar(nil), head[:n]...), dots...), tail...)
+				if fits(cs) || n == 0 {
+					chars = cs
+					break
+				}
+			}
+			if fits(chars) || lo.format&(DT_END_ELLIPSIS|DT_WORD_ELLIPSIS) == 0 {
+				return lo.measure(chars)
+			}
+		}
+	}
+
+	if lo.format&DT_WORD_ELLIPSIS != 0 {
+		for n := len(chars) - 1; n > 0; n-- {
+			if chars[n].r != ' ' || chars[n-1].r == ' ' {
+				continue
+			}
+			cs := append(append([]textChar(nil), chars[:n]...), dots...)
+			if fits(cs) {
+				return lo.measure(cs)
+			}
+		}
+	}
+
+	for n := len(chars) - 1; n >= 0; n-- {
+		cs := append(append([]textChar(nil), chars[:n]...), dots...)
+		if fits(cs) || n == 0 {
+			return lo.measure(cs)
+		}
+	}
+
+	return line
+}
+
+func (lo *textLayouter) measure(chars []textChar) TextLine {
+	line := TextLine{Prefix: -1}
+
+	var sb strings.Builder
+	for _, c := range chars {
+		if c.prefix {
+			line.Prefix = len(line.Dx)
+		}
+
+		w := lo.advance(c.r, line.Width)
+		line.Width += w
+		sb.WriteRune(c.r)
+
+		// A surrogate pair advances once, by its first code unit.
+		line.Dx = append(line.Dx, w)
+		if c.r >= 0x10000 {
+			line.Dx = append(line.Dx, 0)
+		}
+	}
+	line.Text = sb.String()
+
+	return line
+}
@@ -0,0 +1,221 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testMeasure is a fixed pitch font with 10 pixel wide characters on
+// 16 pixel lines.
+var testMeasure = TextMeasure{
+	Advance:         func(r rune) int32 { return 10 },
+	Height:          16,
+	ExternalLeading: 2,
+	AveCharWidth:    10,
+}
+
+func layoutLines(l TextLayout) []string {
+	var lines []string
+	for _, line := range l.Lines {
+		lines = append(lines, line.Text)
+	}
+
+	return lines
+}
+
+func TestLayoutTextBreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		width  int32
+		format UINT
+		want   []string
+	}{
+		{"no wordbreak", "aaa bbb ccc", 70, 0, []string{"aaa bbb ccc"}},
+		{"wordbreak", "aaa bbb ccc", 70, DT_WORDBREAK, []string{"aaa bbb", "ccc"}},
+		{"spaces at break", "aa   bb", 30, DT_WORDBREAK, []string{"aa", "bb"}},
+		{"trailing space fits", "aaa bbb", 30, DT_WORDBREAK, []string{"aaa", "bbb"}},
+		{"long word", "abcdefgh ij", 50, DT_WORDBREAK, []string{"abcdefgh", "ij"}},
+		{"long word then words", "abcdefgh ij kl mn", 50, DT_WORDBREAK, []string{"abcdefgh", "ij kl", "mn"}},
+		{"long word last", "ij abcdefgh", 50, DT_WORDBREAK, []string{"ij", "abcdefgh"}},
+		{"edit control", "abcdefgh", 50, DT_WORDBREAK | DT_EDITCONTROL, []string{"abcde", "fgh"}},
+		{"line breaks", "a\r\nb\nc\rd", 100, 0, []string{"a", "b", "c", "d"}},
+		{"trailing line break", "a\n", 100, 0, []string{"a"}},
+		{"empty lines", "a\n\nb", 100, 0, []string{"a", "", "b"}},
+		{"single line", "a\r\nb", 100, DT_SINGLELINE | DT_WORDBREAK, []string{"a\r\nb"}},
+	}
+	for _, tt := range tests {
+		l := LayoutText(tt.s, RECT{0, 0, tt.width, 1000}, tt.format, nil, testMeasure)
+		if got := layoutLines(l); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: lines = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutTextBreakProportional(t *testing.T) {
+	// After a word wider than the line the next words must not fit in
+	// the room that word would have left.
+	m := testMeasure
+	m.Advance = func(r rune) int32 {
+		switch r {
+		case 'W':
+			return 20
+		case ' ', 'i':
+			return 1
+		}
+		return 10
+	}
+	l := LayoutText("aaaaW ii", RECT{0, 0, 50, 1000}, DT_WORDBREAK, nil, m)
+	if got, want := layoutLines(l), []string{"aaaaW", "ii"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutTextTabs(t *testing.T) {
+	tests := []struct {
+		name   string
+		format UINT
+		dtp    *DRAWTEXTPARAMS
+		want   []int32
+	}{
+		{"not expanded", 0, nil, []int32{10, 10, 10}},
+		{"default", DT_EXPANDTABS, nil, []int32{10, 70, 10}},
+		{"tabstop", DT_EXPANDTABS | DT_TABSTOP | 4<<8, nil, []int32{10, 30, 10}},
+		{"params", DT_EXPANDTABS, &DRAWTEXTPARAMS{ITabLength: 2}, []int32{10, 10, 10}},
+	}
+	for _, tt := range tests {
+		l := LayoutText("a\tb", RECT{0, 0, 1000, 1000}, tt.format, tt.dtp, testMeasure)
+		if got := l.Lines[0].Dx; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Dx = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A tab takes part in breaking like any other character.
+	l := LayoutText("ab\tc d", RECT{0, 0, 90, 1000}, DT_WORDBREAK|DT_EXPANDTABS, nil, testMeasure)
+	if got, want := layoutLines(l), []string{"ab\tc", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tab wordbreak: lines = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutTextPrefix(t *testing.T) {
+	tests := []struct {
+		format UINT
+		text   string
+		prefix int
+	}{
+		{0, "File & Exit", 7},
+		{DT_HIDEPREFIX, "File & Exit", -1},
+		{DT_NOPREFIX, "&File && &Exit", -1},
+	}
+	for _, tt := range tests {
+		line := LayoutText("&File && &Exit", RECT{0, 0, 1000, 1000}, tt.format, nil, testMeasure).Lines[0]
+		if line.Text != tt.text || line.Prefix != tt.prefix {
+			t.Errorf("format %#x: %q prefix %d, want %q prefix %d", tt.format, line.Text, line.Prefix, tt.text, tt.prefix)
+		}
+	}
+}
+
+func TestLayoutTextEllipsis(t *testing.T) {
+	const path = `c:\dir\sub\file.txt`
+	tests := []struct {
+		name   string
+		s      string
+		width  int32
+		format UINT
+		want   string
+	}{
+		{"fits", "one two", 100, DT_END_ELLIPSIS | DT_WORD_ELLIPSIS, "one two"},
+		{"end", "one two three", 90, DT_END_ELLIPSIS, "one tw..."},
+		{"word", "one two three", 90, DT_WORD_ELLIPSIS, "one..."},
+		{"word at space", "one two three", 100, DT_WORD_ELLIPSIS, "one two..."},
+		{"word spaces", "one  two three", 100, DT_WORD_ELLIPSIS, "one..."},
+		{"word without space", "abcdefghij", 50, DT_WORD_ELLIPSIS, "ab..."},
+		{"word too long", "abcdefghij kl", 50, DT_WORD_ELLIPSIS, "ab..."},
+		{"nothing fits", "abcdefghij", 20, DT_END_ELLIPSIS, "..."},
+		{"path", path, 150, DT_PATH_ELLIPSIS, `c:\...\file.txt`},
+		{"path to file", path, 120, DT_PATH_ELLIPSIS, `...\file.txt`},
+		{"path too long", path, 100, DT_PATH_ELLIPSIS, `...\file.txt`},
+		{"path then end", path, 100, DT_PATH_ELLIPSIS | DT_END_ELLIPSIS, `...\fil...`},
+		{"path without separator", "abcdefghij", 50, DT_PATH_ELLIPSIS, "ab..."},
+	}
+	for _, tt := range tests {
+		line := LayoutText(tt.s, RECT{0, 0, tt.width, 1000}, tt.format, nil, testMeasure).Lines[0]
+		if line.Text != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, line.Text, tt.want)
+		}
+		if int(line.Width) != 10*len(line.Dx) {
+			t.Errorf("%s: width %d does not match Dx %v", tt.name, line.Width, line.Dx)
+		}
+	}
+}
+
+func TestLayoutTextPosition(t *testing.T) {
+	rc := RECT{0, 0, 100, 100}
+	tests := []struct {
+		name   string
+		format UINT
+		x, y   int32
+		height int32
+	}{
+		{"left", DT_SINGLELINE, 0, 0, 16},
+		{"center", DT_SINGLELINE | DT_CENTER, 40, 0, 16},
+		{"right", DT_SINGLELINE | DT_RIGHT, 80, 0, 16},
+		{"vcenter", DT_SINGLELINE | DT_VCENTER, 0, 42, 58},
+		{"bottom", DT_SINGLELINE | DT_BOTTOM, 0, 84, 100},
+		{"vcenter multiline", DT_VCENTER, 0, 0, 16},
+	}
+	for _, tt := range tests {
+		l := LayoutText("ab", rc, tt.format, nil, testMeasure)
+		if line := l.Lines[0]; line.X != tt.x || line.Y != tt.y || l.Height != tt.height {
+			t.Errorf("%s: line at %d,%d height %d, want %d,%d height %d", tt.name, line.X, line.Y, l.Height, tt.x, tt.y, tt.height)
+		}
+	}
+
+	dtp := &DRAWTEXTPARAMS{ILeftMargin: 5, IRightMargin: 15}
+	l := LayoutText("aaa bbb", rc, DT_WORDBREAK|DT_RIGHT, dtp, testMeasure)
+	if got, want := layoutLines(l), []string{"aaa bbb"}; !reflect.DeepEqual(got, want) || l.Lines[0].X != 15 {
+		t.Errorf("margins: lines %q at %d, want %q at 15", got, l.Lines[0].X, want)
+	}
+	l = LayoutText("aaa bbb", RECT{0, 0, 80, 100}, DT_WORDBREAK, dtp, testMeasure)
+	if got, want := layoutLines(l), []string{"aaa", "bbb"}; !reflect.DeepEqual(got, want) || l.Lines[1].X != 5 {
+		t.Errorf("narrow margins: lines %q at %d, want %q at 5", got, l.Lines[1].X, want)
+	}
+}
+
+func TestLayoutTextClip(t *testing.T) {
+	rc := RECT{0, 0, 100, 20}
+	tests := []struct {
+		format UINT
+		lines  int
+		drawn  int
+		rect   RECT
+	}{
+		{0, 2, 4, RECT{0, 0, 10, 32}},
+		{DT_NOCLIP, 3, 5, RECT{0, 0, 10, 48}},
+		{DT_CALCRECT, 3, 5, RECT{0, 0, 10, 48}},
+		{DT_EXTERNALLEADING, 2, 4, RECT{0, 0, 10, 36}},
+	}
+	for _, tt := range tests {
+		l := LayoutText("a\nb\nc", rc, tt.format, nil, testMeasure)
+		if len(l.Lines) != tt.lines || l.Drawn != tt.drawn || l.Rect != tt.rect {
+			t.Errorf("format %#x: %d lines, %d drawn, rect %v, want %d, %d, %v", tt.format, len(l.Lines), l.Drawn, l.Rect, tt.lines, tt.drawn, tt.rect)
+		}
+	}
+
+	// A clipped wrapped line counts the units up to its break.
+	l := LayoutText("aaa bbb ccc", RECT{0, 0, 30, 20}, DT_WORDBREAK, nil, testMeasure)
+	if got, want := layoutLines(l), []string{"aaa", "bbb"}; !reflect.DeepEqual(got, want) || l.Drawn != 8 {
+		t.Errorf("wrapped: lines %q, drawn %d, want %q, 8", got, l.Drawn, want)
+	}
+}
+
+func TestLayoutTextSurrogates(t *testing.T) {
+	line := LayoutText("a\U0001F600", RECT{0, 0, 100, 100}, 0, nil, testMeasure).Lines[0]
+	if want := []int32{10, 10, 0}; !reflect.DeepEqual(line.Dx, want) || line.Width != 20 {
+		t.Errorf("Dx = %v, width %d, want %v, 20", line.Dx, line.Width, want)
+	}
+}
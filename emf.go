@@ -0,0 +1,685 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"unicode/utf16"
+)
+
+var ErrEMFInvalid = errors.New("winapi: invalid enhanced metafile")
+
+// ENHMETA_SIGNATURE is the dSignature of an enhanced metafile header,
+// " EMF" in little endian order.
+const ENHMETA_SIGNATURE = 0x464D4520
+
+const (
+	emfHeaderSize = 108
+	emfVersion    = 0x00010000
+)
+
+// EMFRecord is a record of an enhanced metafile as DecodeEMF returns it
+// and EncodeEMF takes it. Records without a type of their own are
+// returned as *EMRRaw, so that a decoded metafile encodes to the same
+// drawing.
+type EMFRecord interface {
+	// Type returns the EMR_ type of the record.
+	Type() DWORD
+	// encode returns the record without its type and size.
+	encode() ([]byte, error)
+}
+
+// EMRHeader is the EMR_HEADER record that starts every enhanced
+// metafile.
+type EMRHeader struct {
+	// Bounds is the inclusive bounding rectangle of the drawing in
+	// device units and Frame the rectangle of the picture in .01 mm.
+	Bounds RECT
+	Frame  RECT
+	// Version, Bytes, Records and PalEntries are filled in by
+	// EncodeEMF: the format version, the size of the metafile, the
+	// number of its records and the number of palette entries of its
+	// EMR_EOF record.
+	Version    DWORD
+	Bytes      DWORD
+	Records    DWORD
+	Handles    WORD
+	PalEntries DWORD
+	// Description is stored as is, normally an application name and a
+	// picture title each followed by a NUL.
+	Description string
+	// Device is the size of the reference device in pixels, and
+	// Millimeters and Micrometers its size in those units.
+	Device      SIZE
+	Millimeters SIZE
+	Micrometers SIZE
+	PixelFormat []byte
+	OpenGL      bool
+}
+
+func (r *EMRHeader) Type() DWORD { return EMR_HEADER }
+
+// EMRPoly is one of the EMR_POLYBEZIER, EMR_POLYGON, EMR_POLYLINE,
+// EMR_POLYBEZIERTO and EMR_POLYLINETO records, or their variants with
+// 16-bit coordinates, EMR_POLYLINE16 and so on.
+type EMRPoly struct {
+	Kind   DWORD
+	Bounds RECT
+	Points []POINT
+}
+
+func (r *EMRPoly) Type() DWORD { return r.Kind }
+
+// EMRExtTextOut is the EMR_EXTTEXTOUTW record that ExtTextOut and
+// TextOut write.
+type EMRExtTextOut struct {
+	Bounds       RECT
+	GraphicsMode DWORD
+	// XScale and YScale scale page units to .01 mm in GM_COMPATIBLE
+	// mode.
+	XScale, YScale float32
+	Reference      POINT
+	Options        UINT
+	// Rect is only stored without ETO_NO_RECT.
+	Rect RECT
+	// Text is the text, or with ETO_GLYPH_INDEX, Glyphs the glyph
+	// indices.
+	Text   string
+	Glyphs []uint16
+	// Dx holds an advance for each code unit, or with ETO_PDY an
+	// advance and a rise.
+	Dx []int32
+}
+
+func (r *EMRExtTextOut) Type() DWORD { return EMR_EXTTEXTOUTW }
+
+// EMRBitBlt is the EMR_BITBLT record, or with a Kind of EMR_STRETCHBLT
+// the EMR_STRETCHBLT record. BitmapInfo and Bits are the BITMAPINFO
+// and pixels of the source, and are empty when the operation does not
+// use one.
+type EMRBitBlt struct {
+	Kind                DWORD
+	Bounds              RECT
+	X, Y, Width, Height int32
+	Rop                 DWORD
+	XSrc, YSrc          int32
+	XformSrc            XFORM
+	BkColorSrc          COLORREF
+	UsageSrc            DWORD
+	BitmapInfo          []byte
+	Bits                []byte
+	// SrcWidth and SrcHeight are the size of the source for
+	// EMR_STRETCHBLT.
+	SrcWidth, SrcHeight int32
+}
+
+func (r *EMRBitBlt) Type() DWORD { return r.Kind }
+
+// EMRSaveDC is the EMR_SAVEDC record.
+type EMRSaveDC struct{}
+
+func (r *EMRSaveDC) Type() DWORD { return EMR_SAVEDC }
+
+// EMRRestoreDC is the EMR_RESTOREDC record. SavedDC is negative, the
+// number of states to go back.
+type EMRRestoreDC struct {
+	SavedDC int32
+}
+
+func (r *EMRRestoreDC) Type() DWORD { return EMR_RESTOREDC }
+
+// EMREOF is the EMR_EOF record that ends every enhanced metafile.
+type EMREOF struct {
+	Palette []PALETTEENTRY
+}
+
+func (r *EMREOF) Type() DWORD { return EMR_EOF }
+
+// EMRRaw is a record of any other type. Data is the record without its
+// type and size.
+type EMRRaw struct {
+	Kind DWORD
+	Data []byte
+}
+
+func (r *EMRRaw) Type() DWORD { return r.Kind }
+
+// DecodeEMF decodes the records of an enhanced metafile, as
+// GetEnhMetaFileBits returns it, from the EMR_HEADER record to the
+// EMR_EOF record.
+func DecodeEMF(b []byte) ([]EMFRecord, error) {
+	var recs []EMFRecord
+	for {
+		if len(b) < 8 {
+			return nil, ErrEMFInvalid
+		}
+
+		typ := DWORD(binary.LittleEndian.Uint32(b))
+		size := binary.LittleEndian.Uint32(b[4:])
+		if size < 8 || size%4 != 0 || uint64(size) > uint64(len(b)) {
+			return nil, ErrEMFInvalid
+		}
+		if (len(recs) == 0) != (typ == EMR_HEADER) {
+			return nil, ErrEMFInvalid
+		}
+
+		rec, err := decodeEMFRecord(typ, b[:size])
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+		if typ == EMR_EOF {
+			return recs, nil
+		}
+		b = b[size:]
+	}
+}
+
+func decodeEMFRecord(typ DWORD, b []byte) (EMFRecord, error) {
+	switch typ {
+	case EMR_HEADER:
+		return decodeEMRHeader(b)
+	case EMR_POLYBEZIER, EMR_POLYGON, EMR_POLYLINE, EMR_POLYBEZIERTO, EMR_POLYLINETO,
+		EMR_POLYBEZIER16, EMR_POLYGON16, EMR_POLYLINE16, EMR_POLYBEZIERTO16, EMR_POLYLINETO16:
+		return decodeEMRPoly(typ, b)
+	case EMR_EXTTEXTOUTW:
+		return decodeEMRExtTextOut(b)
+	case EMR_BITBLT, EMR_STRETCHBLT:
+		return decodeEMRBitBlt(typ, b)
+	case EMR_SAVEDC:
+		return &EMRSaveDC{}, nil
+	case EMR_RESTOREDC:
+		if len(b) < 12 {
+			return nil, ErrEMFInvalid
+		}
+		return &EMRRestoreDC{int32(binary.LittleEndian.Uint32(b[8:]))}, nil
+	case EMR_EOF:
+		return decodeEMREOF(b)
+	}
+
+	return &EMRRaw{typ, append([]byte(nil), b[8:]...)}, nil
+}
+
+// emfData returns the n bytes at off of the record b, or false if they
+// are not within it.
+func emfData(b []byte, off uint32, n uint64) ([]byte, bool) {
+	if uint64(off)+n > uint64(len(b)) {
+		return nil, false
+	}
+
+	return b[off : uint64(off)+n], true
+}
+
+func decodeEMRHeader(b []byte) (*EMRHeader, error) {
+	if len(b) < 88 || binary.LittleEndian.Uint32(b[40:]) != ENHMETA_SIGNATURE {
+		return nil, ErrEMFInvalid
+	}
+
+	le := binary.LittleEndian
+	h := &EMRHeader{
+		Bounds:      getRect(b[8:]),
+		Frame:       getRect(b[24:]),
+		Version:     DWORD(le.Uint32(b[44:])),
+		Bytes:       DWORD(le.Uint32(b[48:])),
+		Records:     DWORD(le.Uint32(b[52:])),
+		Handles:     WORD(le.Uint16(b[56:])),
+		PalEntries:  DWORD(le.Uint32(b[68:])),
+		Device:      getSize(b[72:]),
+		Millimeters: getSize(b[80:]),
+	}
+
+	// The extensions are only present if the description and pixel
+	// format do not start where they would be.
+	end := uint32(len(b))
+	nDesc, offDesc := le.Uint32(b[60:]), le.Uint32(b[64:])
+	if nDesc > 0 {
+		desc, ok := emfData(b, offDesc, 2*uint64(nDesc))
+		if !ok || offDesc < 88 {
+			return nil, ErrEMFInvalid
+		}
+		h.Description = decodeUTF16LE(desc)
+		end = min(end, offDesc)
+	}
+	if end >= 100 {
+		cb, off := le.Uint32(b[88:]), le.Uint32(b[92:])
+		if cb > 0 {
+			pf, ok := emfData(b, off, uint64(cb))
+			if !ok || off < 100 {
+				return nil, ErrEMFInvalid
+			}
+			h.PixelFormat = append([]byte(nil), pf...)
+			end = min(end, off)
+		}
+		h.OpenGL = le.Uint32(b[96:]) != 0
+	}
+	if end >= emfHeaderSize {
+		h.Micrometers = getSize(b[100:])
+	}
+
+	return h, nil
+}
+
+func (r *EMRHeader) encode() ([]byte, error) {
+	desc := utf16Units(r.Description)
+
+	b := make([]byte, emfHeaderSize-8, emfHeaderSize-8+2*len(desc)+len(r.PixelFormat)+6)
+	le := binary.LittleEndian
+	putRect(b, r.Bounds)
+	putRect(b[16:], r.Frame)
+	le.PutUint32(b[32:], ENHMETA_SIGNATURE)
+	le.PutUint32(b[36:], uint32(r.Version))
+	le.PutUint32(b[40:], uint32(r.Bytes))
+	le.PutUint32(b[44:], uint32(r.Records))
+	le.PutUint16(b[48:], uint16(r.Handles))
+	le.PutUint32(b[60:], uint32(r.PalEntries))
+	putSize(b[64:], r.Device)
+	putSize(b[72:], r.Millimeters)
+	putSize(b[92:], r.Micrometers)
+	if r.OpenGL {
+		le.PutUint32(b[88:], 1)
+	}
+
+	if len(desc) > 0 {
+		le.PutUint32(b[52:], uint32(len(desc)))
+		le.PutUint32(b[56:], uint32(8+len(b)))
+		b = appendUTF16LE(b, desc)
+		b = padEMF(b)
+	}
+	if len(r.PixelFormat) > 0 {
+		le.PutUint32(b[80:], uint32(len(r.PixelFormat)))
+		le.PutUint32(b[84:], uint32(8+len(b)))
+		b = append(b, r.PixelFormat...)
+		b = padEMF(b)
+	}
+
+	return b, nil
+}
+
+// isPoly16 reports whether typ is a poly record with 16-bit coordinates.
+func isPoly16(typ DWORD) bool {
+	return typ >= EMR_POLYBEZIER16 && typ <= EMR_POLYLINETO16
+}
+
+func decodeEMRPoly(typ DWORD, b []byte) (*EMRPoly, error) {
+	if len(b) < 28 {
+		return nil, ErrEMFInvalid
+	}
+
+	size := uint64(8)
+	if isPoly16(typ) {
+		size = 4
+	}
+	n := binary.LittleEndian.Uint32(b[24:])
+	data, ok := emfData(b, 28, uint64(n)*size)
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+
+	r := &EMRPoly{Kind: typ, Bounds: getRect(b[8:]), Points: make([]POINT, n)}
+	for i := range r.Points {
+		if size == 4 {
+			r.Points[i] = POINT{
+				int32(int16(binary.LittleEndian.Uint16(data[4*i:]))),
+				int32(int16(binary.LittleEndian.Uint16(data[4*i+2:]))),
+			}
+		} else {
+			r.Points[i] = POINT{
+				int32(binary.LittleEndian.Uint32(data[8*i:])),
+				int32(binary.LittleEndian.Uint32(data[8*i+4:])),
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (r *EMRPoly) encode() ([]byte, error) {
+	switch {
+	case r.Kind >= EMR_POLYBEZIER && r.Kind <= EMR_POLYLINETO, isPoly16(r.Kind):
+	default:
+		return nil, ErrEMFInvalid
+	}
+
+	b := make([]byte, 20, 20+8*len(r.Points))
+	putRect(b, r.Bounds)
+	binary.LittleEndian.PutUint32(b[16:], uint32(len(r.Points)))
+	for _, pt := range r.Points {
+		if !isPoly16(r.Kind) {
+			b = binary.LittleEndian.AppendUint32(b, uint32(pt.X))
+			b = binary.LittleEndian.AppendUint32(b, uint32(pt.Y))
+			continue
+		}
+		if pt.X != int32(int16(pt.X)) || pt.Y != int32(int16(pt.Y)) {
+			return nil, ErrEMFInvalid
+		}
+		b = binary.LittleEndian.AppendUint16(b, uint16(pt.X))
+		b = binary.LittleEndian.AppendUint16(b, uint16(pt.Y))
+	}
+
+	return b, nil
+}
+
+// The offsets of the EMRTEXT of an EMR_EXTTEXTOUTW record.
+const (
+	emrTextOffset = 36
+	emrTextSize   = 40
+)
+
+func decodeEMRExtTextOut(b []byte) (*EMRExtTextOut, error) {
+	if len(b) < emrTextOffset+emrTextSize-16 {
+		return nil, ErrEMFInvalid
+	}
+
+	le := binary.LittleEndian
+	t := b[emrTextOffset:]
+	r := &EMRExtTextOut{
+		Bounds:       getRect(b[8:]),
+		GraphicsMode: DWORD(le.Uint32(b[24:])),
+		XScale:       math.Float32frombits(le.Uint32(b[28:])),
+		YScale:       math.Float32frombits(le.Uint32(b[32:])),
+		Reference:    POINT{int32(le.Uint32(t)), int32(le.Uint32(t[4:]))},
+		Options:      UINT(le.Uint32(t[16:])),
+	}
+
+	n, offString := le.Uint32(t[8:]), le.Uint32(t[12:])
+	offDx := t[20:]
+	if r.Options&ETO_NO_RECT == 0 {
+		if len(b) < emrTextOffset+emrTextSize {
+			return nil, ErrEMFInvalid
+		}
+		r.Rect = getRect(t[20:])
+		offDx = t[36:]
+	}
+
+	s, ok := emfData(b, offString, 2*uint64(n))
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+	if r.Options&ETO_GLYPH_INDEX != 0 {
+		r.Glyphs = make([]uint16, n)
+		for i := range r.Glyphs {
+			r.Glyphs[i] = le.Uint16(s[2*i:])
+		}
+	} else {
+		r.Text = decodeUTF16LE(s)
+	}
+
+	if off := le.Uint32(offDx); off != 0 {
+		if r.Options&ETO_PDY != 0 {
+			n *= 2
+		}
+		dx, ok := emfData(b, off, 4*uint64(n))
+		if !ok {
+			return nil, ErrEMFInvalid
+		}
+		r.Dx = make([]int32, n)
+		for i := range r.Dx {
+			r.Dx[i] = int32(le.Uint32(dx[4*i:]))
+		}
+	}
+
+	return r, nil
+}
+
+func (r *EMRExtTextOut) encode() ([]byte, error) {
+	units := r.Glyphs
+	if r.Options&ETO_GLYPH_INDEX == 0 {
+		units = utf16Units(r.Text)
+	}
+	if r.Dx != nil && !checkDx(r.Dx, len(units), r.Options) {
+		return nil, ErrTextDx
+	}
+
+	size := emrTextOffset + emrTextSize - 8
+	if r.Options&ETO_NO_RECT != 0 {
+		size -= 16
+	}
+	b := make([]byte, size, size+2*len(units)+4*len(r.Dx)+2)
+
+	le := binary.LittleEndian
+	putRect(b, r.Bounds)
+	le.PutUint32(b[16:], uint32(r.GraphicsMode))
+	le.PutUint32(b[20:], math.Float32bits(r.XScale))
+	le.PutUint32(b[24:], math.Float32bits(r.YScale))
+	t := b[emrTextOffset-8:]
+	le.PutUint32(t, uint32(r.Reference.X))
+	le.PutUint32(t[4:], uint32(r.Reference.Y))
+	le.PutUint32(t[8:], uint32(len(units)))
+	le.PutUint32(t[12:], uint32(8+len(b)))
+	le.PutUint32(t[16:], uint32(r.Options))
+	offDx := t[20:]
+	if r.Options&ETO_NO_RECT == 0 {
+		putRect(t[20:], r.Rect)
+		offDx = t[36:]
+	}
+
+	b = appendUTF16LE(b, units)
+	b = padEMF(b)
+	if r.Dx != nil {
+		le.PutUint32(offDx, uint32(8+len(b)))
+		for _, d := range r.Dx {
+			b = le.AppendUint32(b, uint32(d))
+		}
+	}
+
+	return b, nil
+}
+
+// The size of the EMR_BITBLT record up to the source bitmap.
+const emrBitBltSize = 100
+
+func decodeEMRBitBlt(typ DWORD, b []byte) (*EMRBitBlt, error) {
+	size := emrBitBltSize
+	if typ == EMR_STRETCHBLT {
+		size += 8
+	}
+	if len(b) < size {
+		return nil, ErrEMFInvalid
+	}
+
+	le := binary.LittleEndian
+	i32 := func(off int) int32 { return int32(le.Uint32(b[off:])) }
+	f32 := func(off int) float32 { return math.Float32frombits(le.Uint32(b[off:])) }
+	r := &EMRBitBlt{
+		Kind:       typ,
+		Bounds:     getRect(b[8:]),
+		X:          i32(24),
+		Y:          i32(28),
+		Width:      i32(32),
+		Height:     i32(36),
+		Rop:        DWORD(le.Uint32(b[40:])),
+		XSrc:       i32(44),
+		YSrc:       i32(48),
+		XformSrc:   XFORM{f32(52), f32(56), f32(60), f32(64), f32(68), f32(72)},
+		BkColorSrc: COLORREF(le.Uint32(b[76:])),
+		UsageSrc:   DWORD(le.Uint32(b[80:])),
+	}
+	if typ == EMR_STRETCHBLT {
+		r.SrcWidth, r.SrcHeight = i32(100), i32(104)
+	}
+
+	bmi, ok := emfData(b, le.Uint32(b[84:]), uint64(le.Uint32(b[88:])))
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+	bits, ok := emfData(b, le.Uint32(b[92:]), uint64(le.Uint32(b[96:])))
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+	if len(bmi) > 0 {
+		r.BitmapInfo = append([]byte(nil), bmi...)
+	}
+	if len(bits) > 0 {
+		r.Bits = append([]byte(nil), bits...)
+	}
+
+	return r, nil
+}
+
+func (r *EMRBitBlt) encode() ([]byte, error) {
+	size := emrBitBltSize - 8
+	switch r.Kind {
+	case EMR_BITBLT:
+	case EMR_STRETCHBLT:
+		size += 8
+	default:
+		return nil, ErrEMFInvalid
+	}
+
+	b := make([]byte, size, size+len(r.BitmapInfo)+len(r.Bits)+6)
+	le := binary.LittleEndian
+	putRect(b, r.Bounds)
+	for i, v := range []uint32{
+		uint32(r.X), uint32(r.Y), uint32(r.Width), uint32(r.Height),
+		uint32(r.Rop), uint32(r.XSrc), uint32(r.YSrc),
+		math.Float32bits(r.XformSrc.EM11), math.Float32bits(r.XformSrc.EM12),
+		math.Float32bits(r.XformSrc.EM21), math.Float32bits(r.XformSrc.EM22),
+		math.Float32bits(r.XformSrc.EDx), math.Float32bits(r.XformSrc.EDy),
+		uint32(r.BkColorSrc), uint32(r.UsageSrc),
+	} {
+		le.PutUint32(b[16+4*i:], v)
+	}
+	if r.Kind == EMR_STRETCHBLT {
+		le.PutUint32(b[92:], uint32(r.SrcWidth))
+		le.PutUint32(b[96:], uint32(r.SrcHeight))
+	}
+
+	if len(r.BitmapInfo) > 0 {
+		le.PutUint32(b[76:], uint32(8+len(b)))
+		le.PutUint32(b[80:], uint32(len(r.BitmapInfo)))
+		b = padEMF(append(b, r.BitmapInfo...))
+	}
+	if len(r.Bits) > 0 {
+		le.PutUint32(b[84:], uint32(8+len(b)))
+		le.PutUint32(b[88:], uint32(len(r.Bits)))
+		b = padEMF(append(b, r.Bits...))
+	}
+
+	return b, nil
+}
+
+func (r *EMRSaveDC) encode() ([]byte, error) {
+	return nil, nil
+}
+
+func (r *EMRRestoreDC) encode() ([]byte, error) {
+	return binary.LittleEndian.AppendUint32(nil, uint32(r.SavedDC)), nil
+}
+
+func decodeEMREOF(b []byte) (*EMREOF, error) {
+	if len(b) < 20 {
+		return nil, ErrEMFInvalid
+	}
+
+	n := binary.LittleEndian.Uint32(b[8:])
+	pal, ok := emfData(b, binary.LittleEndian.Uint32(b[12:]), 4*uint64(n))
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+
+	r := &EMREOF{}
+	for i := 0; i < len(pal); i += 4 {
+		r.Palette = append(r.Palette, PALETTEENTRY{pal[i], pal[i+1], pal[i+2], pal[i+3]})
+	}
+
+	return r, nil
+}
+
+func (r *EMREOF) encode() ([]byte, error) {
+	b := make([]byte, 8, 12+4*len(r.Palette))
+	binary.LittleEndian.PutUint32(b, uint32(len(r.Palette)))
+	binary.LittleEndian.PutUint32(b[4:], 16)
+	for _, e := range r.Palette {
+		b = append(b, e.PeRed, e.PeGreen, e.PeBlue, e.PeFlags)
+	}
+
+	// nSizeLast repeats the size of the record.
+	return binary.LittleEndian.AppendUint32(b, uint32(8+len(b)+4)), nil
+}
+
+func (r *EMRRaw) encode() ([]byte, error) {
+	return padEMF(append([]byte(nil), r.Data...)), nil
+}
+
+// EncodeEMF encodes recs, which must start with an *EMRHeader and end
+// with an *EMREOF, as an enhanced metafile that SetEnhMetaFileBits
+// accepts. The version, size, record count and palette entry count of
+// the header are filled in; the header in recs is not changed.
+func EncodeEMF(recs []EMFRecord) ([]byte, error) {
+	if len(recs) < 2 {
+		return nil, ErrEMFInvalid
+	}
+	hdr, ok := recs[0].(*EMRHeader)
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+	eof, ok := recs[len(recs)-1].(*EMREOF)
+	if !ok {
+		return nil, ErrEMFInvalid
+	}
+
+	h := *hdr
+	if h.Version == 0 {
+		h.Version = emfVersion
+	}
+	h.Records = DWORD(len(recs))
+	h.PalEntries = DWORD(len(eof.Palette))
+
+	var out []byte
+	for i, rec := range recs {
+		if i == 0 {
+			rec = &h
+		} else if rec.Type() == EMR_HEADER || (rec.Type() == EMR_EOF && i != len(recs)-1) {
+			return nil, ErrEMFInvalid
+		}
+
+		body, err := rec.encode()
+		if err != nil {
+			return nil, err
+		}
+		out = binary.LittleEndian.AppendUint32(out, uint32(rec.Type()))
+		out = binary.LittleEndian.AppendUint32(out, uint32(8+len(body)))
+		out = append(out, body...)
+	}
+	binary.LittleEndian.PutUint32(out[48:], uint32(len(out)))
+
+	return out, nil
+}
+
+func padEMF(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+
+	return b
+}
+
+func getSize(b []byte) SIZE {
+	return SIZE{int32(binary.LittleEndian.Uint32(b)), int32(binary.LittleEndian.Uint32(b[4:]))}
+}
+
+func putSize(b []byte, s SIZE) {
+	binary.LittleEndian.PutUint32(b, uint32(s.Cx))
+	binary.LittleEndian.PutUint32(b[4:], uint32(s.Cy))
+}
+
+func decodeUTF16LE(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+
+	return string(utf16.Decode(u))
+}
+
+func appendUTF16LE(b []byte, u []uint16) []byte {
+	for _, c := range u {
+		b = binary.LittleEndian.AppendUint16(b, c)
+	}
+
+	return b
+}
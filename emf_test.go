@@ -0,0 +1,293 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testEMF is laid out as GDI records a metafile created with
+// CreateEnhMetaFile on a 1920x1080 96 DPI screen, with a 20x10 mm frame
+// and the description "winapi\0test\0\0", in which a line, a rectangle, a
+// polyline, a SaveDC and RestoreDC pair, TextOut, PatBlt and a
+// StretchBlt of a 1x1 bitmap were drawn.
+const testEMF = "" +
+	// EMR_HEADER
+	"01000000880000000000000000000000640000003b0000000000000000000000" +
+	"d0070000e803000020454d4600000100700200000c000000010000000d000000" +
+	"6c000000000000008007000038040000fc0100001d0100000000000000000000" +
+	"0000000060c00700365c0400770069006e006100700069000000740065007300" +
+	"7400000000000000" +
+	// EMR_MOVETOEX
+	"1b000000100000000a0000000a000000" +
+	// EMR_LINETO
+	"36000000100000006400000032000000" +
+	// EMR_SETBKMODE
+	"120000000c00000001000000" +
+	// EMR_RECTANGLE
+	"2b000000180000001400000014000000500000003c000000" +
+	// EMR_POLYLINE16
+	"570000002800000000000000000000001e000000140000000300000000000000" +
+	"0a0014001e000500" +
+	// EMR_SAVEDC
+	"2100000008000000" +
+	// EMR_RESTOREDC
+	"220000000c000000ffffffff" +
+	// EMR_EXTTEXTOUTW
+	"54000000580000000500000005000000140000001500000001000000abaad341" +
+	"abaad3410500000005000000020000004c000000000000000000000000000000" +
+	"ffffffffffffffff50000000480069000800000007000000" +
+	// EMR_BITBLT
+	"4c00000064000000000000000000000009000000090000000000000000000000" +
+	"0a0000000a0000002100f00000000000000000000000803f0000000000000000" +
+	"0000803f00000000000000000000000000000000000000000000000000000000" +
+	"00000000" +
+	// EMR_STRETCHBLT
+	"4d000000980000001e0000001e00000027000000270000001e0000001e000000" +
+	"0a0000000a0000002000cc0000000000000000000000803f0000000000000000" +
+	"0000803f0000000000000000ffffff00000000006c0000002800000094000000" +
+	"0400000001000000010000002800000001000000010000000100200000000000" +
+	"0400000000000000000000000000000000000000102030ff" +
+	// EMR_EOF
+	"0e00000014000000000000001000000014000000"
+
+func testEMFBytes(t *testing.T) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(testEMF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestEMFRoundTrip(t *testing.T) {
+	b := testEMFBytes(t)
+
+	recs, err := DecodeEMF(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []DWORD
+	for _, r := range recs {
+		types = append(types, r.Type())
+	}
+	want := []DWORD{
+		EMR_HEADER, EMR_MOVETOEX, EMR_LINETO, EMR_SETBKMODE, EMR_RECTANGLE,
+		EMR_POLYLINE16, EMR_SAVEDC, EMR_RESTOREDC, EMR_EXTTEXTOUTW,
+		EMR_BITBLT, EMR_STRETCHBLT, EMR_EOF,
+	}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("record types = %v, want %v", types, want)
+	}
+
+	out, err := EncodeEMF(recs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, b) {
+		t.Errorf("EncodeEMF(DecodeEMF(b)) differs from b:\n got %x\nwant %x", out, b)
+	}
+}
+
+func TestDecodeEMFRecords(t *testing.T) {
+	recs, err := DecodeEMF(testEMFBytes(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hdr := &EMRHeader{
+		Bounds:      RECT{0, 0, 100, 59},
+		Frame:       RECT{0, 0, 2000, 1000},
+		Version:     0x10000,
+		Bytes:       624,
+		Records:     12,
+		Handles:     1,
+		Description: "winapi\x00test\x00\x00",
+		Device:      SIZE{1920, 1080},
+		Millimeters: SIZE{508, 285},
+		Micrometers: SIZE{508000, 285750},
+	}
+	if !reflect.DeepEqual(recs[0], hdr) {
+		t.Errorf("header = %+v, want %+v", recs[0], hdr)
+	}
+
+	if r, ok := recs[1].(*EMRRaw); !ok || !bytes.Equal(r.Data, []byte{10, 0, 0, 0, 10, 0, 0, 0}) {
+		t.Errorf("EMR_MOVETOEX = %+v, want raw x 10, y 10", recs[1])
+	}
+
+	poly := &EMRPoly{Kind: EMR_POLYLINE16, Bounds: RECT{0, 0, 30, 20}, Points: []POINT{{0, 0}, {10, 20}, {30, 5}}}
+	if !reflect.DeepEqual(recs[5], poly) {
+		t.Errorf("EMR_POLYLINE16 = %+v, want %+v", recs[5], poly)
+	}
+
+	if r, ok := recs[7].(*EMRRestoreDC); !ok || r.SavedDC != -1 {
+		t.Errorf("EMR_RESTOREDC = %+v, want SavedDC -1", recs[7])
+	}
+
+	text := recs[8].(*EMRExtTextOut)
+	if text.Text != "Hi" || !reflect.DeepEqual(text.Dx, []int32{8, 7}) || text.Reference != (POINT{5, 5}) ||
+		text.Rect != (RECT{0, 0, -1, -1}) || text.GraphicsMode != GM_COMPATIBLE {
+		t.Errorf("EMR_EXTTEXTOUTW = %+v", text)
+	}
+
+	pat := recs[9].(*EMRBitBlt)
+	if pat.Rop != PATCOPY || pat.BitmapInfo != nil || pat.Bits != nil || pat.Width != 10 {
+		t.Errorf("EMR_BITBLT = %+v, want a 10x10 PATCOPY without source", pat)
+	}
+
+	blt := recs[10].(*EMRBitBlt)
+	if blt.Rop != SRCCOPY || len(blt.BitmapInfo) != 40 || !bytes.Equal(blt.Bits, []byte{0x10, 0x20, 0x30, 0xFF}) ||
+		blt.SrcWidth != 1 || blt.SrcHeight != 1 || blt.BkColorSrc != RGB(255, 255, 255) {
+		t.Errorf("EMR_STRETCHBLT = %+v", blt)
+	}
+
+	if eof := recs[11].(*EMREOF); eof.Palette != nil {
+		t.Errorf("EMR_EOF palette = %v, want none", eof.Palette)
+	}
+}
+
+func TestEncodeEMF(t *testing.T) {
+	hdr := &EMRHeader{
+		Bounds:      RECT{-5, -5, 40, 40},
+		Frame:       RECT{0, 0, 1000, 1000},
+		Handles:     2,
+		Description: "app\x00\x00",
+		Device:      SIZE{800, 600},
+		Millimeters: SIZE{211, 158},
+		PixelFormat: []byte{1, 2, 3, 4, 5, 6},
+		OpenGL:      true,
+	}
+	recs := []EMFRecord{
+		hdr,
+		&EMRPoly{Kind: EMR_POLYBEZIER, Bounds: RECT{-5, -5, 40, 40}, Points: []POINT{{-5, -5}, {100000, 0}, {0, 40}, {40, 40}}},
+		&EMRSaveDC{},
+		&EMRExtTextOut{
+			GraphicsMode: GM_ADVANCED, XScale: 1, YScale: 1,
+			Reference: POINT{1, 2}, Options: ETO_NO_RECT | ETO_PDY,
+			Text: "a\U0001F600", Dx: []int32{1, 2, 3, 4, 5, 6},
+		},
+		&EMRExtTextOut{Options: ETO_GLYPH_INDEX | ETO_OPAQUE, Rect: RECT{1, 2, 3, 4}, Glyphs: []uint16{7, 8, 9}},
+		&EMRRestoreDC{-1},
+		&EMRRaw{Kind: EMR_SETMAPMODE, Data: []byte{MM_ANISOTROPIC, 0, 0, 0}},
+		&EMREOF{Palette: []PALETTEENTRY{{1, 2, 3, 0}, {4, 5, 6, 1}}},
+	}
+
+	b, err := EncodeEMF(recs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b)%4 != 0 {
+		t.Errorf("len = %d, not a multiple of 4", len(b))
+	}
+	if hdr.Version != 0 || hdr.Bytes != 0 || hdr.Records != 0 {
+		t.Errorf("EncodeEMF changed the header in recs: %+v", hdr)
+	}
+
+	got, err := DecodeEMF(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append([]EMFRecord(nil), recs...)
+	h := *hdr
+	h.Version = 0x10000
+	h.Bytes = DWORD(len(b))
+	h.Records = DWORD(len(recs))
+	h.PalEntries = 2
+	want[0] = &h
+	if !reflect.DeepEqual(got, want) {
+		for i := range want {
+			if i < len(got) && !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("record %d = %+v, want %+v", i, got[i], want[i])
+			}
+		}
+		t.Fatalf("decoded %d records, want %d", len(got), len(want))
+	}
+
+	again, err := EncodeEMF(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again, b) {
+		t.Error("re-encoding the decoded metafile changed it")
+	}
+}
+
+func TestEncodeEMFInvalid(t *testing.T) {
+	hdr, eof := &EMRHeader{}, &EMREOF{}
+	tests := []struct {
+		name string
+		recs []EMFRecord
+		err  error
+	}{
+		{"empty", nil, ErrEMFInvalid},
+		{"no header", []EMFRecord{&EMRSaveDC{}, eof}, ErrEMFInvalid},
+		{"no eof", []EMFRecord{hdr, &EMRSaveDC{}}, ErrEMFInvalid},
+		{"second header", []EMFRecord{hdr, hdr, eof}, ErrEMFInvalid},
+		{"eof inside", []EMFRecord{hdr, eof, &EMRSaveDC{}, eof}, ErrEMFInvalid},
+		{"poly kind", []EMFRecord{hdr, &EMRPoly{Kind: EMR_RECTANGLE}, eof}, ErrEMFInvalid},
+		{"poly16 range", []EMFRecord{hdr, &EMRPoly{Kind: EMR_POLYGON16, Points: []POINT{{0, 40000}}}, eof}, ErrEMFInvalid},
+		{"blt kind", []EMFRecord{hdr, &EMRBitBlt{Kind: EMR_MASKBLT}, eof}, ErrEMFInvalid},
+		{"dx length", []EMFRecord{hdr, &EMRExtTextOut{Text: "ab", Dx: []int32{1}}, eof}, ErrTextDx},
+	}
+	for _, tt := range tests {
+		if _, err := EncodeEMF(tt.recs); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeEMFTruncated(t *testing.T) {
+	b := testEMFBytes(t)
+	for n := 0; n < len(b); n++ {
+		if _, err := DecodeEMF(b[:n]); err != ErrEMFInvalid {
+			t.Fatalf("DecodeEMF(b[:%d]) err = %v, want ErrEMFInvalid", n, err)
+		}
+	}
+}
+
+func TestDecodeEMFCorrupt(t *testing.T) {
+	// The offsets of records in testEMF.
+	const (
+		header    = 0
+		polyline  = 204
+		exttext   = 264
+		stretchbl = 452
+	)
+	le := binary.LittleEndian
+	tests := []struct {
+		name string
+		edit func(b []byte)
+	}{
+		{"signature", func(b []byte) { b[header+40] = 'X' }},
+		{"header size below 88", func(b []byte) { le.PutUint32(b[header+4:], 80) }},
+		{"header not first", func(b []byte) { le.PutUint32(b[header:], EMR_SAVEDC) }},
+		{"size not aligned", func(b []byte) { le.PutUint32(b[polyline+4:], 42) }},
+		{"size below 8", func(b []byte) { le.PutUint32(b[polyline+4:], 4) }},
+		{"size past end", func(b []byte) { le.PutUint32(b[polyline+4:], 1<<31) }},
+		{"description past record", func(b []byte) { le.PutUint32(b[header+60:], 1000) }},
+		{"description in header", func(b []byte) { le.PutUint32(b[header+64:], 40) }},
+		{"point count", func(b []byte) { le.PutUint32(b[polyline+24:], 1<<30) }},
+		{"string past record", func(b []byte) { le.PutUint32(b[exttext+44:], 1<<31) }},
+		{"dx past record", func(b []byte) { le.PutUint32(b[exttext+72:], 88) }},
+		{"bits past record", func(b []byte) { le.PutUint32(b[stretchbl+96:], 5) }},
+		{"no eof", func(b []byte) { le.PutUint32(b[len(b)-20:], EMR_SAVEDC) }},
+	}
+	for _, tt := range tests {
+		b := testEMFBytes(t)
+		tt.edit(b)
+		if recs, err := DecodeEMF(b); err != ErrEMFInvalid {
+			t.Errorf("%s: DecodeEMF = %d records, err %v, want ErrEMFInvalid", tt.name, len(recs), err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"sort"
+)
+
+// FaceName returns the face name of lf.
+func (lf *LOGFONT) FaceName() string {
+	return utf16ToString(lf.LfFaceName[:])
+}
+
+// SetFaceName sets the face name of lf, truncated to LF_FACESIZE-1
+// UTF-16 code units.
+func (lf *LOGFONT) SetFaceName(name string) {
+	u := utf16Units(name)
+	if len(u) > LF_FACESIZE-1 {
+		u = u[:LF_FACESIZE-1]
+		// Do not leave half of a surrogate pair behind.
+		if u[len(u)-1]&0xFC00 == 0xD800 {
+			u = u[:len(u)-1]
+		}
+	}
+
+	lf.LfFaceName = [LF_FACESIZE]uint16{}
+	copy(lf.LfFaceName[:], u)
+}
+
+// FontFamily describes an installed font family as EnumFontFamiliesEx
+// reports it, with the metrics of its first enumerated style.
+type FontFamily struct {
+	Name     string
+	Type     DWORD
+	LogFont  LOGFONT
+	Metrics  NEWTEXTMETRICEX
+	Charsets []byte
+}
+
+// FontFamilies returns the font families installed for hdc sorted by
+// name. Vertical fonts, whose names start with '@', are left out.
+func FontFamilies(hdc HDC) []FontFamily {
+	lf := LOGFONT{LfCharSet: DEFAULT_CHARSET}
+
+	index := make(map[string]int)
+	var families []FontFamily
+	EnumFontFamiliesEx(hdc, &lf, func(elf *ENUMLOGFONTEX, ntm *NEWTEXTMETRICEX, fontType DWORD) bool {
+		name := elf.ElfLogFont.FaceName()
+		if name == "" || name[0] == '@' {
+			return true
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(families)
+			index[name] = i
+			families = append(families, FontFamily{Name: name, Type: fontType, LogFont: elf.ElfLogFont, Metrics: *ntm})
+		}
+
+		f := &families[i]
+		for _, cs := range f.Charsets {
+			if cs == elf.ElfLogFont.LfCharSet {
+				return true
+			}
+		}
+		f.Charsets = append(f.Charsets, elf.ElfLogFont.LfCharSet)
+
+		return true
+	})
+
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+
+	return families
+}
@@ -5,39 +5,125 @@
 package winapi
 
 import (
-	"syscall"
+	"image/color"
+	"runtime"
 	"unsafe"
 )
 
 var (
-	modGdi32                  = syscall.NewLazyDLL("Gdi32.dll")
-	procGetObjectW            = modGdi32.NewProc("GetObjectW")
-	procMoveToEx              = modGdi32.NewProc("MoveToEx")
-	procTextOutW              = modGdi32.NewProc("TextOutW")
-	procPolyTextOutW          = modGdi32.NewProc("PolyTextOutW")
-	procExtTextOutW           = modGdi32.NewProc("ExtTextOutW")
-	procGetTextExtentPointW   = modGdi32.NewProc("GetTextExtentPointW")
-	procGetTextExtentPoint32W = modGdi32.NewProc("GetTextExtentPoint32W")
-	procCreatePolygonRgn      = modGdi32.NewProc("CreatePolygonRgn")
-	procDPtoLP                = modGdi32.NewProc("DPtoLP")
-	procLPtoDP                = modGdi32.NewProc("LPtoDP")
-	procPolygon               = modGdi32.NewProc("Polygon")
-	procPolyline              = modGdi32.NewProc("Polyline")
-	procLineTo                = modGdi32.NewProc("LineTo")
-	procPolyBezier            = modGdi32.NewProc("PolyBezier")
-	procPolyBezierTo          = modGdi32.NewProc("PolyBezierTo")
-	procPolylineTo            = modGdi32.NewProc("PolylineTo")
-	procSetViewportExtEx      = modGdi32.NewProc("SetViewportExtEx")
-	procSetViewportOrgEx      = modGdi32.NewProc("SetViewportOrgEx")
-	procSetWindowExtEx        = modGdi32.NewProc("SetWindowExtEx")
-	procSetWindowOrgEx        = modGdi32.NewProc("SetWindowOrgEx")
-	procOffsetViewportOrgEx   = modGdi32.NewProc("OffsetViewportOrgEx")
-	procOffsetWindowOrgEx     = modGdi32.NewProc("OffsetWindowOrgEx")
-	procScaleViewportExtEx    = modGdi32.NewProc("ScaleViewportExtEx")
-	procScaleWindowExtEx      = modGdi32.NewProc("ScaleWindowExtEx")
-	procSetBitmapDimensionEx  = modGdi32.NewProc("SetBitmapDimensionEx")
-	procSetBrushOrgEx         = modGdi32.NewProc("SetBrushOrgEx")
-	procGdiFlush              = modGdi32.NewProc("GdiFlush")
+	modGdi32                   = newLazyDLL("Gdi32.dll")
+	procGetObjectW             = modGdi32.NewProc("GetObjectW")
+	procMoveToEx               = modGdi32.NewProc("MoveToEx")
+	procTextOutW               = modGdi32.NewProc("TextOutW")
+	procPolyTextOutW           = modGdi32.NewProc("PolyTextOutW")
+	procExtTextOutW            = modGdi32.NewProc("ExtTextOutW")
+	procGetTextExtentPointW    = modGdi32.NewProc("GetTextExtentPointW")
+	procGetTextExtentPoint32W  = modGdi32.NewProc("GetTextExtentPoint32W")
+	procCreatePolygonRgn       = modGdi32.NewProc("CreatePolygonRgn")
+	procDPtoLP                 = modGdi32.NewProc("DPtoLP")
+	procLPtoDP                 = modGdi32.NewProc("LPtoDP")
+	procPolygon                = modGdi32.NewProc("Polygon")
+	procPolyline               = modGdi32.NewProc("Polyline")
+	procLineTo                 = modGdi32.NewProc("LineTo")
+	procPolyBezier             = modGdi32.NewProc("PolyBezier")
+	procPolyBezierTo           = modGdi32.NewProc("PolyBezierTo")
+	procPolylineTo             = modGdi32.NewProc("PolylineTo")
+	procSetViewportExtEx       = modGdi32.NewProc("SetViewportExtEx")
+	procSetViewportOrgEx       = modGdi32.NewProc("SetViewportOrgEx")
+	procSetWindowExtEx         = modGdi32.NewProc("SetWindowExtEx")
+	procSetWindowOrgEx         = modGdi32.NewProc("SetWindowOrgEx")
+	procOffsetViewportOrgEx    = modGdi32.NewProc("OffsetViewportOrgEx")
+	procOffsetWindowOrgEx      = modGdi32.NewProc("OffsetWindowOrgEx")
+	procScaleViewportExtEx     = modGdi32.NewProc("ScaleViewportExtEx")
+	procScaleWindowExtEx       = modGdi32.NewProc("ScaleWindowExtEx")
+	procSetBitmapDimensionEx   = modGdi32.NewProc("SetBitmapDimensionEx")
+	procSetBrushOrgEx          = modGdi32.NewProc("SetBrushOrgEx")
+	procGdiFlush               = modGdi32.NewProc("GdiFlush")
+	procGetCurrentPositionEx   = modGdi32.NewProc("GetCurrentPositionEx")
+	procSetTextColor           = modGdi32.NewProc("SetTextColor")
+	procSetBkColor             = modGdi32.NewProc("SetBkColor")
+	procSetBkMode              = modGdi32.NewProc("SetBkMode")
+	procSetPolyFillMode        = modGdi32.NewProc("SetPolyFillMode")
+	procSetDCPenColor          = modGdi32.NewProc("SetDCPenColor")
+	procSetDCBrushColor        = modGdi32.NewProc("SetDCBrushColor")
+	procSetMapMode             = modGdi32.NewProc("SetMapMode")
+	procGetMapMode             = modGdi32.NewProc("GetMapMode")
+	procGetViewportExtEx       = modGdi32.NewProc("GetViewportExtEx")
+	procGetViewportOrgEx       = modGdi32.NewProc("GetViewportOrgEx")
+	procGetWindowExtEx         = modGdi32.NewProc("GetWindowExtEx")
+	procGetWindowOrgEx         = modGdi32.NewProc("GetWindowOrgEx")
+	procGetDeviceCaps          = modGdi32.NewProc("GetDeviceCaps")
+	procSetGraphicsMode        = modGdi32.NewProc("SetGraphicsMode")
+	procGetGraphicsMode        = modGdi32.NewProc("GetGraphicsMode")
+	procSetWorldTransform      = modGdi32.NewProc("SetWorldTransform")
+	procModifyWorldTransform   = modGdi32.NewProc("ModifyWorldTransform")
+	procGetWorldTransform      = modGdi32.NewProc("GetWorldTransform")
+	procCombineTransform       = modGdi32.NewProc("CombineTransform")
+	procCreatePen              = modGdi32.NewProc("CreatePen")
+	procExtCreatePen           = modGdi32.NewProc("ExtCreatePen")
+	procCreateSolidBrush       = modGdi32.NewProc("CreateSolidBrush")
+	procCreateHatchBrush       = modGdi32.NewProc("CreateHatchBrush")
+	procCreatePatternBrush     = modGdi32.NewProc("CreatePatternBrush")
+	procCreateFontIndirectW    = modGdi32.NewProc("CreateFontIndirectW")
+	procGetStockObject         = modGdi32.NewProc("GetStockObject")
+	procSelectObject           = modGdi32.NewProc("SelectObject")
+	procDeleteObject           = modGdi32.NewProc("DeleteObject")
+	procGetObjectType          = modGdi32.NewProc("GetObjectType")
+	procCreateDIBSection       = modGdi32.NewProc("CreateDIBSection")
+	procGetDIBits              = modGdi32.NewProc("GetDIBits")
+	procSetDIBitsToDevice      = modGdi32.NewProc("SetDIBitsToDevice")
+	procStretchDIBits          = modGdi32.NewProc("StretchDIBits")
+	procBitBlt                 = modGdi32.NewProc("BitBlt")
+	procStretchBlt             = modGdi32.NewProc("StretchBlt")
+	procPatBlt                 = modGdi32.NewProc("PatBlt")
+	procMaskBlt                = modGdi32.NewProc("MaskBlt")
+	procSetStretchBltMode      = modGdi32.NewProc("SetStretchBltMode")
+	procGetStretchBltMode      = modGdi32.NewProc("GetStretchBltMode")
+	procCreateCompatibleDC     = modGdi32.NewProc("CreateCompatibleDC")
+	procDeleteDC               = modGdi32.NewProc("DeleteDC")
+	procCreateCompatibleBitmap = modGdi32.NewProc("CreateCompatibleBitmap")
+	procCreateRectRgn          = modGdi32.NewProc("CreateRectRgn")
+	procCreateRoundRectRgn     = modGdi32.NewProc("CreateRoundRectRgn")
+	procCombineRgn             = modGdi32.NewProc("CombineRgn")
+	procOffsetRgn              = modGdi32.NewProc("OffsetRgn")
+	procPtInRegion             = modGdi32.NewProc("PtInRegion")
+	procGetRegionData          = modGdi32.NewProc("GetRegionData")
+	procExtCreateRegion        = modGdi32.NewProc("ExtCreateRegion")
+	procSelectClipRgn          = modGdi32.NewProc("SelectClipRgn")
+	procBeginPath              = modGdi32.NewProc("BeginPath")
+	procEndPath                = modGdi32.NewProc("EndPath")
+	procAbortPath              = modGdi32.NewProc("AbortPath")
+	procCloseFigure            = modGdi32.NewProc("CloseFigure")
+	procStrokePath             = modGdi32.NewProc("StrokePath")
+	procFillPath               = modGdi32.NewProc("FillPath")
+	procStrokeAndFillPath      = modGdi32.NewProc("StrokeAndFillPath")
+	procWidenPath              = modGdi32.NewProc("WidenPath")
+	procFlattenPath            = modGdi32.NewProc("FlattenPath")
+	procGetPath                = modGdi32.NewProc("GetPath")
+	procPathToRegion           = modGdi32.NewProc("PathToRegion")
+	procEnumFontFamiliesExW    = modGdi32.NewProc("EnumFontFamiliesExW")
+	procGetTextMetricsW        = modGdi32.NewProc("GetTextMetricsW")
+	procGetCharABCWidthsW      = modGdi32.NewProc("GetCharABCWidthsW")
+	procGetKerningPairsW       = modGdi32.NewProc("GetKerningPairsW")
+	procGetFontData            = modGdi32.NewProc("GetFontData")
+	procCreateEnhMetaFileW     = modGdi32.NewProc("CreateEnhMetaFileW")
+	procCloseEnhMetaFile       = modGdi32.NewProc("CloseEnhMetaFile")
+	procDeleteEnhMetaFile      = modGdi32.NewProc("DeleteEnhMetaFile")
+	procGetEnhMetaFileW        = modGdi32.NewProc("GetEnhMetaFileW")
+	procPlayEnhMetaFile        = modGdi32.NewProc("PlayEnhMetaFile")
+	procGetEnhMetaFileBits     = modGdi32.NewProc("GetEnhMetaFileBits")
+	procSetEnhMetaFileBits     = modGdi32.NewProc("SetEnhMetaFileBits")
+	procRectangle              = modGdi32.NewProc("Rectangle")
+	procEllipse                = modGdi32.NewProc("Ellipse")
+	procSaveDC                 = modGdi32.NewProc("SaveDC")
+	procRestoreDC              = modGdi32.NewProc("RestoreDC")
+	procSetWinMetaFileBits     = modGdi32.NewProc("SetWinMetaFileBits")
+	procCreatePalette          = modGdi32.NewProc("CreatePalette")
+	procSelectPalette          = modGdi32.NewProc("SelectPalette")
+	procRealizePalette         = modGdi32.NewProc("RealizePalette")
+	procGetNearestPaletteIndex = modGdi32.NewProc("GetNearestPaletteIndex")
+	procCreateHalftonePalette  = modGdi32.NewProc("CreateHalftonePalette")
+	procGetPaletteEntries      = modGdi32.NewProc("GetPaletteEntries")
 )
 
 func GetObject(h HANDLE) []byte {
@@ -54,150 +140,1351 @@ func GetObject(h HANDLE) []byte {
 }
 
 func MoveToEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.moveTo(x, y, lppt)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procMoveToEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func TextOut(hdc HDC, x int32, y int32, lpString string) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.extTextOut(x, y, 0, nil, lpString, nil)
+	}
+
+	u := utf16Units(lpString)
+	var ret uintptr
+	ret, _, lastError = procTextOutW.Call(uintptr(hdc), uintptr(x), uintptr(y), utf16Ptr(u), uintptr(len(u)))
+
+	return PtrToBool(ret)
+}
+
+// ExtTextOut draws text like TextOut, optionally filling or clipping to
+// rc as options asks. dx, if not nil, holds the advance of each UTF-16
+// code unit of text, or with ETO_PDY an advance and a rise, towards the
+// top of the page, for each. With ETO_GLYPH_INDEX each code unit of
+// text is a glyph index; ExtTextOutGlyphs takes them as a slice.
+func ExtTextOut(hdc HDC, x int32, y int32, options UINT, rc *RECT, text string, dx []int32) bool {
+	if !checkDx(dx, len(utf16Units(text)), options) {
+		lastError = ErrTextDx
+		return false
+	}
+
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.extTextOut(x, y, options, rc, text, dx)
+	}
+
+	return extTextOut(hdc, x, y, options, rc, utf16Units(text), dx)
+}
+
+// ExtTextOutGlyphs draws the glyphs with the given indexes in the
+// selected font, as ExtTextOut does with ETO_GLYPH_INDEX.
+func ExtTextOutGlyphs(hdc HDC, x int32, y int32, options UINT, rc *RECT, glyphs []uint16, dx []int32) bool {
+	if !checkDx(dx, len(glyphs), options) {
+		lastError = ErrTextDx
+		return false
+	}
+
+	return extTextOut(hdc, x, y, options|ETO_GLYPH_INDEX, rc, glyphs, dx)
+}
+
+func extTextOut(hdc HDC, x int32, y int32, options UINT, rc *RECT, units []uint16, dx []int32) bool {
+	var pdx uintptr
+	if len(dx) > 0 {
+		pdx = uintptr(unsafe.Pointer(&dx[0]))
+	}
+
+	var ret uintptr
+	ret, _, lastError = procExtTextOutW.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(options), uintptr(unsafe.Pointer(rc)), utf16Ptr(units), uintptr(len(units)), pdx)
+
+	return PtrToBool(ret)
+}
+
+// PolyTextOut draws several strings as ExtTextOut would.
+func PolyTextOut(hdc HDC, texts []PolyText) bool {
+	items, bufs, ok := polyTextItems(texts)
+	if !ok {
+		lastError = ErrTextDx
+		return false
+	}
+
+	if dc := lookupSoftDC(hdc); dc != nil {
+		for _, t := range texts {
+			rc := t.Rect
+			if !dc.extTextOut(t.X, t.Y, t.Options, &rc, t.Text, t.Dx) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(items) == 0 {
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolyTextOutW.Call(uintptr(hdc), uintptr(unsafe.Pointer(&items[0])), uintptr(len(items)))
+	runtime.KeepAlive(bufs)
+	runtime.KeepAlive(texts)
+
+	return PtrToBool(ret)
+}
+
+func GetTextExtentPoint(hdc HDC, lpString string, lpsz *SIZE) bool {
+	u := utf16Units(lpString)
+	var ret uintptr
+	ret, _, lastError = procGetTextExtentPointW.Call(uintptr(hdc), utf16Ptr(u), uintptr(len(u)), uintptr(unsafe.Pointer(lpsz)))
+
+	return PtrToBool(ret)
+}
+
+func GetTextExtentPoint32(hdc HDC, lpString string, psizl *SIZE) bool {
+	u := utf16Units(lpString)
+	var ret uintptr
+	ret, _, lastError = procGetTextExtentPoint32W.Call(uintptr(hdc), utf16Ptr(u), uintptr(len(u)), uintptr(unsafe.Pointer(psizl)))
+
+	return PtrToBool(ret)
+}
+
+func CreatePolygonRgn(pts []POINT, iMode int32) HRGN {
+	var ret uintptr
+	ret, _, lastError = procCreatePolygonRgn.Call(uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)), uintptr(iMode))
+	trackGDIObject(ret, "CreatePolygonRgn")
+
+	return HRGN(ret)
+}
+
+func CreateRectRgn(left int32, top int32, right int32, bottom int32) HRGN {
+	var ret uintptr
+	ret, _, lastError = procCreateRectRgn.Call(uintptr(left), uintptr(top), uintptr(right), uintptr(bottom))
+	trackGDIObject(ret, "CreateRectRgn")
+
+	return HRGN(ret)
+}
+
+// CreateRoundRectRgn creates a rectangular region with corners rounded
+// by an ellipse of size w, h. The right column and bottom row of the
+// rectangle are not part of the region.
+func CreateRoundRectRgn(left int32, top int32, right int32, bottom int32, w int32, h int32) HRGN {
+	var ret uintptr
+	ret, _, lastError = procCreateRoundRectRgn.Call(uintptr(left), uintptr(top), uintptr(right), uintptr(bottom), uintptr(w), uintptr(h))
+	trackGDIObject(ret, "CreateRoundRectRgn")
+
+	return HRGN(ret)
+}
+
+// CombineRgn sets dst, which must exist, to the combination of src1 and
+// src2 with mode and returns the type of the result or ERROR. src2 is
+// ignored for RGN_COPY.
+func CombineRgn(dst HRGN, src1 HRGN, src2 HRGN, mode int32) int32 {
+	var ret uintptr
+	ret, _, lastError = procCombineRgn.Call(uintptr(dst), uintptr(src1), uintptr(src2), uintptr(mode))
+
+	return int32(ret)
+}
+
+func OffsetRgn(h HRGN, x int32, y int32) int32 {
+	var ret uintptr
+	ret, _, lastError = procOffsetRgn.Call(uintptr(h), uintptr(x), uintptr(y))
+
+	return int32(ret)
+}
+
+func PtInRegion(h HRGN, x int32, y int32) bool {
+	var ret uintptr
+	ret, _, lastError = procPtInRegion.Call(uintptr(h), uintptr(x), uintptr(y))
+
+	return PtrToBool(ret)
+}
+
+// GetRegionData returns the RGNDATA describing h, or nil on failure.
+func GetRegionData(h HRGN) []byte {
+	var ret uintptr
+	ret, _, lastError = procGetRegionData.Call(uintptr(h), 0, 0)
+	if ret == 0 {
+		return nil
+	}
+
+	buf := make([]byte, uint(ret))
+	ret, _, lastError = procGetRegionData.Call(uintptr(h), ret, uintptr(unsafe.Pointer(&buf[0])))
+	if ret == 0 {
+		return nil
+	}
+
+	return buf
+}
+
+// ExtCreateRegion creates a region from RGNDATA, transformed by xf
+// unless it is nil. data must hold at least an RGNDATAHEADER.
+func ExtCreateRegion(xf *XFORM, data []byte) HRGN {
+	if len(data) < int(unsafe.Sizeof(RGNDATAHEADER{})) {
+		lastError = ErrRegionDataInvalid
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procExtCreateRegion.Call(uintptr(unsafe.Pointer(xf)), uintptr(len(data)), uintptr(unsafe.Pointer(&data[0])))
+	trackGDIObject(ret, "ExtCreateRegion")
+
+	return HRGN(ret)
+}
+
+// SelectClipRgn sets the clipping region of hdc to a copy of h, or
+// removes it if h is 0, and returns the type of the new clipping region.
+func SelectClipRgn(hdc HDC, h HRGN) int32 {
+	var ret uintptr
+	ret, _, lastError = procSelectClipRgn.Call(uintptr(hdc), uintptr(h))
+
+	return int32(ret)
+}
+
+// BeginPath discards the path of hdc and starts recording the lines and
+// curves drawn on it into a new one, until EndPath.
+func BeginPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.beginPath()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procBeginPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+func EndPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.endPath()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procEndPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+func AbortPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.abortPath()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procAbortPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// CloseFigure closes the current figure of the path being recorded.
+func CloseFigure(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.closeFigure()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procCloseFigure.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// StrokePath draws the outline of the path with the pen and discards it.
+func StrokePath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.strokeAndFillPath(true, false)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procStrokePath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// FillPath fills the path with the brush, closing its open figures, and
+// discards it.
+func FillPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.strokeAndFillPath(false, true)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procFillPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+func StrokeAndFillPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.strokeAndFillPath(true, true)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procStrokeAndFillPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// WidenPath replaces the path with the area the pen would paint when
+// stroking it. It needs a geometric pen or one wider than a pixel, so it
+// fails on a SoftDC.
+func WidenPath(hdc HDC) bool {
+	if lookupSoftDC(hdc) != nil {
+		return false
+	}
+
+	var ret uintptr
+	ret, _, lastError = procWidenPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// FlattenPath replaces the curves of the path with lines.
+func FlattenPath(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.flattenPath()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procFlattenPath.Call(uintptr(hdc))
+
+	return PtrToBool(ret)
+}
+
+// GetPath returns the points of the path of hdc in logical coordinates
+// with their PT_ types, or nil if there is no completed path.
+func GetPath(hdc HDC) ([]POINT, []byte) {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.getPath()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procGetPath.Call(uintptr(hdc), 0, 0, 0)
+	if int32(ret) <= 0 {
+		return nil, nil
+	}
+
+	pts := make([]POINT, ret)
+	types := make([]byte, ret)
+	ret, _, lastError = procGetPath.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(unsafe.Pointer(&types[0])), ret)
+	if int32(ret) <= 0 {
+		return nil, nil
+	}
+
+	return pts[:ret], types[:ret]
+}
+
+// PathToRegion converts the path of hdc to a region using its fill mode
+// and discards the path.
+func PathToRegion(hdc HDC) HRGN {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		rgn := dc.pathRegion()
+		if rgn == nil {
+			return 0
+		}
+		h, _ := rgn.HRGN()
+		return h
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPathToRegion.Call(uintptr(hdc))
+	trackGDIObject(ret, "PathToRegion")
+
+	return HRGN(ret)
+}
+
+func DPtoLP(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		dc.xform.DPtoLP(pts)
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procDPtoLP.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func LPtoDP(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		dc.xform.LPtoDP(pts)
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procLPtoDP.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+// Rectangle draws a rectangle outlined with the pen and filled with the
+// brush. The right and bottom edges are excluded.
+func Rectangle(hdc HDC, left int32, top int32, right int32, bottom int32) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.rectangle(left, top, right, bottom)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procRectangle.Call(uintptr(hdc), uintptr(left), uintptr(top), uintptr(right), uintptr(bottom))
+
+	return PtrToBool(ret)
+}
+
+// Ellipse draws the ellipse inscribed in a rectangle, outlined with the
+// pen and filled with the brush.
+func Ellipse(hdc HDC, left int32, top int32, right int32, bottom int32) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.ellipse(left, top, right, bottom)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procEllipse.Call(uintptr(hdc), uintptr(left), uintptr(top), uintptr(right), uintptr(bottom))
+
+	return PtrToBool(ret)
+}
+
+func Polygon(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.polygon(pts)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolygon.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func Polyline(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.polyline(pts)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolyline.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func LineTo(hdc HDC, x int32, y int32) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.lineTo(x, y)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procLineTo.Call(uintptr(hdc), uintptr(x), uintptr(y))
+
+	return PtrToBool(ret)
+}
+
+func PolyBezier(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.polyBezier(pts)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolyBezier.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func PolyBezierTo(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.polyBezierTo(pts)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolyBezierTo.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func PolylineTo(hdc HDC, pts []POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.polylineTo(pts)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procPolylineTo.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+
+	return PtrToBool(ret)
+}
+
+func SetViewportExtEx(hdc HDC, x int32, y int32, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old, ok := dc.xform.SetViewportExt(x, y)
+		if lpsz != nil {
+			*lpsz = old
+		}
+		return ok
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetViewportExtEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lpsz)))
+
+	return PtrToBool(ret)
+}
+
+func SetViewportOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.xform.SetViewportOrg(x, y)
+		if lppt != nil {
+			*lppt = old
+		}
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetViewportOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func SetWindowExtEx(hdc HDC, x int32, y int32, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old, ok := dc.xform.SetWindowExt(x, y)
+		if lpsz != nil {
+			*lpsz = old
+		}
+		return ok
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetWindowExtEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lpsz)))
+
+	return PtrToBool(ret)
+}
+
+func SetWindowOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.xform.SetWindowOrg(x, y)
+		if lppt != nil {
+			*lppt = old
+		}
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetWindowOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func OffsetViewportOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.xform.OffsetViewportOrg(x, y)
+		if lppt != nil {
+			*lppt = old
+		}
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procOffsetViewportOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func OffsetWindowOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.xform.OffsetWindowOrg(x, y)
+		if lppt != nil {
+			*lppt = old
+		}
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procOffsetWindowOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func ScaleViewportExtEx(hdc HDC, xn int32, dx int32, yn int32, yd int32, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old, ok := dc.xform.ScaleViewportExt(xn, dx, yn, yd)
+		if lpsz != nil {
+			*lpsz = old
+		}
+		return ok
+	}
+
+	var ret uintptr
+	ret, _, lastError = procScaleViewportExtEx.Call(uintptr(hdc), uintptr(xn), uintptr(dx), uintptr(yn), uintptr(yd), uintptr(unsafe.Pointer(lpsz)))
+
+	return PtrToBool(ret)
+}
+
+func ScaleWindowExtEx(hdc HDC, xn int32, xd int32, yn int32, yd int32, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old, ok := dc.xform.ScaleWindowExt(xn, xd, yn, yd)
+		if lpsz != nil {
+			*lpsz = old
+		}
+		return ok
+	}
+
+	var ret uintptr
+	ret, _, lastError = procScaleWindowExtEx.Call(uintptr(hdc), uintptr(xn), uintptr(xd), uintptr(yn), uintptr(yd), uintptr(unsafe.Pointer(lpsz)))
+
+	return PtrToBool(ret)
+}
+
+func CreatePen(style int32, width int32, color COLORREF) HPEN {
+	var ret uintptr
+	ret, _, lastError = procCreatePen.Call(uintptr(style), uintptr(width), uintptr(color))
+	trackGDIObject(ret, "CreatePen")
+
+	return HPEN(ret)
+}
+
+// ExtCreatePen creates a cosmetic or geometric pen. styles holds the
+// dash and gap lengths of a PS_USERSTYLE pen and is nil otherwise.
+func ExtCreatePen(style DWORD, width DWORD, lb *LOGBRUSH, styles []DWORD) HPEN {
+	var pstyles uintptr
+	if len(styles) > 0 {
+		pstyles = uintptr(unsafe.Pointer(&styles[0]))
+	}
+
+	var ret uintptr
+	ret, _, lastError = procExtCreatePen.Call(uintptr(style), uintptr(width), uintptr(unsafe.Pointer(lb)), uintptr(len(styles)), pstyles)
+	trackGDIObject(ret, "ExtCreatePen")
+
+	return HPEN(ret)
+}
+
+func CreateSolidBrush(color COLORREF) HBRUSH {
+	var ret uintptr
+	ret, _, lastError = procCreateSolidBrush.Call(uintptr(color))
+	trackGDIObject(ret, "CreateSolidBrush")
+
+	return HBRUSH(ret)
+}
+
+func CreateHatchBrush(hatch int32, color COLORREF) HBRUSH {
+	var ret uintptr
+	ret, _, lastError = procCreateHatchBrush.Call(uintptr(hatch), uintptr(color))
+	trackGDIObject(ret, "CreateHatchBrush")
+
+	return HBRUSH(ret)
+}
+
+// CreatePatternBrush creates a brush tiling hbm. The bitmap is copied,
+// so it can be deleted once the brush exists.
+func CreatePatternBrush(hbm HBITMAP) HBRUSH {
+	var ret uintptr
+	ret, _, lastError = procCreatePatternBrush.Call(uintptr(hbm))
+	trackGDIObject(ret, "CreatePatternBrush")
+
+	return HBRUSH(ret)
+}
+
+func CreateFontIndirect(lf *LOGFONT) HFONT {
+	var ret uintptr
+	ret, _, lastError = procCreateFontIndirectW.Call(uintptr(unsafe.Pointer(lf)))
+	trackGDIObject(ret, "CreateFontIndirect")
+
+	return HFONT(ret)
+}
+
+// EnumFontFamiliesEx calls fn for each font matching the character set
+// and face name of lf, which may be empty, until fn returns false. The
+// text metrics past the TEXTMETRIC are only filled in for TrueType
+// fonts.
+func EnumFontFamiliesEx(hdc HDC, lf *LOGFONT, fn func(elf *ENUMLOGFONTEX, ntm *NEWTEXTMETRICEX, fontType DWORD) bool) bool {
+	id := registerCallback(fn)
+	defer unregisterCallback(id)
+
+	var ret uintptr
+	ret, _, lastError = procEnumFontFamiliesExW.Call(uintptr(hdc), uintptr(unsafe.Pointer(lf)), enumFontFamiliesCallback, id, 0)
+
+	// The callback's return value is passed through, so any nonzero
+	// value means the enumeration ran to the end.
+	return ret != 0
+}
+
+var enumFontFamiliesCallback = newCallback(func(elf *ENUMLOGFONTEX, tm *TEXTMETRIC, fontType DWORD, id uintptr) uintptr {
+	fn, _ := lookupCallback(id).(func(*ENUMLOGFONTEX, *NEWTEXTMETRICEX, DWORD) bool)
+	if fn == nil {
+		return 0
+	}
+
+	e := *elf
+	var ntm NEWTEXTMETRICEX
+	if fontType&TRUETYPE_FONTTYPE != 0 {
+		ntm = *(*NEWTEXTMETRICEX)(unsafe.Pointer(tm))
+	} else {
+		ntm.NtmTm.TEXTMETRIC = *tm
+	}
+
+	return BoolToPtr(fn(&e, &ntm, fontType))
+})
+
+func GetTextMetrics(hdc HDC, tm *TEXTMETRIC) bool {
+	var ret uintptr
+	ret, _, lastError = procGetTextMetricsW.Call(uintptr(hdc), uintptr(unsafe.Pointer(tm)))
+
+	return PtrToBool(ret)
+}
+
+// GetCharABCWidths returns the ABC widths of the characters first to
+// last of the selected TrueType font, or nil on failure.
+func GetCharABCWidths(hdc HDC, first UINT, last UINT) []ABC {
+	if last < first {
+		return nil
+	}
+
+	abc := make([]ABC, last-first+1)
+	var ret uintptr
+	ret, _, lastError = procGetCharABCWidthsW.Call(uintptr(hdc), uintptr(first), uintptr(last), uintptr(unsafe.Pointer(&abc[0])))
+	if ret == 0 {
+		return nil
+	}
+
+	return abc
+}
+
+// GetKerningPairs returns the kerning pairs of the selected font.
+func GetKerningPairs(hdc HDC) []KERNINGPAIR {
+	var ret uintptr
+	ret, _, lastError = procGetKerningPairsW.Call(uintptr(hdc), 0, 0)
+	if ret == 0 {
+		return nil
+	}
+
+	pairs := make([]KERNINGPAIR, ret)
+	ret, _, lastError = procGetKerningPairsW.Call(uintptr(hdc), ret, uintptr(unsafe.Pointer(&pairs[0])))
+
+	return pairs[:ret]
+}
+
+// GetFontData returns the font table table, made with FontTableTag, of
+// the selected TrueType font from offset on. A table of 0 returns the
+// whole font file, and FontTableTag("ttcf") the whole font collection.
+// It returns nil on failure.
+func GetFontData(hdc HDC, table DWORD, offset DWORD) []byte {
+	var ret uintptr
+	ret, _, lastError = procGetFontData.Call(uintptr(hdc), uintptr(table), uintptr(offset), 0, 0)
+	if DWORD(ret) == GDI_ERROR || ret == 0 {
+		return nil
+	}
+
+	buf := make([]byte, ret)
+	ret, _, lastError = procGetFontData.Call(uintptr(hdc), uintptr(table), uintptr(offset), uintptr(unsafe.Pointer(&buf[0])), ret)
+	if DWORD(ret) == GDI_ERROR {
+		return nil
+	}
+
+	return buf[:ret]
+}
+
+// FontTableTag returns the table argument of GetFontData for a four
+// letter OpenType table tag such as "cmap".
+func FontTableTag(tag string) DWORD {
+	var t DWORD
+	for i := 0; i < 4 && i < len(tag); i++ {
+		t |= DWORD(tag[i]) << (8 * i)
+	}
+
+	return t
+}
+
+// CreateEnhMetaFile creates a DC that records an enhanced metafile, in
+// the file filename or in memory if it is empty. hdcRef, or the screen
+// if it is 0, is the reference device, and frame the size of the picture
+// in .01 mm, or nil to use the bounds of the drawing. desc is an optional
+// application name and picture title separated by a NUL.
+func CreateEnhMetaFile(hdcRef HDC, filename string, frame *RECT, desc string) HDC {
+	var d []uint16
+	if desc != "" {
+		d = utf16Units(desc + "\x00\x00")
+	}
+
+	var ret uintptr
+	ret, _, lastError = procCreateEnhMetaFileW.Call(uintptr(hdcRef), StringToUintptr(filename), uintptr(unsafe.Pointer(frame)), utf16Ptr(d))
+	runtime.KeepAlive(d)
+
+	return HDC(ret)
+}
+
+// CloseEnhMetaFile ends the recording of hdc, deletes it and returns the
+// metafile, which must be deleted with DeleteEnhMetaFile.
+func CloseEnhMetaFile(hdc HDC) HENHMETAFILE {
+	var ret uintptr
+	ret, _, lastError = procCloseEnhMetaFile.Call(uintptr(hdc))
+
+	return HENHMETAFILE(ret)
+}
+
+func DeleteEnhMetaFile(hemf HENHMETAFILE) bool {
+	var ret uintptr
+	ret, _, lastError = procDeleteEnhMetaFile.Call(uintptr(hemf))
+
+	return PtrToBool(ret)
+}
+
+// GetEnhMetaFile opens the enhanced metafile filename.
+func GetEnhMetaFile(filename string) HENHMETAFILE {
+	var ret uintptr
+	ret, _, lastError = procGetEnhMetaFileW.Call(StringToUintptr(filename))
+
+	return HENHMETAFILE(ret)
+}
+
+// PlayEnhMetaFile draws hemf on hdc, scaled to fit rc.
+func PlayEnhMetaFile(hdc HDC, hemf HENHMETAFILE, rc *RECT) bool {
+	var ret uintptr
+	ret, _, lastError = procPlayEnhMetaFile.Call(uintptr(hdc), uintptr(hemf), uintptr(unsafe.Pointer(rc)))
+
+	return PtrToBool(ret)
+}
+
+// GetEnhMetaFileBits returns the contents of hemf, which DecodeEMF
+// decodes, or nil on failure.
+func GetEnhMetaFileBits(hemf HENHMETAFILE) []byte {
+	var ret uintptr
+	ret, _, lastError = procGetEnhMetaFileBits.Call(uintptr(hemf), 0, 0)
+	if ret == 0 {
+		return nil
+	}
+
+	buf := make([]byte, ret)
+	ret, _, lastError = procGetEnhMetaFileBits.Call(uintptr(hemf), ret, uintptr(unsafe.Pointer(&buf[0])))
+	if ret == 0 {
+		return nil
+	}
+
+	return buf[:ret]
+}
+
+// SetEnhMetaFileBits creates an in-memory metafile from data, as
+// GetEnhMetaFileBits returns it or EncodeEMF produces it.
+func SetEnhMetaFileBits(data []byte) HENHMETAFILE {
+	if len(data) == 0 {
+		lastError = ErrEMFInvalid
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetEnhMetaFileBits.Call(uintptr(len(data)), uintptr(unsafe.Pointer(&data[0])))
+
+	return HENHMETAFILE(ret)
+}
+
+// SetWinMetaFileBits converts a Windows metafile, without a placeable
+// header, to an enhanced metafile. mfp gives the size of the picture and
+// may be nil to use the size of the reference device hdcRef.
+func SetWinMetaFileBits(data []byte, hdcRef HDC, mfp *METAFILEPICT) HENHMETAFILE {
+	if len(data) == 0 {
+		lastError = ErrWMFInvalid
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetWinMetaFileBits.Call(uintptr(len(data)), uintptr(unsafe.Pointer(&data[0])), uintptr(hdcRef), uintptr(unsafe.Pointer(mfp)))
+
+	return HENHMETAFILE(ret)
+}
+
+// CreatePalette creates a logical palette holding the colors of p,
+// which must have between 1 and 65535 entries. Alpha is dropped.
+func CreatePalette(p color.Palette) HPALETTE {
+	if len(p) == 0 || len(p) > 0xFFFF {
+		lastError = ErrPaletteInvalid
+		return 0
+	}
+
+	// LOGPALETTE is a version and an entry count followed by the entries.
+	b := make([]PALETTEENTRY, 1+len(p))
+	*(*[2]WORD)(unsafe.Pointer(&b[0])) = [2]WORD{0x300, WORD(len(p))}
+	copy(b[1:], PaletteEntries(p))
+
+	var ret uintptr
+	ret, _, lastError = procCreatePalette.Call(uintptr(unsafe.Pointer(&b[0])))
+	trackGDIObject(ret, "CreatePalette")
+
+	return HPALETTE(ret)
+}
+
+// CreateHalftonePalette creates the palette HALFTONE stretching uses on
+// palette devices like hdc.
+func CreateHalftonePalette(hdc HDC) HPALETTE {
+	var ret uintptr
+	ret, _, lastError = procCreateHalftonePalette.Call(uintptr(hdc))
+	trackGDIObject(ret, "CreateHalftonePalette")
+
+	return HPALETTE(ret)
+}
+
+// SelectPalette selects hpal into hdc and returns the palette it
+// replaced. With forceBackground set the palette is realized as a
+// background palette even when the window has the focus.
+func SelectPalette(hdc HDC, hpal HPALETTE, forceBackground bool) HPALETTE {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.palette
+		dc.palette = hpal
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSelectPalette.Call(uintptr(hdc), uintptr(hpal), BoolToPtr(forceBackground))
+
+	return HPALETTE(ret)
+}
+
+// RealizePalette maps the palette selected into hdc to the system
+// palette and returns the number of entries that changed, or GDI_ERROR.
+// A SoftDC has no system palette, so nothing changes.
+func RealizePalette(hdc HDC) UINT {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procRealizePalette.Call(uintptr(hdc))
+
+	return UINT(ret)
+}
+
+// GetNearestPaletteIndex returns the index of the entry of hpal closest
+// to c, or CLR_INVALID. NearestPaletteIndex does the same in Go.
+func GetNearestPaletteIndex(hpal HPALETTE, c COLORREF) UINT {
+	var ret uintptr
+	ret, _, lastError = procGetNearestPaletteIndex.Call(uintptr(hpal), uintptr(c))
+
+	return UINT(ret)
+}
+
+// GetPaletteEntries returns the entries of hpal, or nil on failure.
+func GetPaletteEntries(hpal HPALETTE) []PALETTEENTRY {
+	var ret uintptr
+	ret, _, lastError = procGetPaletteEntries.Call(uintptr(hpal), 0, 0, 0)
+	if ret == 0 {
+		return nil
+	}
+
+	entries := make([]PALETTEENTRY, ret)
+	ret, _, lastError = procGetPaletteEntries.Call(uintptr(hpal), 0, ret, uintptr(unsafe.Pointer(&entries[0])))
+	if ret == 0 {
+		return nil
+	}
+
+	return entries[:ret]
+}
+
+// GetStockObject returns one of the predefined pens, brushes, fonts or
+// palettes. Stock objects must not be deleted.
+func GetStockObject(i int32) HGDIOBJ {
+	var ret uintptr
+	ret, _, lastError = procGetStockObject.Call(uintptr(i))
+
+	return HGDIOBJ(ret)
+}
+
+// SelectObject selects obj into hdc. It returns the object it replaced
+// and a function selecting that object back, which should be called,
+// usually deferred, before obj is deleted. On failure old is 0 and
+// restore does nothing. Regions are copied rather than selected, so for
+// them old is the region type and restore does nothing.
+//
+// A SoftDC accepts pens and brushes, drawn with their color, or as null
+// for PS_NULL and BS_NULL. Its initial pen and brush and those set with
+// SelectPen and SelectBrush are returned as the stock BLACK_PEN and
+// WHITE_BRUSH, but restore brings back the pen or brush that was
+// actually replaced.
+func SelectObject(hdc HDC, obj HGDIOBJ) (old HGDIOBJ, restore func()) {
+	restore = func() {}
+	if dc := lookupSoftDC(hdc); dc != nil {
+		var undo func()
+		old, undo = dc.selectObject(obj)
+		if undo != nil {
+			restore = undo
+		}
+		return old, restore
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSelectObject.Call(uintptr(hdc), uintptr(obj))
+	if ret == 0 || ret == HGDI_ERROR {
+		return 0, restore
+	}
+
+	old = HGDIOBJ(ret)
+	if GetObjectType(obj) != OBJ_REGION {
+		restore = func() {
+			procSelectObject.Call(uintptr(hdc), uintptr(old))
+		}
+	}
+
+	return old, restore
+}
+
+// DeleteObject deletes a pen, brush, font, bitmap, region or palette.
+// The object must not be selected into a device context.
+func DeleteObject(obj HGDIOBJ) bool {
+	var ret uintptr
+	ret, _, lastError = procDeleteObject.Call(uintptr(obj))
+	if ret != 0 {
+		untrackGDIObject(uintptr(obj))
+	}
+
+	return PtrToBool(ret)
+}
+
+func GetObjectType(obj HGDIOBJ) DWORD {
+	var ret uintptr
+	ret, _, lastError = procGetObjectType.Call(uintptr(obj))
+
+	return DWORD(ret)
+}
+
+// CreateDIBSection creates a bitmap whose pixels live in memory the
+// application can access, returned in bits. bmi may point to a
+// BITMAPV5HEADER converted to *BITMAPINFO.
+func CreateDIBSection(hdc HDC, bmi *BITMAPINFO, usage UINT, bits *uintptr, section HANDLE, offset DWORD) HBITMAP {
+	var ret uintptr
+	ret, _, lastError = procCreateDIBSection.Call(uintptr(hdc), uintptr(unsafe.Pointer(bmi)), uintptr(usage), uintptr(unsafe.Pointer(bits)), uintptr(section), uintptr(offset))
+	trackGDIObject(ret, "CreateDIBSection")
+
+	return HBITMAP(ret)
+}
+
+// GetDIBits copies lines scan lines of hbm, starting at start, into
+// bits in the format described by bmi. If bits is nil it fills in bmi
+// instead. It returns the number of lines copied.
+func GetDIBits(hdc HDC, hbm HBITMAP, start UINT, lines UINT, bits []byte, bmi *BITMAPINFO, usage UINT) int32 {
+	var pbits uintptr
+	if len(bits) > 0 {
+		pbits = uintptr(unsafe.Pointer(&bits[0]))
+	}
+
+	var ret uintptr
+	ret, _, lastError = procGetDIBits.Call(uintptr(hdc), uintptr(hbm), uintptr(start), uintptr(lines), pbits, uintptr(unsafe.Pointer(bmi)), uintptr(usage))
+
+	return int32(ret)
+}
+
+func SetDIBitsToDevice(hdc HDC, xDest int32, yDest int32, w DWORD, h DWORD, xSrc int32, ySrc int32, startScan UINT, lines UINT, bits []byte, bmi *BITMAPINFO, usage UINT) int32 {
+	if len(bits) == 0 {
+		lastError = ERROR_INVALID_PARAMETER
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetDIBitsToDevice.Call(uintptr(hdc), uintptr(xDest), uintptr(yDest), uintptr(w), uintptr(h), uintptr(xSrc), uintptr(ySrc), uintptr(startScan), uintptr(lines), uintptr(unsafe.Pointer(&bits[0])), uintptr(unsafe.Pointer(bmi)), uintptr(usage))
+
+	return int32(ret)
+}
+
+// StretchDIBits draws a DIB scaled into a rectangle. A SoftDC draws the
+// formats DecodeDIB supports except BI_BITFIELDS, whose masks it cannot
+// reach through bmi.
+func StretchDIBits(hdc HDC, xDest int32, yDest int32, destW int32, destH int32, xSrc int32, ySrc int32, srcW int32, srcH int32, bits []byte, bmi *BITMAPINFO, usage UINT, rop DWORD) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		// The masks of a BI_BITFIELDS DIB follow bmi in memory that a
+		// *BITMAPINFO does not cover.
+		if bmi.BmiHeader.BiCompression == BI_BITFIELDS {
+			lastError = ErrDIBFormat
+			return 0
+		}
+		return dc.stretchDIBits(xDest, yDest, destW, destH, xSrc, ySrc, srcW, srcH, bits, &bmi.BmiHeader, [3]DWORD{}, rop)
+	}
+
+	if len(bits) == 0 {
+		lastError = ERROR_INVALID_PARAMETER
+		return 0
+	}
+
+	var ret uintptr
+	ret, _, lastError = procStretchDIBits.Call(uintptr(hdc), uintptr(xDest), uintptr(yDest), uintptr(destW), uintptr(destH), uintptr(xSrc), uintptr(ySrc), uintptr(srcW), uintptr(srcH), uintptr(unsafe.Pointer(&bits[0])), uintptr(unsafe.Pointer(bmi)), uintptr(usage), uintptr(rop))
+
+	return int32(ret)
+}
+
+// SaveDC saves the state of hdc and returns its number, or 0 on
+// failure.
+func SaveDC(hdc HDC) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.saveDC()
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSaveDC.Call(uintptr(hdc))
+
+	return int32(ret)
+}
+
+// RestoreDC restores the state saved as number saved, or with a negative
+// number, the state -saved steps back, discarding the states saved after
+// it.
+func RestoreDC(hdc HDC, saved int32) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.restoreDC(saved)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procRestoreDC.Call(uintptr(hdc), uintptr(saved))
+
+	return PtrToBool(ret)
+}
+
+func CreateCompatibleDC(hdc HDC) HDC {
+	var ret uintptr
+	ret, _, lastError = procCreateCompatibleDC.Call(uintptr(hdc))
+
+	return HDC(ret)
+}
+
+// DeleteDC deletes a device context created with CreateCompatibleDC or
+// CreateDC. A SoftDC is closed.
+func DeleteDC(hdc HDC) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		dc.Close()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procMoveToEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+	ret, _, lastError = procDeleteDC.Call(uintptr(hdc))
 
 	return PtrToBool(ret)
 }
 
-func TextOut(hdc HDC, x int32, y int32, lpString string) bool {
+func CreateCompatibleBitmap(hdc HDC, w int32, h int32) HBITMAP {
 	var ret uintptr
-	ret, _, lastError = procTextOutW.Call(uintptr(hdc), uintptr(x), uintptr(y), StringToUintptr(lpString), uintptr(len(lpString)))
+	ret, _, lastError = procCreateCompatibleBitmap.Call(uintptr(hdc), uintptr(w), uintptr(h))
+	trackGDIObject(ret, "CreateCompatibleBitmap")
 
-	return PtrToBool(ret)
+	return HBITMAP(ret)
 }
 
-func GetTextExtentPoint(hdc HDC, lpString string, lpsz *SIZE) bool {
+// BitBlt combines a rectangle of hdcSrc with hdcDest using the raster
+// operation rop. hdcSrc may be 0 if rop does not use the source.
+func BitBlt(hdcDest HDC, x int32, y int32, w int32, h int32, hdcSrc HDC, xSrc int32, ySrc int32, rop DWORD) bool {
+	if dc := lookupSoftDC(hdcDest); dc != nil {
+		return dc.stretchBlt(x, y, w, h, lookupSoftDC(hdcSrc), xSrc, ySrc, w, h, rop)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procGetTextExtentPointW.Call(uintptr(hdc), StringToUintptr(lpString), uintptr(len(lpString)), uintptr(unsafe.Pointer(lpsz)))
+	ret, _, lastError = procBitBlt.Call(uintptr(hdcDest), uintptr(x), uintptr(y), uintptr(w), uintptr(h), uintptr(hdcSrc), uintptr(xSrc), uintptr(ySrc), uintptr(rop))
 
 	return PtrToBool(ret)
 }
 
-func GetTextExtentPoint32(hdc HDC, lpString string, psizl *SIZE) bool {
+// StretchBlt is like BitBlt but scales the source rectangle to the
+// destination rectangle using the stretch mode of hdcDest. The image is
+// mirrored if the signs of the widths or heights differ.
+func StretchBlt(hdcDest HDC, xDest int32, yDest int32, wDest int32, hDest int32, hdcSrc HDC, xSrc int32, ySrc int32, wSrc int32, hSrc int32, rop DWORD) bool {
+	if dc := lookupSoftDC(hdcDest); dc != nil {
+		return dc.stretchBlt(xDest, yDest, wDest, hDest, lookupSoftDC(hdcSrc), xSrc, ySrc, wSrc, hSrc, rop)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procGetTextExtentPoint32W.Call(uintptr(hdc), StringToUintptr(lpString), uintptr(len(lpString)), uintptr(unsafe.Pointer(psizl)))
+	ret, _, lastError = procStretchBlt.Call(uintptr(hdcDest), uintptr(xDest), uintptr(yDest), uintptr(wDest), uintptr(hDest), uintptr(hdcSrc), uintptr(xSrc), uintptr(ySrc), uintptr(wSrc), uintptr(hSrc), uintptr(rop))
 
 	return PtrToBool(ret)
 }
 
-func CreatePolygonRgn(pts []POINT, iMode int32) HRGN {
+// PatBlt combines a rectangle of hdc with its brush using a raster
+// operation that does not use a source.
+func PatBlt(hdc HDC, x int32, y int32, w int32, h int32, rop DWORD) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		if ropUsesSource(rop) {
+			return false
+		}
+		return dc.stretchBlt(x, y, w, h, nil, 0, 0, 0, 0, rop)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procCreatePolygonRgn.Call(uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)), uintptr(iMode))
+	ret, _, lastError = procPatBlt.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(w), uintptr(h), uintptr(rop))
 
-	return HRGN(ret)
+	return PtrToBool(ret)
 }
 
-func DPtoLP(hdc HDC, pts []POINT) bool {
+// MaskBlt is like BitBlt but uses the foreground operation of rop, made
+// with MAKEROP4, where the monochrome bitmap hbmMask is 1 and the
+// background operation where it is 0.
+func MaskBlt(hdcDest HDC, x int32, y int32, w int32, h int32, hdcSrc HDC, xSrc int32, ySrc int32, hbmMask HBITMAP, xMask int32, yMask int32, rop DWORD) bool {
+	if dc := lookupSoftDC(hdcDest); dc != nil {
+		return dc.maskBlt(x, y, w, h, lookupSoftDC(hdcSrc), xSrc, ySrc, hbmMask, xMask, yMask, rop)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procDPtoLP.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procMaskBlt.Call(uintptr(hdcDest), uintptr(x), uintptr(y), uintptr(w), uintptr(h), uintptr(hdcSrc), uintptr(xSrc), uintptr(ySrc), uintptr(hbmMask), uintptr(xMask), uintptr(yMask), uintptr(rop))
 
 	return PtrToBool(ret)
 }
 
-func LPtoDP(hdc HDC, pts []POINT) bool {
+func SetStretchBltMode(hdc HDC, mode int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.stretch
+		dc.stretch = mode
+		return old
+	}
+
 	var ret uintptr
-	ret, _, lastError = procLPtoDP.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procSetStretchBltMode.Call(uintptr(hdc), uintptr(mode))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func Polygon(hdc HDC, pts []POINT) bool {
+func GetStretchBltMode(hdc HDC) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.stretch
+	}
+
 	var ret uintptr
-	ret, _, lastError = procPolygon.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procGetStretchBltMode.Call(uintptr(hdc))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func Polyline(hdc HDC, pts []POINT) bool {
+func SetMapMode(hdc HDC, mode int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.xform.SetMapMode(mode)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procPolyline.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procSetMapMode.Call(uintptr(hdc), uintptr(mode))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func LineTo(hdc HDC, x int32, y int32) bool {
+func GetMapMode(hdc HDC) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.xform.MapMode()
+	}
+
 	var ret uintptr
-	ret, _, lastError = procLineTo.Call(uintptr(hdc), uintptr(x), uintptr(y))
+	ret, _, lastError = procGetMapMode.Call(uintptr(hdc))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func PolyBezier(hdc HDC, pts []POINT) bool {
+func GetViewportExtEx(hdc HDC, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*lpsz = dc.xform.ViewportExt()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procPolyBezier.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procGetViewportExtEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(lpsz)))
 
 	return PtrToBool(ret)
 }
 
-func PolyBezierTo(hdc HDC, pts []POINT) bool {
+func GetViewportOrgEx(hdc HDC, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*lppt = dc.xform.ViewportOrg()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procPolyBezierTo.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procGetViewportOrgEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(lppt)))
 
 	return PtrToBool(ret)
 }
 
-func PolylineTo(hdc HDC, pts []POINT) bool {
+func GetWindowExtEx(hdc HDC, lpsz *SIZE) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*lpsz = dc.xform.WindowExt()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procPolylineTo.Call(uintptr(hdc), uintptr(unsafe.Pointer(&pts[0])), uintptr(len(pts)))
+	ret, _, lastError = procGetWindowExtEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(lpsz)))
 
 	return PtrToBool(ret)
 }
 
-func SetViewportExtEx(hdc HDC, x int32, y int32, lpsz *SIZE) bool {
+func GetWindowOrgEx(hdc HDC, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*lppt = dc.xform.WindowOrg()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procSetViewportExtEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lpsz)))
+	ret, _, lastError = procGetWindowOrgEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(lppt)))
 
 	return PtrToBool(ret)
 }
 
-func SetViewportOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+func SetGraphicsMode(hdc HDC, mode int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.xform.SetGraphicsMode(mode)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procSetViewportOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+	ret, _, lastError = procSetGraphicsMode.Call(uintptr(hdc), uintptr(mode))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func SetWindowExtEx(hdc HDC, x int32, y int32, lpsz *SIZE) bool {
+func GetGraphicsMode(hdc HDC) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.xform.GraphicsMode()
+	}
+
 	var ret uintptr
-	ret, _, lastError = procSetWindowExtEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lpsz)))
+	ret, _, lastError = procGetGraphicsMode.Call(uintptr(hdc))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
-func SetWindowOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+func SetWorldTransform(hdc HDC, xf *XFORM) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.xform.SetWorldTransform(*xf)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procSetWindowOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+	ret, _, lastError = procSetWorldTransform.Call(uintptr(hdc), uintptr(unsafe.Pointer(xf)))
 
 	return PtrToBool(ret)
 }
 
-func OffsetViewportOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+// ModifyWorldTransform changes the world transform. xf is ignored, and
+// may be nil, for MWT_IDENTITY.
+func ModifyWorldTransform(hdc HDC, xf *XFORM, mode DWORD) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		var x XFORM
+		if xf != nil {
+			x = *xf
+		}
+		return dc.xform.ModifyWorldTransform(x, mode)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procOffsetViewportOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+	ret, _, lastError = procModifyWorldTransform.Call(uintptr(hdc), uintptr(unsafe.Pointer(xf)), uintptr(mode))
 
 	return PtrToBool(ret)
 }
 
-func OffsetWindowOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
+func GetWorldTransform(hdc HDC, xf *XFORM) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*xf = dc.xform.WorldTransform()
+		return true
+	}
+
 	var ret uintptr
-	ret, _, lastError = procOffsetWindowOrgEx.Call(uintptr(hdc), uintptr(x), uintptr(y), uintptr(unsafe.Pointer(lppt)))
+	ret, _, lastError = procGetWorldTransform.Call(uintptr(hdc), uintptr(unsafe.Pointer(xf)))
 
 	return PtrToBool(ret)
 }
 
-func ScaleViewportExtEx(hdc HDC, xn int32, dx int32, yn int32, yd int32, lpsz *SIZE) bool {
+// CombineTransform sets out to the transform applying a, then b.
+func CombineTransform(out, a, b *XFORM) bool {
 	var ret uintptr
-	ret, _, lastError = procScaleViewportExtEx.Call(uintptr(hdc), uintptr(xn), uintptr(dx), uintptr(yn), uintptr(yd), uintptr(unsafe.Pointer(lpsz)))
+	ret, _, lastError = procCombineTransform.Call(uintptr(unsafe.Pointer(out)), uintptr(unsafe.Pointer(a)), uintptr(unsafe.Pointer(b)))
 
 	return PtrToBool(ret)
 }
 
-func ScaleWindowExtEx(hdc HDC, xn int32, xd int32, yn int32, yd int32, lpsz *SIZE) bool {
+func GetDeviceCaps(hdc HDC, index int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.deviceCaps(index)
+	}
+
 	var ret uintptr
-	ret, _, lastError = procScaleWindowExtEx.Call(uintptr(hdc), uintptr(xn), uintptr(xd), uintptr(yn), uintptr(yd), uintptr(unsafe.Pointer(lpsz)))
+	ret, _, lastError = procGetDeviceCaps.Call(uintptr(hdc), uintptr(index))
 
-	return PtrToBool(ret)
+	return int32(ret)
 }
 
 func SetBitmapDimensionEx(hbm HBITMAP, w int32, h int32, lpsz *SIZE) bool {
@@ -214,6 +1501,100 @@ func SetBrushOrgEx(hdc HDC, x int32, y int32, lppt *POINT) bool {
 	return PtrToBool(ret)
 }
 
+func GetCurrentPositionEx(hdc HDC, lppt *POINT) bool {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		*lppt = dc.pos
+		return true
+	}
+
+	var ret uintptr
+	ret, _, lastError = procGetCurrentPositionEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(lppt)))
+
+	return PtrToBool(ret)
+}
+
+func SetTextColor(hdc HDC, color COLORREF) COLORREF {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.textColor
+		dc.textColor = color
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetTextColor.Call(uintptr(hdc), uintptr(color))
+
+	return COLORREF(ret)
+}
+
+func SetBkColor(hdc HDC, color COLORREF) COLORREF {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.bkColor
+		dc.bkColor = color
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetBkColor.Call(uintptr(hdc), uintptr(color))
+
+	return COLORREF(ret)
+}
+
+func SetBkMode(hdc HDC, mode int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.bkMode
+		dc.bkMode = mode
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetBkMode.Call(uintptr(hdc), uintptr(mode))
+
+	return int32(ret)
+}
+
+func SetPolyFillMode(hdc HDC, mode int32) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.fillMode
+		dc.fillMode = mode
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetPolyFillMode.Call(uintptr(hdc), uintptr(mode))
+
+	return int32(ret)
+}
+
+// SetDCPenColor sets the color of the DC_PEN stock pen. On a SoftDC it
+// sets the color of the current pen.
+func SetDCPenColor(hdc HDC, color COLORREF) COLORREF {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.pen.Color
+		dc.pen.Color = color
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetDCPenColor.Call(uintptr(hdc), uintptr(color))
+
+	return COLORREF(ret)
+}
+
+// SetDCBrushColor sets the color of the DC_BRUSH stock brush. On a
+// SoftDC it sets the color of the current brush.
+func SetDCBrushColor(hdc HDC, color COLORREF) COLORREF {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		old := dc.brush.Color
+		dc.brush.Color = color
+		return old
+	}
+
+	var ret uintptr
+	ret, _, lastError = procSetDCBrushColor.Call(uintptr(hdc), uintptr(color))
+
+	return COLORREF(ret)
+}
+
 func GdiFlush() bool {
 	var ret uintptr
 	ret, _, lastError = procGdiFlush.Call()
@@ -221,7 +1602,451 @@ func GdiFlush() bool {
 	return PtrToBool(ret)
 }
 
-const LF_FACESIZE = 32
+type LOGPEN struct {
+	LopnStyle UINT
+	LopnWidth POINT
+	LopnColor COLORREF
+}
+
+type LOGBRUSH struct {
+	LbStyle UINT
+	LbColor COLORREF
+	LbHatch uintptr
+}
+
+// Pen styles
+const (
+	PS_SOLID         = 0
+	PS_DASH          = 1
+	PS_DOT           = 2
+	PS_DASHDOT       = 3
+	PS_DASHDOTDOT    = 4
+	PS_NULL          = 5
+	PS_INSIDEFRAME   = 6
+	PS_USERSTYLE     = 7
+	PS_ALTERNATE     = 8
+	PS_STYLE_MASK    = 0x0000000F
+	PS_ENDCAP_ROUND  = 0x00000000
+	PS_ENDCAP_SQUARE = 0x00000100
+	PS_ENDCAP_FLAT   = 0x00000200
+	PS_ENDCAP_MASK   = 0x00000F00
+	PS_JOIN_ROUND    = 0x00000000
+	PS_JOIN_BEVEL    = 0x00001000
+	PS_JOIN_MITER    = 0x00002000
+	PS_JOIN_MASK     = 0x0000F000
+	PS_COSMETIC      = 0x00000000
+	PS_GEOMETRIC     = 0x00010000
+	PS_TYPE_MASK     = 0x000F0000
+)
+
+// Brush styles
+const (
+	BS_SOLID         = 0
+	BS_NULL          = 1
+	BS_HOLLOW        = BS_NULL
+	BS_HATCHED       = 2
+	BS_PATTERN       = 3
+	BS_INDEXED       = 4
+	BS_DIBPATTERN    = 5
+	BS_DIBPATTERNPT  = 6
+	BS_PATTERN8X8    = 7
+	BS_DIBPATTERN8X8 = 8
+	BS_MONOPATTERN   = 9
+)
+
+// Hatch styles
+const (
+	HS_HORIZONTAL = 0
+	HS_VERTICAL   = 1
+	HS_FDIAGONAL  = 2
+	HS_BDIAGONAL  = 3
+	HS_CROSS      = 4
+	HS_DIAGCROSS  = 5
+)
+
+// Stock objects
+const (
+	WHITE_BRUSH         = 0
+	LTGRAY_BRUSH        = 1
+	GRAY_BRUSH          = 2
+	DKGRAY_BRUSH        = 3
+	BLACK_BRUSH         = 4
+	NULL_BRUSH          = 5
+	HOLLOW_BRUSH        = NULL_BRUSH
+	WHITE_PEN           = 6
+	BLACK_PEN           = 7
+	NULL_PEN            = 8
+	OEM_FIXED_FONT      = 10
+	ANSI_FIXED_FONT     = 11
+	ANSI_VAR_FONT       = 12
+	SYSTEM_FONT         = 13
+	DEVICE_DEFAULT_FONT = 14
+	DEFAULT_PALETTE     = 15
+	SYSTEM_FIXED_FONT   = 16
+	DEFAULT_GUI_FONT    = 17
+	DC_BRUSH            = 18
+	DC_PEN              = 19
+)
+
+// GetObjectType return values
+const (
+	OBJ_PEN         = 1
+	OBJ_BRUSH       = 2
+	OBJ_DC          = 3
+	OBJ_METADC      = 4
+	OBJ_PAL         = 5
+	OBJ_FONT        = 6
+	OBJ_BITMAP      = 7
+	OBJ_REGION      = 8
+	OBJ_METAFILE    = 9
+	OBJ_MEMDC       = 10
+	OBJ_EXTPEN      = 11
+	OBJ_ENHMETADC   = 12
+	OBJ_ENHMETAFILE = 13
+	OBJ_COLORSPACE  = 14
+)
+
+// Region types
+const (
+	ERROR         = 0
+	NULLREGION    = 1
+	SIMPLEREGION  = 2
+	COMPLEXREGION = 3
+)
+
+// CombineRgn modes
+const (
+	RGN_AND  = 1
+	RGN_OR   = 2
+	RGN_XOR  = 3
+	RGN_DIFF = 4
+	RGN_COPY = 5
+)
+
+const RDH_RECTANGLES = 1
+
+// GetPath point types
+const (
+	PT_CLOSEFIGURE = 0x01
+	PT_LINETO      = 0x02
+	PT_BEZIERTO    = 0x04
+	PT_MOVETO      = 0x06
+)
+
+// Font types
+const (
+	RASTER_FONTTYPE   = 0x0001
+	DEVICE_FONTTYPE   = 0x0002
+	TRUETYPE_FONTTYPE = 0x0004
+)
+
+// NEWTEXTMETRIC flags
+const (
+	NTM_ITALIC         = 0x00000001
+	NTM_BOLD           = 0x00000020
+	NTM_REGULAR        = 0x00000040
+	NTM_NONNEGATIVE_AC = 0x00010000
+	NTM_PS_OPENTYPE    = 0x00020000
+	NTM_TT_OPENTYPE    = 0x00040000
+	NTM_MULTIPLEMASTER = 0x00080000
+	NTM_TYPE1          = 0x00100000
+	NTM_DSIG           = 0x00200000
+)
+
+// GDI_ERROR is returned by GetFontData and other functions returning a
+// DWORD on failure.
+const GDI_ERROR = 0xFFFFFFFF
+
+// Windows metafile record functions
+const (
+	META_EOF                   = 0x0000
+	META_SAVEDC                = 0x001E
+	META_REALIZEPALETTE        = 0x0035
+	META_SETPALENTRIES         = 0x0037
+	META_CREATEPALETTE         = 0x00F7
+	META_SETBKMODE             = 0x0102
+	META_SETMAPMODE            = 0x0103
+	META_SETROP2               = 0x0104
+	META_SETRELABS             = 0x0105
+	META_SETPOLYFILLMODE       = 0x0106
+	META_SETSTRETCHBLTMODE     = 0x0107
+	META_SETTEXTCHAREXTRA      = 0x0108
+	META_RESTOREDC             = 0x0127
+	META_INVERTREGION          = 0x012A
+	META_PAINTREGION           = 0x012B
+	META_SELECTCLIPREGION      = 0x012C
+	META_SELECTOBJECT          = 0x012D
+	META_SETTEXTALIGN          = 0x012E
+	META_RESIZEPALETTE         = 0x0139
+	META_DIBCREATEPATTERNBRUSH = 0x0142
+	META_SETLAYOUT             = 0x0149
+	META_DELETEOBJECT          = 0x01F0
+	META_CREATEPATTERNBRUSH    = 0x01F9
+	META_SETBKCOLOR            = 0x0201
+	META_SETTEXTCOLOR          = 0x0209
+	META_SETTEXTJUSTIFICATION  = 0x020A
+	META_SETWINDOWORG          = 0x020B
+	META_SETWINDOWEXT          = 0x020C
+	META_SETVIEWPORTORG        = 0x020D
+	META_SETVIEWPORTEXT        = 0x020E
+	META_OFFSETWINDOWORG       = 0x020F
+	META_OFFSETVIEWPORTORG     = 0x0211
+	META_LINETO                = 0x0213
+	META_MOVETO                = 0x0214
+	META_OFFSETCLIPRGN         = 0x0220
+	META_FILLREGION            = 0x0228
+	META_SETMAPPERFLAGS        = 0x0231
+	META_SELECTPALETTE         = 0x0234
+	META_CREATEPENINDIRECT     = 0x02FA
+	META_CREATEFONTINDIRECT    = 0x02FB
+	META_CREATEBRUSHINDIRECT   = 0x02FC
+	META_POLYGON               = 0x0324
+	META_POLYLINE              = 0x0325
+	META_SCALEWINDOWEXT        = 0x0410
+	META_SCALEVIEWPORTEXT      = 0x0412
+	META_EXCLUDECLIPRECT       = 0x0415
+	META_INTERSECTCLIPRECT     = 0x0416
+	META_ELLIPSE               = 0x0418
+	META_FLOODFILL             = 0x0419
+	META_RECTANGLE             = 0x041B
+	META_SETPIXEL              = 0x041F
+	META_FRAMEREGION           = 0x0429
+	META_ANIMATEPALETTE        = 0x0436
+	META_TEXTOUT               = 0x0521
+	META_POLYPOLYGON           = 0x0538
+	META_EXTFLOODFILL          = 0x0548
+	META_ROUNDRECT             = 0x061C
+	META_PATBLT                = 0x061D
+	META_ESCAPE                = 0x0626
+	META_CREATEREGION          = 0x06FF
+	META_ARC                   = 0x0817
+	META_PIE                   = 0x081A
+	META_CHORD                 = 0x0830
+	META_BITBLT                = 0x0922
+	META_DIBBITBLT             = 0x0940
+	META_EXTTEXTOUT            = 0x0A32
+	META_STRETCHBLT            = 0x0B23
+	META_DIBSTRETCHBLT         = 0x0B41
+	META_SETDIBTODEV           = 0x0D33
+	META_STRETCHDIB            = 0x0F43
+)
+
+// Enhanced metafile record types
+const (
+	EMR_HEADER                  = 1
+	EMR_POLYBEZIER              = 2
+	EMR_POLYGON                 = 3
+	EMR_POLYLINE                = 4
+	EMR_POLYBEZIERTO            = 5
+	EMR_POLYLINETO              = 6
+	EMR_POLYPOLYLINE            = 7
+	EMR_POLYPOLYGON             = 8
+	EMR_SETWINDOWEXTEX          = 9
+	EMR_SETWINDOWORGEX          = 10
+	EMR_SETVIEWPORTEXTEX        = 11
+	EMR_SETVIEWPORTORGEX        = 12
+	EMR_SETBRUSHORGEX           = 13
+	EMR_EOF                     = 14
+	EMR_SETPIXELV               = 15
+	EMR_SETMAPPERFLAGS          = 16
+	EMR_SETMAPMODE              = 17
+	EMR_SETBKMODE               = 18
+	EMR_SETPOLYFILLMODE         = 19
+	EMR_SETROP2                 = 20
+	EMR_SETSTRETCHBLTMODE       = 21
+	EMR_SETTEXTALIGN            = 22
+	EMR_SETCOLORADJUSTMENT      = 23
+	EMR_SETTEXTCOLOR            = 24
+	EMR_SETBKCOLOR              = 25
+	EMR_OFFSETCLIPRGN           = 26
+	EMR_MOVETOEX                = 27
+	EMR_SETMETARGN              = 28
+	EMR_EXCLUDECLIPRECT         = 29
+	EMR_INTERSECTCLIPRECT       = 30
+	EMR_SCALEVIEWPORTEXTEX      = 31
+	EMR_SCALEWINDOWEXTEX        = 32
+	EMR_SAVEDC                  = 33
+	EMR_RESTOREDC               = 34
+	EMR_SETWORLDTRANSFORM       = 35
+	EMR_MODIFYWORLDTRANSFORM    = 36
+	EMR_SELECTOBJECT            = 37
+	EMR_CREATEPEN               = 38
+	EMR_CREATEBRUSHINDIRECT     = 39
+	EMR_DELETEOBJECT            = 40
+	EMR_ANGLEARC                = 41
+	EMR_ELLIPSE                 = 42
+	EMR_RECTANGLE               = 43
+	EMR_ROUNDRECT               = 44
+	EMR_ARC                     = 45
+	EMR_CHORD                   = 46
+	EMR_PIE                     = 47
+	EMR_SELECTPALETTE           = 48
+	EMR_CREATEPALETTE           = 49
+	EMR_SETPALETTEENTRIES       = 50
+	EMR_RESIZEPALETTE           = 51
+	EMR_REALIZEPALETTE          = 52
+	EMR_EXTFLOODFILL            = 53
+	EMR_LINETO                  = 54
+	EMR_ARCTO                   = 55
+	EMR_POLYDRAW                = 56
+	EMR_SETARCDIRECTION         = 57
+	EMR_SETMITERLIMIT           = 58
+	EMR_BEGINPATH               = 59
+	EMR_ENDPATH                 = 60
+	EMR_CLOSEFIGURE             = 61
+	EMR_FILLPATH                = 62
+	EMR_STROKEANDFILLPATH       = 63
+	EMR_STROKEPATH              = 64
+	EMR_FLATTENPATH             = 65
+	EMR_WIDENPATH               = 66
+	EMR_SELECTCLIPPATH          = 67
+	EMR_ABORTPATH               = 68
+	EMR_GDICOMMENT              = 70
+	EMR_FILLRGN                 = 71
+	EMR_FRAMERGN                = 72
+	EMR_INVERTRGN               = 73
+	EMR_PAINTRGN                = 74
+	EMR_EXTSELECTCLIPRGN        = 75
+	EMR_BITBLT                  = 76
+	EMR_STRETCHBLT              = 77
+	EMR_MASKBLT                 = 78
+	EMR_PLGBLT                  = 79
+	EMR_SETDIBITSTODEVICE       = 80
+	EMR_STRETCHDIBITS           = 81
+	EMR_EXTCREATEFONTINDIRECTW  = 82
+	EMR_EXTTEXTOUTA             = 83
+	EMR_EXTTEXTOUTW             = 84
+	EMR_POLYBEZIER16            = 85
+	EMR_POLYGON16               = 86
+	EMR_POLYLINE16              = 87
+	EMR_POLYBEZIERTO16          = 88
+	EMR_POLYLINETO16            = 89
+	EMR_POLYPOLYLINE16          = 90
+	EMR_POLYPOLYGON16           = 91
+	EMR_POLYDRAW16              = 92
+	EMR_CREATEMONOBRUSH         = 93
+	EMR_CREATEDIBPATTERNBRUSHPT = 94
+	EMR_EXTCREATEPEN            = 95
+	EMR_POLYTEXTOUTA            = 96
+	EMR_POLYTEXTOUTW            = 97
+	EMR_SETICMMODE              = 98
+	EMR_CREATECOLORSPACE        = 99
+	EMR_SETCOLORSPACE           = 100
+	EMR_DELETECOLORSPACE        = 101
+	EMR_GLSRECORD               = 102
+	EMR_GLSBOUNDEDRECORD        = 103
+	EMR_PIXELFORMAT             = 104
+	EMR_DRAWESCAPE              = 105
+	EMR_EXTESCAPE               = 106
+	EMR_SMALLTEXTOUT            = 108
+	EMR_FORCEUFIMAPPING         = 109
+	EMR_NAMEDESCAPE             = 110
+	EMR_COLORCORRECTPALETTE     = 111
+	EMR_SETICMPROFILEA          = 112
+	EMR_SETICMPROFILEW          = 113
+	EMR_ALPHABLEND              = 114
+	EMR_SETLAYOUT               = 115
+	EMR_TRANSPARENTBLT          = 116
+	EMR_GRADIENTFILL            = 118
+	EMR_SETLINKEDUFIS           = 119
+	EMR_SETTEXTJUSTIFICATION    = 120
+	EMR_COLORMATCHTOTARGETW     = 121
+	EMR_CREATECOLORSPACEW       = 122
+)
+
+// HGDI_ERROR is returned by SelectObject on failure.
+const HGDI_ERROR = ^uintptr(0)
+
+// Mapping modes
+const (
+	MM_TEXT        = 1
+	MM_LOMETRIC    = 2
+	MM_HIMETRIC    = 3
+	MM_LOENGLISH   = 4
+	MM_HIENGLISH   = 5
+	MM_TWIPS       = 6
+	MM_ISOTROPIC   = 7
+	MM_ANISOTROPIC = 8
+	MM_MIN         = MM_TEXT
+	MM_MAX         = MM_ANISOTROPIC
+)
+
+// Graphics modes
+const (
+	GM_COMPATIBLE = 1
+	GM_ADVANCED   = 2
+)
+
+// ModifyWorldTransform modes
+const (
+	MWT_IDENTITY      = 1
+	MWT_LEFTMULTIPLY  = 2
+	MWT_RIGHTMULTIPLY = 3
+)
+
+// GetDeviceCaps indexes
+const (
+	DRIVERVERSION = 0
+	TECHNOLOGY    = 2
+	HORZSIZE      = 4
+	VERTSIZE      = 6
+	HORZRES       = 8
+	VERTRES       = 10
+	BITSPIXEL     = 12
+	PLANES        = 14
+	NUMCOLORS     = 24
+	ASPECTX       = 40
+	ASPECTY       = 42
+	ASPECTXY      = 44
+	LOGPIXELSX    = 88
+	LOGPIXELSY    = 90
+	SIZEPALETTE   = 104
+	NUMRESERVED   = 106
+	COLORRES      = 108
+)
+
+// Device technologies
+const (
+	DT_PLOTTER    = 0
+	DT_RASDISPLAY = 1
+	DT_RASPRINTER = 2
+	DT_RASCAMERA  = 3
+	DT_CHARSTREAM = 4
+	DT_METAFILE   = 5
+	DT_DISPFILE   = 6
+)
+
+const CLR_INVALID = 0xFFFFFFFF
+
+// Raster capabilities
+const (
+	RASTERCAPS = 38
+	RC_PALETTE = 0x0100
+)
+
+// ExtTextOut options
+const (
+	ETO_OPAQUE         = 0x0002
+	ETO_CLIPPED        = 0x0004
+	ETO_GLYPH_INDEX    = 0x0010
+	ETO_RTLREADING     = 0x0080
+	ETO_NO_RECT        = 0x0100
+	ETO_NUMERICSLOCAL  = 0x0400
+	ETO_NUMERICSLATIN  = 0x0800
+	ETO_IGNORELANGUAGE = 0x1000
+	ETO_PDY            = 0x2000
+)
+
+// Background modes
+const (
+	TRANSPARENT = 1
+	OPAQUE      = 2
+)
+
+const (
+	LF_FACESIZE     = 32
+	LF_FULLFACESIZE = 64
+)
 
 // Font weight constants
 const (
@@ -318,6 +2143,20 @@ const (
 	FF_SWISS      = 32
 )
 
+type METAFILEPICT struct {
+	Mm   int32
+	XExt int32
+	YExt int32
+	HMF  HMETAFILE
+}
+
+type PALETTEENTRY struct {
+	PeRed   byte
+	PeGreen byte
+	PeBlue  byte
+	PeFlags byte
+}
+
 type LOGFONT struct {
 	LfHeight         int32
 	LfWidth          int32
@@ -335,10 +2174,82 @@ type LOGFONT struct {
 	LfFaceName       [LF_FACESIZE]uint16
 }
 
+type ENUMLOGFONTEX struct {
+	ElfLogFont  LOGFONT
+	ElfFullName [LF_FULLFACESIZE]uint16
+	ElfStyle    [LF_FACESIZE]uint16
+	ElfScript   [LF_FACESIZE]uint16
+}
+
+type TEXTMETRIC struct {
+	TmHeight           int32
+	TmAscent           int32
+	TmDescent          int32
+	TmInternalLeading  int32
+	TmExternalLeading  int32
+	TmAveCharWidth     int32
+	TmMaxCharWidth     int32
+	TmWeight           int32
+	TmOverhang         int32
+	TmDigitizedAspectX int32
+	TmDigitizedAspectY int32
+	TmFirstChar        uint16
+	TmLastChar         uint16
+	TmDefaultChar      uint16
+	TmBreakChar        uint16
+	TmItalic           byte
+	TmUnderlined       byte
+	TmStruckOut        byte
+	TmPitchAndFamily   byte
+	TmCharSet          byte
+}
+
+type NEWTEXTMETRIC struct {
+	TEXTMETRIC
+	NtmFlags      DWORD
+	NtmSizeEM     UINT
+	NtmCellHeight UINT
+	NtmAvgWidth   UINT
+}
+
+type FONTSIGNATURE struct {
+	FsUsb [4]DWORD
+	FsCsb [2]DWORD
+}
+
+type NEWTEXTMETRICEX struct {
+	NtmTm      NEWTEXTMETRIC
+	NtmFontSig FONTSIGNATURE
+}
+
+type ABC struct {
+	AbcA int32
+	AbcB UINT
+	AbcC int32
+}
+
+type KERNINGPAIR struct {
+	WFirst      uint16
+	WSecond     uint16
+	IKernAmount int32
+}
+
 func RGB(r, g, b byte) COLORREF {
 	return COLORREF(r) | (COLORREF(g) << 8) | (COLORREF(b) << 16)
 }
 
+// PALETTERGB returns a color that is matched against the palette
+// selected into the device context instead of being dithered.
+func PALETTERGB(r, g, b byte) COLORREF {
+	return 0x02000000 | RGB(r, g, b)
+}
+
+// PALETTEINDEX returns a color referring to entry i of the palette
+// selected into the device context.
+func PALETTEINDEX(i WORD) COLORREF {
+	return 0x01000000 | COLORREF(i)
+}
+
 func (p COLORREF) GetRValue() byte {
 	return byte(p)
 }
@@ -362,3 +2273,409 @@ type POINT struct {
 type RECT struct {
 	Left, Top, Right, Bottom int32
 }
+
+// RGNDATAHEADER starts the RGNDATA of GetRegionData, followed by
+// NCount rectangles.
+type RGNDATAHEADER struct {
+	DwSize   DWORD
+	IType    DWORD
+	NCount   DWORD
+	NRgnSize DWORD
+	RcBound  RECT
+}
+
+type BITMAPINFOHEADER struct {
+	BiSize          DWORD
+	BiWidth         int32
+	BiHeight        int32
+	BiPlanes        WORD
+	BiBitCount      WORD
+	BiCompression   DWORD
+	BiSizeImage     DWORD
+	BiXPelsPerMeter int32
+	BiYPelsPerMeter int32
+	BiClrUsed       DWORD
+	BiClrImportant  DWORD
+}
+
+type RGBQUAD struct {
+	RgbBlue     byte
+	RgbGreen    byte
+	RgbRed      byte
+	RgbReserved byte
+}
+
+type BITMAPINFO struct {
+	BmiHeader BITMAPINFOHEADER
+	BmiColors [1]RGBQUAD
+}
+
+type CIEXYZ struct {
+	CiexyzX int32
+	CiexyzY int32
+	CiexyzZ int32
+}
+
+type CIEXYZTRIPLE struct {
+	CiexyzRed   CIEXYZ
+	CiexyzGreen CIEXYZ
+	CiexyzBlue  CIEXYZ
+}
+
+type BITMAPV5HEADER struct {
+	BV5Size          DWORD
+	BV5Width         int32
+	BV5Height        int32
+	BV5Planes        WORD
+	BV5BitCount      WORD
+	BV5Compression   DWORD
+	BV5SizeImage     DWORD
+	BV5XPelsPerMeter int32
+	BV5YPelsPerMeter int32
+	BV5ClrUsed       DWORD
+	BV5ClrImportant  DWORD
+	BV5RedMask       DWORD
+	BV5GreenMask     DWORD
+	BV5BlueMask      DWORD
+	BV5AlphaMask     DWORD
+	BV5CSType        DWORD
+	BV5Endpoints     CIEXYZTRIPLE
+	BV5GammaRed      DWORD
+	BV5GammaGreen    DWORD
+	BV5GammaBlue     DWORD
+	BV5Intent        DWORD
+	BV5ProfileData   DWORD
+	BV5ProfileSize   DWORD
+	BV5Reserved      DWORD
+}
+
+// DIB color table usage
+const (
+	DIB_RGB_COLORS = 0
+	DIB_PAL_COLORS = 1
+)
+
+// Logical color space types
+const (
+	LCS_CALIBRATED_RGB      = 0x00000000
+	LCS_sRGB                = 0x73524742
+	LCS_WINDOWS_COLOR_SPACE = 0x57696E20
+	PROFILE_LINKED          = 0x4C494E4B
+	PROFILE_EMBEDDED        = 0x4D424544
+)
+
+// Rendering intents
+const (
+	LCS_GM_BUSINESS         = 0x00000001
+	LCS_GM_GRAPHICS         = 0x00000002
+	LCS_GM_IMAGES           = 0x00000004
+	LCS_GM_ABS_COLORIMETRIC = 0x00000008
+)
+
+// Raster operations
+const (
+	SRCCOPY        = 0x00CC0020
+	SRCPAINT       = 0x00EE0086
+	SRCAND         = 0x008800C6
+	SRCINVERT      = 0x00660046
+	SRCERASE       = 0x00440328
+	NOTSRCCOPY     = 0x00330008
+	NOTSRCERASE    = 0x001100A6
+	MERGECOPY      = 0x00C000CA
+	MERGEPAINT     = 0x00BB0226
+	PATCOPY        = 0x00F00021
+	PATPAINT       = 0x00FB0A09
+	PATINVERT      = 0x005A0049
+	DSTINVERT      = 0x00550009
+	BLACKNESS      = 0x00000042
+	WHITENESS      = 0x00FF0062
+	NOMIRRORBITMAP = 0x80000000
+	CAPTUREBLT     = 0x40000000
+)
+
+// Stretch modes
+const (
+	BLACKONWHITE = 1
+	WHITEONBLACK = 2
+	COLORONCOLOR = 3
+	HALFTONE     = 4
+
+	STRETCH_ANDSCANS    = BLACKONWHITE
+	STRETCH_ORSCANS     = WHITEONBLACK
+	STRETCH_DELETESCANS = COLORONCOLOR
+	STRETCH_HALFTONE    = HALFTONE
+)
+
+// Ternary raster operations by truth table. Windows only looks at bits
+// 16 to 23 of a raster operation, which hold the result for each
+// combination of pattern, source and destination bits as described by
+// ROP3, so these are equivalent to the documented codes: SRCCOPY is
+// ROP3_CC, PATCOPY is ROP3_F0 and so on.
+const (
+	ROP3_00 = 0x00000000
+	ROP3_01 = 0x00010000
+	ROP3_02 = 0x00020000
+	ROP3_03 = 0x00030000
+	ROP3_04 = 0x00040000
+	ROP3_05 = 0x00050000
+	ROP3_06 = 0x00060000
+	ROP3_07 = 0x00070000
+	ROP3_08 = 0x00080000
+	ROP3_09 = 0x00090000
+	ROP3_0A = 0x000A0000
+	ROP3_0B = 0x000B0000
+	ROP3_0C = 0x000C0000
+	ROP3_0D = 0x000D0000
+	ROP3_0E = 0x000E0000
+	ROP3_0F = 0x000F0000
+	ROP3_10 = 0x00100000
+	ROP3_11 = 0x00110000
+	ROP3_12 = 0x00120000
+	ROP3_13 = 0x00130000
+	ROP3_14 = 0x00140000
+	ROP3_15 = 0x00150000
+	ROP3_16 = 0x00160000
+	ROP3_17 = 0x00170000
+	ROP3_18 = 0x00180000
+	ROP3_19 = 0x00190000
+	ROP3_1A = 0x001A0000
+	ROP3_1B = 0x001B0000
+	ROP3_1C = 0x001C0000
+	ROP3_1D = 0x001D0000
+	ROP3_1E = 0x001E0000
+	ROP3_1F = 0x001F0000
+	ROP3_20 = 0x00200000
+	ROP3_21 = 0x00210000
+	ROP3_22 = 0x00220000
+	ROP3_23 = 0x00230000
+	ROP3_24 = 0x00240000
+	ROP3_25 = 0x00250000
+	ROP3_26 = 0x00260000
+	ROP3_27 = 0x00270000
+	ROP3_28 = 0x00280000
+	ROP3_29 = 0x00290000
+	ROP3_2A = 0x002A0000
+	ROP3_2B = 0x002B0000
+	ROP3_2C = 0x002C0000
+	ROP3_2D = 0x002D0000
+	ROP3_2E = 0x002E0000
+	ROP3_2F = 0x002F0000
+	ROP3_30 = 0x00300000
+	ROP3_31 = 0x00310000
+	ROP3_32 = 0x00320000
+	ROP3_33 = 0x00330000
+	ROP3_34 = 0x00340000
+	ROP3_35 = 0x00350000
+	ROP3_36 = 0x00360000
+	ROP3_37 = 0x00370000
+	ROP3_38 = 0x00380000
+	ROP3_39 = 0x00390000
+	ROP3_3A = 0x003A0000
+	ROP3_3B = 0x003B0000
+	ROP3_3C = 0x003C0000
+	ROP3_3D = 0x003D0000
+	ROP3_3E = 0x003E0000
+	ROP3_3F = 0x003F0000
+	ROP3_40 = 0x00400000
+	ROP3_41 = 0x00410000
+	ROP3_42 = 0x00420000
+	ROP3_43 = 0x00430000
+	ROP3_44 = 0x00440000
+	ROP3_45 = 0x00450000
+	ROP3_46 = 0x00460000
+	ROP3_47 = 0x00470000
+	ROP3_48 = 0x00480000
+	ROP3_49 = 0x00490000
+	ROP3_4A = 0x004A0000
+	ROP3_4B = 0x004B0000
+	ROP3_4C = 0x004C0000
+	ROP3_4D = 0x004D0000
+	ROP3_4E = 0x004E0000
+	ROP3_4F = 0x004F0000
+	ROP3_50 = 0x00500000
+	ROP3_51 = 0x00510000
+	ROP3_52 = 0x00520000
+	ROP3_53 = 0x00530000
+	ROP3_54 = 0x00540000
+	ROP3_55 = 0x00550000
+	ROP3_56 = 0x00560000
+	ROP3_57 = 0x00570000
+	ROP3_58 = 0x00580000
+	ROP3_59 = 0x00590000
+	ROP3_5A = 0x005A0000
+	ROP3_5B = 0x005B0000
+	ROP3_5C = 0x005C0000
+	ROP3_5D = 0x005D0000
+	ROP3_5E = 0x005E0000
+	ROP3_5F = 0x005F0000
+	ROP3_60 = 0x00600000
+	ROP3_61 = 0x00610000
+	ROP3_62 = 0x00620000
+	ROP3_63 = 0x00630000
+	ROP3_64 = 0x00640000
+	ROP3_65 = 0x00650000
+	ROP3_66 = 0x00660000
+	ROP3_67 = 0x00670000
+	ROP3_68 = 0x00680000
+	ROP3_69 = 0x00690000
+	ROP3_6A = 0x006A0000
+	ROP3_6B = 0x006B0000
+	ROP3_6C = 0x006C0000
+	ROP3_6D = 0x006D0000
+	ROP3_6E = 0x006E0000
+	ROP3_6F = 0x006F0000
+	ROP3_70 = 0x00700000
+	ROP3_71 = 0x00710000
+	ROP3_72 = 0x00720000
+	ROP3_73 = 0x00730000
+	ROP3_74 = 0x00740000
+	ROP3_75 = 0x00750000
+	ROP3_76 = 0x00760000
+	ROP3_77 = 0x00770000
+	ROP3_78 = 0x00780000
+	ROP3_79 = 0x00790000
+	ROP3_7A = 0x007A0000
+	ROP3_7B = 0x007B0000
+	ROP3_7C = 0x007C0000
+	ROP3_7D = 0x007D0000
+	ROP3_7E = 0x007E0000
+	ROP3_7F = 0x007F0000
+	ROP3_80 = 0x00800000
+	ROP3_81 = 0x00810000
+	ROP3_82 = 0x00820000
+	ROP3_83 = 0x00830000
+	ROP3_84 = 0x00840000
+	ROP3_85 = 0x00850000
+	ROP3_86 = 0x00860000
+	ROP3_87 = 0x00870000
+	ROP3_88 = 0x00880000
+	ROP3_89 = 0x00890000
+	ROP3_8A = 0x008A0000
+	ROP3_8B = 0x008B0000
+	ROP3_8C = 0x008C0000
+	ROP3_8D = 0x008D0000
+	ROP3_8E = 0x008E0000
+	ROP3_8F = 0x008F0000
+	ROP3_90 = 0x00900000
+	ROP3_91 = 0x00910000
+	ROP3_92 = 0x00920000
+	ROP3_93 = 0x00930000
+	ROP3_94 = 0x00940000
+	ROP3_95 = 0x00950000
+	ROP3_96 = 0x00960000
+	ROP3_97 = 0x00970000
+	ROP3_98 = 0x00980000
+	ROP3_99 = 0x00990000
+	ROP3_9A = 0x009A0000
+	ROP3_9B = 0x009B0000
+	ROP3_9C = 0x009C0000
+	ROP3_9D = 0x009D0000
+	ROP3_9E = 0x009E0000
+	ROP3_9F = 0x009F0000
+	ROP3_A0 = 0x00A00000
+	ROP3_A1 = 0x00A10000
+	ROP3_A2 = 0x00A20000
+	ROP3_A3 = 0x00A30000
+	ROP3_A4 = 0x00A40000
+	ROP3_A5 = 0x00A50000
+	ROP3_A6 = 0x00A60000
+	ROP3_A7 = 0x00A70000
+	ROP3_A8 = 0x00A80000
+	ROP3_A9 = 0x00A90000
+	ROP3_AA = 0x00AA0000
+	ROP3_AB = 0x00AB0000
+	ROP3_AC = 0x00AC0000
+	ROP3_AD = 0x00AD0000
+	ROP3_AE = 0x00AE0000
+	ROP3_AF = 0x00AF0000
+	ROP3_B0 = 0x00B00000
+	ROP3_B1 = 0x00B10000
+	ROP3_B2 = 0x00B20000
+	ROP3_B3 = 0x00B30000
+	ROP3_B4 = 0x00B40000
+	ROP3_B5 = 0x00B50000
+	ROP3_B6 = 0x00B60000
+	ROP3_B7 = 0x00B70000
+	ROP3_B8 = 0x00B80000
+	ROP3_B9 = 0x00B90000
+	ROP3_BA = 0x00BA0000
+	ROP3_BB = 0x00BB0000
+	ROP3_BC = 0x00BC0000
+	ROP3_BD = 0x00BD0000
+	ROP3_BE = 0x00BE0000
+	ROP3_BF = 0x00BF0000
+	ROP3_C0 = 0x00C00000
+	ROP3_C1 = 0x00C10000
+	ROP3_C2 = 0x00C20000
+	ROP3_C3 = 0x00C30000
+	ROP3_C4 = 0x00C40000
+	ROP3_C5 = 0x00C50000
+	ROP3_C6 = 0x00C60000
+	ROP3_C7 = 0x00C70000
+	ROP3_C8 = 0x00C80000
+	ROP3_C9 = 0x00C90000
+	ROP3_CA = 0x00CA0000
+	ROP3_CB = 0x00CB0000
+	ROP3_CC = 0x00CC0000
+	ROP3_CD = 0x00CD0000
+	ROP3_CE = 0x00CE0000
+	ROP3_CF = 0x00CF0000
+	ROP3_D0 = 0x00D00000
+	ROP3_D1 = 0x00D10000
+	ROP3_D2 = 0x00D20000
+	ROP3_D3 = 0x00D30000
+	ROP3_D4 = 0x00D40000
+	ROP3_D5 = 0x00D50000
+	ROP3_D6 = 0x00D60000
+	ROP3_D7 = 0x00D70000
+	ROP3_D8 = 0x00D80000
+	ROP3_D9 = 0x00D90000
+	ROP3_DA = 0x00DA0000
+	ROP3_DB = 0x00DB0000
+	ROP3_DC = 0x00DC0000
+	ROP3_DD = 0x00DD0000
+	ROP3_DE = 0x00DE0000
+	ROP3_DF = 0x00DF0000
+	ROP3_E0 = 0x00E00000
+	ROP3_E1 = 0x00E10000
+	ROP3_E2 = 0x00E20000
+	ROP3_E3 = 0x00E30000
+	ROP3_E4 = 0x00E40000
+	ROP3_E5 = 0x00E50000
+	ROP3_E6 = 0x00E60000
+	ROP3_E7 = 0x00E70000
+	ROP3_E8 = 0x00E80000
+	ROP3_E9 = 0x00E90000
+	ROP3_EA = 0x00EA0000
+	ROP3_EB = 0x00EB0000
+	ROP3_EC = 0x00EC0000
+	ROP3_ED = 0x00ED0000
+	ROP3_EE = 0x00EE0000
+	ROP3_EF = 0x00EF0000
+	ROP3_F0 = 0x00F00000
+	ROP3_F1 = 0x00F10000
+	ROP3_F2 = 0x00F20000
+	ROP3_F3 = 0x00F30000
+	ROP3_F4 = 0x00F40000
+	ROP3_F5 = 0x00F50000
+	ROP3_F6 = 0x00F60000
+	ROP3_F7 = 0x00F70000
+	ROP3_F8 = 0x00F80000
+	ROP3_F9 = 0x00F90000
+	ROP3_FA = 0x00FA0000
+	ROP3_FB = 0x00FB0000
+	ROP3_FC = 0x00FC0000
+	ROP3_FD = 0x00FD0000
+	ROP3_FE = 0x00FE0000
+	ROP3_FF = 0x00FF0000
+)
+
+// Bitmap compression constants
+const (
+	BI_RGB       = 0
+	BI_RLE8      = 1
+	BI_RLE4      = 2
+	BI_BITFIELDS = 3
+	BI_JPEG      = 4
+	BI_PNG       = 5
+)
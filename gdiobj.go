@@ -0,0 +1,106 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// GDIObjectLeak describes a GDI object created while tracking was
+// enabled and not yet deleted.
+type GDIObjectLeak struct {
+	Object  HGDIOBJ
+	Creator string
+	stack   []uintptr
+	seq     uint64
+}
+
+// Stack returns the stack trace of the creating call.
+func (l GDIObjectLeak) Stack() string {
+	var sb strings.Builder
+	frames := runtime.CallersFrames(l.stack)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			break
+		}
+	}
+
+	return sb.String()
+}
+
+func (l GDIObjectLeak) String() string {
+	return fmt.Sprintf("%s %#x created at\n%s", l.Creator, uintptr(l.Object), l.Stack())
+}
+
+var (
+	gdiTracking     atomic.Bool
+	gdiTrackMutex   sync.Mutex
+	gdiTrackObjects = map[uintptr]GDIObjectLeak{}
+	gdiTrackSeq     uint64
+)
+
+// TrackGDIObjects turns recording of the GDI objects created through
+// this package on or off. Recording stores a stack trace for every
+// object, so it is meant for tests and debugging. Turning it off
+// forgets the recorded objects.
+func TrackGDIObjects(on bool) {
+	gdiTrackMutex.Lock()
+	defer gdiTrackMutex.Unlock()
+
+	gdiTracking.Store(on)
+	if !on {
+		gdiTrackObjects = map[uintptr]GDIObjectLeak{}
+	}
+}
+
+// GDIObjectLeaks returns the objects created since tracking was turned
+// on that have not been deleted with DeleteObject, oldest first.
+func GDIObjectLeaks() []GDIObjectLeak {
+	gdiTrackMutex.Lock()
+	defer gdiTrackMutex.Unlock()
+
+	leaks := make([]GDIObjectLeak, 0, len(gdiTrackObjects))
+	for _, l := range gdiTrackObjects {
+		leaks = append(leaks, l)
+	}
+	sort.Slice(leaks, func(i, j int) bool { return leaks[i].seq < leaks[j].seq })
+
+	return leaks
+}
+
+// trackGDIObject records h, returned by the creating function creator.
+func trackGDIObject(h uintptr, creator string) {
+	if h == 0 || !gdiTracking.Load() {
+		return
+	}
+
+	// Skip runtime.Callers, trackGDIObject and the wrapper itself.
+	pc := make([]uintptr, 32)
+	pc = pc[:runtime.Callers(3, pc)]
+
+	gdiTrackMutex.Lock()
+	defer gdiTrackMutex.Unlock()
+
+	gdiTrackSeq++
+	gdiTrackObjects[h] = GDIObjectLeak{Object: HGDIOBJ(h), Creator: creator, stack: pc, seq: gdiTrackSeq}
+}
+
+func untrackGDIObject(h uintptr) {
+	if !gdiTracking.Load() {
+		return
+	}
+
+	gdiTrackMutex.Lock()
+	defer gdiTrackMutex.Unlock()
+
+	delete(gdiTrackObjects, h)
+}
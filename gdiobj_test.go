@@ -0,0 +1,37 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import "testing"
+
+func TestGDIObjectLeaksOrder(t *testing.T) {
+	TrackGDIObjects(true)
+	defer TrackGDIObjects(false)
+
+	// Handles are recycled, so a newer object can have a lower value.
+	for _, h := range []uintptr{0x300, 0x100, 0x200, 0x50} {
+		trackGDIObject(h, "CreatePen")
+	}
+	untrackGDIObject(0x200)
+
+	var got []HGDIOBJ
+	for _, l := range GDIObjectLeaks() {
+		got = append(got, l.Object)
+	}
+	want := []HGDIOBJ{0x300, 0x100, 0x50}
+	if len(got) != len(want) {
+		t.Fatalf("leaks %#x, want %#x", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("leaks %#x, want %#x", got, want)
+		}
+	}
+
+	TrackGDIObjects(false)
+	if leaks := GDIObjectLeaks(); len(leaks) != 0 {
+		t.Errorf("%d leaks after tracking was turned off", len(leaks))
+	}
+}
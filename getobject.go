@@ -0,0 +1,349 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"unsafe"
+)
+
+type BITMAP struct {
+	BmType       int32
+	BmWidth      int32
+	BmHeight     int32
+	BmWidthBytes int32
+	BmPlanes     WORD
+	BmBitsPixel  WORD
+	BmBits       uintptr
+}
+
+type DIBSECTION struct {
+	DsBm        BITMAP
+	DsBmih      BITMAPINFOHEADER
+	DsBitfields [3]DWORD
+	DshSection  HANDLE
+	DsOffset    DWORD
+}
+
+// EXTLOGPEN describes a pen created by ExtCreatePen. Unlike the C
+// structure it holds the style entries in a slice, so it cannot be
+// passed to Windows directly.
+type EXTLOGPEN struct {
+	ElpPenStyle   DWORD
+	ElpWidth      DWORD
+	ElpBrushStyle UINT
+	ElpColor      COLORREF
+	ElpHatch      uintptr
+	ElpStyleEntry []DWORD
+}
+
+var (
+	ErrGDIObjectType    = errors.New("winapi: wrong GDI object type")
+	ErrGDIObjectInvalid = errors.New("winapi: invalid GDI object data")
+)
+
+// Sizes of the structures returned by GetObject on 32 and 64 bit
+// Windows. The decoders accept either, so data captured on one can be
+// decoded on the other.
+const (
+	logFontSize      = 92
+	logPenSize       = 16
+	logBrushSize32   = 12
+	logBrushSize64   = 16
+	bitmapSize32     = 24
+	bitmapSize64     = 32
+	dibSectionSize32 = 84
+	dibSectionSize64 = 104
+)
+
+// GetObjectFont returns the description of a font.
+func GetObjectFont(h HFONT) (LOGFONT, error) {
+	b, err := getObjectOfType(HGDIOBJ(h), logFontSize, OBJ_FONT)
+	if err != nil {
+		return LOGFONT{}, err
+	}
+
+	return DecodeLOGFONT(b)
+}
+
+// GetObjectPen returns the description of a pen. Pens created by
+// CreatePen are returned as a solid brush pen with no style entries.
+func GetObjectPen(h HPEN) (EXTLOGPEN, error) {
+	switch GetObjectType(HGDIOBJ(h)) {
+	case OBJ_PEN:
+		b, err := getObject(HGDIOBJ(h), logPenSize)
+		if err != nil {
+			return EXTLOGPEN{}, err
+		}
+		lp, err := DecodeLOGPEN(b)
+		if err != nil {
+			return EXTLOGPEN{}, err
+		}
+		return EXTLOGPEN{
+			ElpPenStyle:   DWORD(lp.LopnStyle),
+			ElpWidth:      DWORD(lp.LopnWidth.X),
+			ElpBrushStyle: BS_SOLID,
+			ElpColor:      lp.LopnColor,
+		}, nil
+	case OBJ_EXTPEN:
+		b, err := getObject(HGDIOBJ(h), 0)
+		if err != nil {
+			return EXTLOGPEN{}, err
+		}
+		return DecodeEXTLOGPEN(b)
+	}
+
+	return EXTLOGPEN{}, ErrGDIObjectType
+}
+
+// GetObjectBrush returns the description of a brush.
+func GetObjectBrush(h HBRUSH) (LOGBRUSH, error) {
+	b, err := getObjectOfType(HGDIOBJ(h), int(unsafe.Sizeof(LOGBRUSH{})), OBJ_BRUSH)
+	if err != nil {
+		return LOGBRUSH{}, err
+	}
+
+	return DecodeLOGBRUSH(b)
+}
+
+// GetObjectBitmap returns the description of a bitmap. The DIBSECTION
+// is nil unless the bitmap is a DIB section.
+func GetObjectBitmap(h HBITMAP) (BITMAP, *DIBSECTION, error) {
+	b, err := getObjectOfType(HGDIOBJ(h), int(unsafe.Sizeof(DIBSECTION{})), OBJ_BITMAP)
+	if err != nil {
+		return BITMAP{}, nil, err
+	}
+
+	if len(b) == int(unsafe.Sizeof(DIBSECTION{})) {
+		ds, err := DecodeDIBSECTION(b)
+		if err != nil {
+			return BITMAP{}, nil, err
+		}
+		return ds.DsBm, &ds, nil
+	}
+
+	bm, err := DecodeBITMAP(b)
+
+	return bm, nil, err
+}
+
+// GetObjectPalette returns the number of entries in a palette.
+func GetObjectPalette(h HPALETTE) (int, error) {
+	b, err := getObjectOfType(HGDIOBJ(h), 2, OBJ_PAL)
+	if err != nil {
+		return 0, err
+	}
+
+	return DecodePaletteEntryCount(b)
+}
+
+// GetObjectValue returns the description of any object GetObject
+// supports, as the value the matching GetObject function returns:
+// LOGFONT, EXTLOGPEN, LOGBRUSH, BITMAP, *DIBSECTION or, for palettes,
+// the entry count.
+func GetObjectValue(h HGDIOBJ) (interface{}, error) {
+	switch GetObjectType(h) {
+	case OBJ_FONT:
+		return GetObjectFont(HFONT(h))
+	case OBJ_PEN, OBJ_EXTPEN:
+		return GetObjectPen(HPEN(h))
+	case OBJ_BRUSH:
+		return GetObjectBrush(HBRUSH(h))
+	case OBJ_BITMAP:
+		bm, ds, err := GetObjectBitmap(HBITMAP(h))
+		if ds != nil {
+			return ds, err
+		}
+		return bm, err
+	case OBJ_PAL:
+		return GetObjectPalette(HPALETTE(h))
+	case 0:
+		return nil, lastError
+	}
+
+	return nil, ErrGDIObjectType
+}
+
+func getObjectOfType(h HGDIOBJ, size int, typ DWORD) ([]byte, error) {
+	if GetObjectType(h) != typ {
+		return nil, ErrGDIObjectType
+	}
+
+	return getObject(h, size)
+}
+
+// getObject returns GetObject's description of h into a buffer of size
+// bytes, or of the size GetObject asks for if size is 0.
+func getObject(h HGDIOBJ, size int) ([]byte, error) {
+	if size == 0 {
+		var ret uintptr
+		ret, _, lastError = procGetObjectW.Call(uintptr(h), 0, 0)
+		if ret == 0 {
+			return nil, lastError
+		}
+		size = int(ret)
+	}
+
+	buf := make([]byte, size)
+	var ret uintptr
+	ret, _, lastError = procGetObjectW.Call(uintptr(h), uintptr(size), uintptr(unsafe.Pointer(&buf[0])))
+	if ret == 0 {
+		return nil, lastError
+	}
+
+	return buf[:ret], nil
+}
+
+func DecodeLOGFONT(b []byte) (LOGFONT, error) {
+	var lf LOGFONT
+	if len(b) < logFontSize {
+		return lf, ErrGDIObjectInvalid
+	}
+	binary.Read(bytes.NewReader(b), binary.LittleEndian, &lf)
+
+	return lf, nil
+}
+
+func DecodeLOGPEN(b []byte) (LOGPEN, error) {
+	var lp LOGPEN
+	if len(b) < logPenSize {
+		return lp, ErrGDIObjectInvalid
+	}
+	binary.Read(bytes.NewReader(b), binary.LittleEndian, &lp)
+
+	return lp, nil
+}
+
+// DecodeEXTLOGPEN decodes an EXTLOGPEN followed by its style entries.
+func DecodeEXTLOGPEN(b []byte) (EXTLOGPEN, error) {
+	// ElpHatch is pointer sized, so the entry count follows it at 20 or
+	// 24 and must account for the rest of the data.
+	for _, hatch := range []int{ptrLayout(8, 4), ptrLayout(4, 8)} {
+		count := 16 + hatch
+		if len(b) < count+4 {
+			continue
+		}
+		n := binary.LittleEndian.Uint32(b[count:])
+		if uint64(n) > uint64(len(b)/4) || len(b) < count+4+4*int(n) || len(b) > count+8+4*int(n) {
+			continue
+		}
+
+		lp := EXTLOGPEN{
+			ElpPenStyle:   DWORD(binary.LittleEndian.Uint32(b)),
+			ElpWidth:      DWORD(binary.LittleEndian.Uint32(b[4:])),
+			ElpBrushStyle: UINT(binary.LittleEndian.Uint32(b[8:])),
+			ElpColor:      COLORREF(binary.LittleEndian.Uint32(b[12:])),
+			ElpHatch:      uintptr(readPtr(b[16:], hatch)),
+		}
+		for i := 0; i < int(n); i++ {
+			lp.ElpStyleEntry = append(lp.ElpStyleEntry, DWORD(binary.LittleEndian.Uint32(b[count+4+4*i:])))
+		}
+		return lp, nil
+	}
+
+	return EXTLOGPEN{}, ErrGDIObjectInvalid
+}
+
+func DecodeLOGBRUSH(b []byte) (LOGBRUSH, error) {
+	var lb LOGBRUSH
+	var hatch int
+	switch len(b) {
+	case logBrushSize32:
+		hatch = 4
+	case logBrushSize64:
+		hatch = 8
+	default:
+		return lb, ErrGDIObjectInvalid
+	}
+
+	lb.LbStyle = UINT(binary.LittleEndian.Uint32(b))
+	lb.LbColor = COLORREF(binary.LittleEndian.Uint32(b[4:]))
+	lb.LbHatch = uintptr(readPtr(b[8:], hatch))
+
+	return lb, nil
+}
+
+func DecodeBITMAP(b []byte) (BITMAP, error) {
+	switch len(b) {
+	case bitmapSize32:
+		return decodeBITMAP(b, 4), nil
+	case bitmapSize64:
+		return decodeBITMAP(b, 8), nil
+	}
+
+	return BITMAP{}, ErrGDIObjectInvalid
+}
+
+func decodeBITMAP(b []byte, ptr int) BITMAP {
+	return BITMAP{
+		BmType:       int32(binary.LittleEndian.Uint32(b)),
+		BmWidth:      int32(binary.LittleEndian.Uint32(b[4:])),
+		BmHeight:     int32(binary.LittleEndian.Uint32(b[8:])),
+		BmWidthBytes: int32(binary.LittleEndian.Uint32(b[12:])),
+		BmPlanes:     WORD(binary.LittleEndian.Uint16(b[16:])),
+		BmBitsPixel:  WORD(binary.LittleEndian.Uint16(b[18:])),
+		BmBits:       uintptr(readPtr(b[20+ptr-4:], ptr)),
+	}
+}
+
+func DecodeDIBSECTION(b []byte) (DIBSECTION, error) {
+	var ds DIBSECTION
+	var ptr int
+	switch len(b) {
+	case dibSectionSize32:
+		ptr = 4
+	case dibSectionSize64:
+		ptr = 8
+	default:
+		return ds, ErrGDIObjectInvalid
+	}
+
+	bm := bitmapSize32
+	if ptr == 8 {
+		bm = bitmapSize64
+	}
+	ds.DsBm = decodeBITMAP(b, ptr)
+	binary.Read(bytes.NewReader(b[bm:]), binary.LittleEndian, &ds.DsBmih)
+	for i := range ds.DsBitfields {
+		ds.DsBitfields[i] = DWORD(binary.LittleEndian.Uint32(b[bm+40+4*i:]))
+	}
+
+	// dshSection is pointer aligned.
+	off := (bm + 52 + ptr - 1) &^ (ptr - 1)
+	ds.DshSection = HANDLE(readPtr(b[off:], ptr))
+	ds.DsOffset = DWORD(binary.LittleEndian.Uint32(b[off+ptr:]))
+
+	return ds, nil
+}
+
+// DecodePaletteEntryCount decodes the entry count GetObject returns for
+// a palette.
+func DecodePaletteEntryCount(b []byte) (int, error) {
+	if len(b) < 2 {
+		return 0, ErrGDIObjectInvalid
+	}
+
+	return int(binary.LittleEndian.Uint16(b)), nil
+}
+
+// ptrLayout returns a if pointers are 8 bytes on this platform, b
+// otherwise, so decoders try the native layout first.
+func ptrLayout(a, b int) int {
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		return a
+	}
+
+	return b
+}
+
+func readPtr(b []byte, size int) uint64 {
+	if size == 8 {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return uint64(binary.LittleEndian.Uint32(b))
+}
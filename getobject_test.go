@@ -0,0 +1,198 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// The descriptions GetObject returns on 32 and 64 bit Windows for a
+// Segoe UI font, an ExtCreatePen pen with style entries, a cosmetic pen,
+// a hatched brush, a bitmap and a 32x16 BI_BITFIELDS DIB section.
+// Padding is filled with 0xcc so reading it shows.
+const (
+	testLogFont = "" +
+		"f4ffffff00000000000000000000000090010000000000010000050053006500" +
+		"67006f0065002000550049000000000000000000000000000000000000000000" +
+		"00000000000000000000000000000000000000000000000000000000"
+
+	testExtLogPen32 = "" +
+		"070201000500000002000000ff00000004000000030000000400000002000000" +
+		"01000000"
+	testExtLogPen64 = "" +
+		"070201000500000002000000ff00000004000000000000000300000004000000" +
+		"0200000001000000"
+
+	testCosmeticPen32 = "000000000100000000000000808080000000000000000000"
+	testCosmeticPen64 = "0000000001000000000000008080800000000000000000000000000000000000"
+
+	testLogBrush32 = "02000000ff00000004000000"
+	testLogBrush64 = "02000000ff0000000400000000000000"
+
+	testBitmap32 = "00000000200000001000000080000000010020000000a400"
+	testBitmap64 = "0000000020000000100000008000000001002000cccccccc0000a2f5d4010000"
+
+	testDIBSection32 = "" +
+		"00000000200000001000000080000000010020000000a4002800000020000000" +
+		"f0ffffff01002000030000000008000000000000000000000000000000000000" +
+		"0000ff0000ff0000ff000000f401000040000000"
+	// dshSection is 8 byte aligned, at 88 rather than 84.
+	testDIBSection64 = "" +
+		"0000000020000000100000008000000001002000cccccccc0000a2f5d4010000" +
+		"2800000020000000f0ffffff0100200003000000000800000000000000000000" +
+		"00000000000000000000ff0000ff0000ff000000cccccccc3c0a000000000000" +
+		"40000000cccccccc"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestDecodeLOGFONT(t *testing.T) {
+	lf, err := DecodeLOGFONT(mustHex(t, testLogFont))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := LOGFONT{LfHeight: -12, LfWeight: FW_NORMAL, LfCharSet: DEFAULT_CHARSET, LfQuality: CLEARTYPE_QUALITY}
+	copy(want.LfFaceName[:], utf16Units("Segoe UI"))
+	if lf != want {
+		t.Errorf("DecodeLOGFONT = %+v, want %+v", lf, want)
+	}
+
+	if _, err := DecodeLOGFONT(mustHex(t, testLogFont)[:logFontSize-1]); err != ErrGDIObjectInvalid {
+		t.Errorf("DecodeLOGFONT of %d bytes: err = %v, want ErrGDIObjectInvalid", logFontSize-1, err)
+	}
+}
+
+func TestDecodeEXTLOGPEN(t *testing.T) {
+	geometric := EXTLOGPEN{
+		ElpPenStyle:   PS_GEOMETRIC | PS_USERSTYLE | PS_ENDCAP_FLAT,
+		ElpWidth:      5,
+		ElpBrushStyle: BS_HATCHED,
+		ElpColor:      RGB(255, 0, 0),
+		ElpHatch:      HS_CROSS,
+		ElpStyleEntry: []DWORD{4, 2, 1},
+	}
+	cosmetic := EXTLOGPEN{ElpPenStyle: PS_COSMETIC | PS_SOLID, ElpWidth: 1, ElpColor: RGB(128, 128, 128)}
+
+	tests := []struct {
+		name string
+		data string
+		want EXTLOGPEN
+	}{
+		{"32 bit", testExtLogPen32, geometric},
+		{"64 bit", testExtLogPen64, geometric},
+		{"32 bit cosmetic", testCosmeticPen32, cosmetic},
+		{"64 bit cosmetic", testCosmeticPen64, cosmetic},
+	}
+	for _, tt := range tests {
+		lp, err := DecodeEXTLOGPEN(mustHex(t, tt.data))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(lp, tt.want) {
+			t.Errorf("%s: DecodeEXTLOGPEN = %+v, want %+v", tt.name, lp, tt.want)
+		}
+	}
+}
+
+func TestDecodeEXTLOGPENInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"no count", mustHex(t, testExtLogPen32)[:20]},
+		{"missing entry", mustHex(t, testExtLogPen32)[:32]},
+		{"trailing entry", append(mustHex(t, testExtLogPen64), 9, 0, 0, 0, 9, 0, 0, 0)},
+		{"count too large", mustHex(t, "0000000001000000000000000000000000000000ffffffff")},
+	}
+	for _, tt := range tests {
+		if lp, err := DecodeEXTLOGPEN(tt.data); err != ErrGDIObjectInvalid {
+			t.Errorf("%s: DecodeEXTLOGPEN = %+v, %v, want ErrGDIObjectInvalid", tt.name, lp, err)
+		}
+	}
+}
+
+func TestDecodeLOGBRUSH(t *testing.T) {
+	want := LOGBRUSH{LbStyle: BS_HATCHED, LbColor: RGB(255, 0, 0), LbHatch: HS_CROSS}
+	for _, data := range []string{testLogBrush32, testLogBrush64} {
+		lb, err := DecodeLOGBRUSH(mustHex(t, data))
+		if err != nil || lb != want {
+			t.Errorf("DecodeLOGBRUSH(%s) = %+v, %v, want %+v", data, lb, err, want)
+		}
+	}
+
+	if _, err := DecodeLOGBRUSH(mustHex(t, testLogBrush64)[:14]); err != ErrGDIObjectInvalid {
+		t.Errorf("DecodeLOGBRUSH of 14 bytes: err = %v, want ErrGDIObjectInvalid", err)
+	}
+}
+
+func TestDecodeBITMAP(t *testing.T) {
+	bits64 := uint64(0x000001D4F5A20000)
+	tests := []struct {
+		data string
+		bits uintptr
+	}{
+		{testBitmap32, 0x00A40000},
+		{testBitmap64, uintptr(bits64)},
+	}
+	for _, tt := range tests {
+		want := BITMAP{BmWidth: 32, BmHeight: 16, BmWidthBytes: 128, BmPlanes: 1, BmBitsPixel: 32, BmBits: tt.bits}
+		bm, err := DecodeBITMAP(mustHex(t, tt.data))
+		if err != nil || bm != want {
+			t.Errorf("DecodeBITMAP(%s) = %+v, %v, want %+v", tt.data, bm, err, want)
+		}
+	}
+
+	if _, err := DecodeBITMAP(make([]byte, 28)); err != ErrGDIObjectInvalid {
+		t.Errorf("DecodeBITMAP of 28 bytes: err = %v, want ErrGDIObjectInvalid", err)
+	}
+}
+
+func TestDecodeDIBSECTION(t *testing.T) {
+	bits64, section64 := uint64(0x000001D4F5A20000), uint64(0xA3C)
+	tests := []struct {
+		data    string
+		bits    uintptr
+		section HANDLE
+	}{
+		{testDIBSection32, 0x00A40000, 0x1F4},
+		{testDIBSection64, uintptr(bits64), HANDLE(section64)},
+	}
+	for _, tt := range tests {
+		want := DIBSECTION{
+			DsBm: BITMAP{BmWidth: 32, BmHeight: 16, BmWidthBytes: 128, BmPlanes: 1, BmBitsPixel: 32, BmBits: tt.bits},
+			DsBmih: BITMAPINFOHEADER{
+				BiSize: 40, BiWidth: 32, BiHeight: -16, BiPlanes: 1, BiBitCount: 32,
+				BiCompression: BI_BITFIELDS, BiSizeImage: 2048,
+			},
+			DsBitfields: [3]DWORD{0xFF0000, 0xFF00, 0xFF},
+			DshSection:  tt.section,
+			DsOffset:    64,
+		}
+		ds, err := DecodeDIBSECTION(mustHex(t, tt.data))
+		if err != nil || ds != want {
+			t.Errorf("DecodeDIBSECTION(%s) =\n%+v, %v, want\n%+v", tt.data, ds, err, want)
+		}
+	}
+
+	for _, n := range []int{0, bitmapSize64, dibSectionSize32 + 4, dibSectionSize64 - 4} {
+		if _, err := DecodeDIBSECTION(make([]byte, n)); err != ErrGDIObjectInvalid {
+			t.Errorf("DecodeDIBSECTION of %d bytes: err = %v, want ErrGDIObjectInvalid", n, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// DROPFILES is the header of a CF_HDROP block. PFiles is the offset of
+// the file list from the start of the header.
+type DROPFILES struct {
+	PFiles DWORD
+	Pt     POINT
+	FNC    BOOL
+	FWide  BOOL
+}
+
+const dropFilesSize = 20
+
+var ErrDropFilesInvalid = errors.New("winapi: invalid DROPFILES block")
+
+// NewGlobal allocates moveable memory holding a copy of b, as required
+// for clipboard and OLE data. Once ownership is passed on, e.g. by a
+// successful SetClipboardData, the caller must not free it. A zero size
+// block cannot be locked, so for an empty b a single zero byte is
+// allocated.
+func NewGlobal(b []byte) (HGLOBAL, error) {
+	h := GlobalAlloc(GMEM_MOVEABLE, uintptr(max(len(b), 1)))
+	if h == 0 {
+		return 0, lastError
+	}
+
+	err := h.WithLock(func(mem []byte) error {
+		copy(mem, b)
+		return nil
+	})
+	if err != nil {
+		h.Free()
+		return 0, err
+	}
+
+	return h, nil
+}
+
+// NewGlobalText allocates s as NUL terminated UTF-16 for CF_UNICODETEXT.
+func NewGlobalText(s string) (HGLOBAL, error) {
+	return NewGlobal(utf16Bytes(s, 1))
+}
+
+// NewGlobalDIB allocates a packed DIB for CF_DIB: the header, then the
+// color table or, for BI_BITFIELDS, the three masks, then the bits.
+func NewGlobalDIB(hdr *BITMAPINFOHEADER, colors []RGBQUAD, bits []byte) (HGLOBAL, error) {
+	return NewGlobal(PackDIB(hdr, colors, bits))
+}
+
+// NewGlobalDropFiles allocates a CF_HDROP block listing files.
+func NewGlobalDropFiles(files []string) (HGLOBAL, error) {
+	return NewGlobal(EncodeDropFiles(files, POINT{}, false))
+}
+
+// Size returns the size of the memory block, which may be larger than
+// requested.
+func (h HGLOBAL) Size() int {
+	return int(GlobalSize(h))
+}
+
+// Lock locks the block and returns its contents. The slice is only
+// valid until the matching Unlock.
+func (h HGLOBAL) Lock() ([]byte, error) {
+	addr := GlobalLock(h)
+	if addr == 0 {
+		return nil, lastError
+	}
+
+	return bytesAt(addr, int(GlobalSize(h))), nil
+}
+
+func (h HGLOBAL) Unlock() {
+	GlobalUnlock(h)
+}
+
+// WithLock calls fn with the contents of the block, which stays locked
+// until fn returns. fn must not retain the slice.
+func (h HGLOBAL) WithLock(fn func(b []byte) error) error {
+	b, err := h.Lock()
+	if err != nil {
+		return err
+	}
+	defer h.Unlock()
+
+	return fn(b)
+}
+
+// Free frees the block. It must not be locked.
+func (h HGLOBAL) Free() error {
+	if GlobalFree(h) != 0 {
+		return lastError
+	}
+
+	return nil
+}
+
+// Text returns the contents of a CF_UNICODETEXT block up to the first
+// NUL.
+func (h HGLOBAL) Text() (string, error) {
+	var s string
+	err := h.WithLock(func(b []byte) error {
+		s = utf16BytesToString(b)
+		return nil
+	})
+
+	return s, err
+}
+
+// DropFiles returns the file names in a CF_HDROP block.
+func (h HGLOBAL) DropFiles() ([]string, error) {
+	var files []string
+	err := h.WithLock(func(b []byte) (err error) {
+		files, err = DecodeDropFiles(b)
+		return err
+	})
+
+	return files, err
+}
+
+// PackDIB lays out a packed DIB as used by CF_DIB. hdr.BiSize is set if
+// it is zero.
+func PackDIB(hdr *BITMAPINFOHEADER, colors []RGBQUAD, bits []byte) []byte {
+	h := *hdr
+	if h.BiSize == 0 {
+		h.BiSize = DWORD(unsafe.Sizeof(h))
+	}
+
+	b := make([]byte, 0, int(h.BiSize)+len(colors)*4+len(bits))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiSize))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiWidth))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiHeight))
+	b = binary.LittleEndian.AppendUint16(b, uint16(h.BiPlanes))
+	b = binary.LittleEndian.AppendUint16(b, uint16(h.BiBitCount))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiCompression))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiSizeImage))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiXPelsPerMeter))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiYPelsPerMeter))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiClrUsed))
+	b = binary.LittleEndian.AppendUint32(b, uint32(h.BiClrImportant))
+	for _, c := range colors {
+		b = append(b, c.RgbBlue, c.RgbGreen, c.RgbRed, c.RgbReserved)
+	}
+
+	return append(b, bits...)
+}
+
+// EncodeDropFiles lays out a CF_HDROP block with a wide, double NUL
+// terminated file list. pt is the drop point, in client coordinates
+// unless nonClient is set.
+func EncodeDropFiles(files []string, pt POINT, nonClient bool) []byte {
+	b := make([]byte, 0, dropFilesSize+2)
+	b = binary.LittleEndian.AppendUint32(b, dropFilesSize)
+	b = binary.LittleEndian.AppendUint32(b, uint32(pt.X))
+	b = binary.LittleEndian.AppendUint32(b, uint32(pt.Y))
+	b = binary.LittleEndian.AppendUint32(b, uint32(BoolToPtr(nonClient)))
+	b = binary.LittleEndian.AppendUint32(b, 1)
+	for _, f := range files {
+		b = append(b, utf16Bytes(f, 1)...)
+	}
+
+	return append(b, 0, 0)
+}
+
+// DecodeDropFiles returns the file names in a CF_HDROP block. ANSI
+// lists are returned byte for byte, without code page conversion.
+func DecodeDropFiles(b []byte) ([]string, error) {
+	if len(b) < dropFilesSize {
+		return nil, ErrDropFilesInvalid
+	}
+
+	off := binary.LittleEndian.Uint32(b)
+	if off < dropFilesSize || uint64(off) > uint64(len(b)) {
+		return nil, ErrDropFilesInvalid
+	}
+
+	var files []string
+	list := b[off:]
+	if binary.LittleEndian.Uint32(b[16:]) != 0 {
+		for len(list) >= 2 && (list[0] != 0 || list[1] != 0) {
+			n := 0
+			for n+1 < len(list) && (list[n] != 0 || list[n+1] != 0) {
+				n += 2
+			}
+			files = append(files, utf16BytesToString(list[:n]))
+			if n+2 > len(list) {
+				break
+			}
+			list = list[n+2:]
+		}
+
+		return files, nil
+	}
+
+	for len(list) > 0 && list[0] != 0 {
+		n := 0
+		for n < len(list) && list[n] != 0 {
+			n++
+		}
+		files = append(files, string(list[:n]))
+		if n == len(list) {
+			break
+		}
+		list = list[n+1:]
+	}
+
+	return files, nil
+}
+
+// utf16Bytes encodes s as little-endian UTF-16 followed by nul zero
+// code units.
+func utf16Bytes(s string, nul int) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 0, 2*(len(u)+nul))
+	for _, c := range u {
+		b = binary.LittleEndian.AppendUint16(b, c)
+	}
+
+	return append(b, make([]byte, 2*nul)...)
+}
+
+// utf16BytesToString decodes little-endian UTF-16 up to the first NUL
+// or the end of b.
+func utf16BytesToString(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c == 0 {
+			break
+		}
+		u = append(u, c)
+	}
+
+	return string(utf16.Decode(u))
+}
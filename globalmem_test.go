@@ -0,0 +1,86 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dropFilesHeader returns a DROPFILES header with the file list at off.
+func dropFilesHeader(off byte, wide bool) []byte {
+	b := []byte{
+		off, 0, 0, 0,
+		10, 0, 0, 0, 20, 0, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 0,
+	}
+	if wide {
+		b[16] = 1
+	}
+
+	return b
+}
+
+func TestDecodeDropFiles(t *testing.T) {
+	wide := append(dropFilesHeader(20, true),
+		'C', 0, ':', 0, '\\', 0, 'a', 0, 0, 0,
+		0xE4, 0, 0x3D, 0xD8, 0x00, 0xDE, 0, 0,
+		0, 0)
+	ansi := append(dropFilesHeader(20, false),
+		'C', ':', '\\', 'a', 0,
+		'b', 0xE4, 0,
+		0)
+	// A list placed after padding rather than right after the header.
+	padded := append(dropFilesHeader(24, false), 0xFF, 0xFF, 0xFF, 0xFF, 'x', 0, 0)
+
+	tests := []struct {
+		name string
+		b    []byte
+		want []string
+	}{
+		{"wide", wide, []string{`C:\a`, "\u00e4\U0001F600"}},
+		{"ANSI", ansi, []string{`C:\a`, "b\xe4"}},
+		{"padded", padded, []string{"x"}},
+		{"wide empty", append(dropFilesHeader(20, true), 0, 0), nil},
+		{"ANSI empty", append(dropFilesHeader(20, false), 0), nil},
+		{"no list", dropFilesHeader(20, true), nil},
+		{"wide unterminated", append(dropFilesHeader(20, true), 'a', 0, 0, 0, 'b', 0), []string{"a", "b"}},
+		{"wide odd", append(dropFilesHeader(20, true), 'a', 0, 'b'), []string{"a"}},
+		{"ANSI unterminated", append(dropFilesHeader(20, false), 'a', 0, 'b'), []string{"a", "b"}},
+		{"encoded", EncodeDropFiles([]string{`C:\x`, `D:\y z`}, POINT{3, 4}, true), []string{`C:\x`, `D:\y z`}},
+	}
+	for _, tt := range tests {
+		got, err := DecodeDropFiles(tt.b)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	invalid := [][]byte{
+		nil,
+		dropFilesHeader(20, true)[:19],
+		dropFilesHeader(19, true),
+		dropFilesHeader(21, true),
+		dropFilesHeader(0, false),
+	}
+	for i, b := range invalid {
+		if _, err := DecodeDropFiles(b); err != ErrDropFilesInvalid {
+			t.Errorf("invalid block %d: %v, want ErrDropFilesInvalid", i, err)
+		}
+	}
+}
+
+func TestEncodeDropFiles(t *testing.T) {
+	got := EncodeDropFiles([]string{"a", "bc"}, POINT{10, 20}, false)
+	want := append(dropFilesHeader(20, true), 'a', 0, 0, 0, 'b', 0, 'c', 0, 0, 0, 0, 0)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeDropFiles = % x, want % x", got, want)
+	}
+}
@@ -5,20 +5,86 @@
 package winapi
 
 import (
+	"errors"
 	"syscall"
 	"unsafe"
 )
 
 var (
-	modKernel32         = syscall.NewLazyDLL("Kernel32.dll")
-	procGetLastError    = modKernel32.NewProc("GetLastError")
-	procGetLocaleInfo   = modKernel32.NewProc("GetLocaleInfoW")
-	procGetModuleHandle = modKernel32.NewProc("GetModuleHandleW")
-	procSetSystemPowerState = modKernel32.NewProc("SetSystemPowerState")
+	modKernel32                       = newLazyDLL("Kernel32.dll")
+	procGetLastError                  = modKernel32.NewProc("GetLastError")
+	procGetLocaleInfo                 = modKernel32.NewProc("GetLocaleInfoW")
+	procGetModuleHandle               = modKernel32.NewProc("GetModuleHandleW")
+	procSetSystemPowerState           = modKernel32.NewProc("SetSystemPowerState")
+	procCloseHandle                   = modKernel32.NewProc("CloseHandle")
+	procCreateEventEx                 = modKernel32.NewProc("CreateEventExW")
+	procOpenEvent                     = modKernel32.NewProc("OpenEventW")
+	procSetEvent                      = modKernel32.NewProc("SetEvent")
+	procResetEvent                    = modKernel32.NewProc("ResetEvent")
+	procCreateMutexEx                 = modKernel32.NewProc("CreateMutexExW")
+	procOpenMutex                     = modKernel32.NewProc("OpenMutexW")
+	procReleaseMutex                  = modKernel32.NewProc("ReleaseMutex")
+	procCreateSemaphoreEx             = modKernel32.NewProc("CreateSemaphoreExW")
+	procOpenSemaphore                 = modKernel32.NewProc("OpenSemaphoreW")
+	procReleaseSemaphore              = modKernel32.NewProc("ReleaseSemaphore")
+	procCreateWaitableTimerEx         = modKernel32.NewProc("CreateWaitableTimerExW")
+	procOpenWaitableTimer             = modKernel32.NewProc("OpenWaitableTimerW")
+	procSetWaitableTimer              = modKernel32.NewProc("SetWaitableTimer")
+	procCancelWaitableTimer           = modKernel32.NewProc("CancelWaitableTimer")
+	procWaitForSingleObject           = modKernel32.NewProc("WaitForSingleObject")
+	procWaitForMultipleObjects        = modKernel32.NewProc("WaitForMultipleObjects")
+	procCreateFileMapping             = modKernel32.NewProc("CreateFileMappingW")
+	procOpenFileMapping               = modKernel32.NewProc("OpenFileMappingW")
+	procMapViewOfFile                 = modKernel32.NewProc("MapViewOfFile")
+	procUnmapViewOfFile               = modKernel32.NewProc("UnmapViewOfFile")
+	procFlushViewOfFile               = modKernel32.NewProc("FlushViewOfFile")
+	procGetSystemTimeAsFileTime       = modKernel32.NewProc("GetSystemTimeAsFileTime")
+	procGetSystemTime                 = modKernel32.NewProc("GetSystemTime")
+	procGetLocalTime                  = modKernel32.NewProc("GetLocalTime")
+	procFileTimeToSystemTime          = modKernel32.NewProc("FileTimeToSystemTime")
+	procSystemTimeToFileTime          = modKernel32.NewProc("SystemTimeToFileTime")
+	procGetTickCount                  = modKernel32.NewProc("GetTickCount")
+	procGetTickCount64                = modKernel32.NewProc("GetTickCount64")
+	procQueryPerformanceCounter       = modKernel32.NewProc("QueryPerformanceCounter")
+	procQueryPerformanceFrequency     = modKernel32.NewProc("QueryPerformanceFrequency")
+	procGetTimeZoneInformation        = modKernel32.NewProc("GetTimeZoneInformation")
+	procGetDynamicTimeZoneInformation = modKernel32.NewProc("GetDynamicTimeZoneInformation")
+	procGetCurrentProcess             = modKernel32.NewProc("GetCurrentProcess")
+	procGetNativeSystemInfo           = modKernel32.NewProc("GetNativeSystemInfo")
+	procIsWow64Process                = modKernel32.NewProc("IsWow64Process")
+	procIsWow64Process2               = modKernel32.NewProc("IsWow64Process2")
+	procGetProductInfo                = modKernel32.NewProc("GetProductInfo")
+	procCreateToolhelp32Snapshot      = modKernel32.NewProc("CreateToolhelp32Snapshot")
+	procProcess32First                = modKernel32.NewProc("Process32FirstW")
+	procProcess32Next                 = modKernel32.NewProc("Process32NextW")
+	procThread32First                 = modKernel32.NewProc("Thread32First")
+	procThread32Next                  = modKernel32.NewProc("Thread32Next")
+	procModule32First                 = modKernel32.NewProc("Module32FirstW")
+	procModule32Next                  = modKernel32.NewProc("Module32NextW")
+	procAllocConsole                  = modKernel32.NewProc("AllocConsole")
+	procAttachConsole                 = modKernel32.NewProc("AttachConsole")
+	procFreeConsole                   = modKernel32.NewProc("FreeConsole")
+	procGetConsoleWindow              = modKernel32.NewProc("GetConsoleWindow")
+	procGetStdHandle                  = modKernel32.NewProc("GetStdHandle")
+	procSetStdHandle                  = modKernel32.NewProc("SetStdHandle")
+	procGetConsoleMode                = modKernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode                = modKernel32.NewProc("SetConsoleMode")
+	procGetConsoleScreenBufferInfo    = modKernel32.NewProc("GetConsoleScreenBufferInfo")
+	procSetConsoleTextAttribute       = modKernel32.NewProc("SetConsoleTextAttribute")
+	procSetConsoleCtrlHandler         = modKernel32.NewProc("SetConsoleCtrlHandler")
+	procGlobalAlloc                   = modKernel32.NewProc("GlobalAlloc")
+	procGlobalLock                    = modKernel32.NewProc("GlobalLock")
+	procGlobalUnlock                  = modKernel32.NewProc("GlobalUnlock")
+	procGlobalSize                    = modKernel32.NewProc("GlobalSize")
+	procGlobalFree                    = modKernel32.NewProc("GlobalFree")
 )
 
 var lastError error
 
+// ErrNotSupported is the error of every call into a Windows DLL on
+// other systems.
+var ErrNotSupported = errors.New("winapi: not supported on this system")
+
 func GetLastError() uint {
 	var ret uintptr
 	ret, _, lastError = procGetLastError.Call()
@@ -62,6 +128,715 @@ func SetSystemPowerState(suspend, force bool) bool {
 	return PtrToBool(ret)
 }
 
+func CloseHandle(h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procCloseHandle.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+type SECURITY_ATTRIBUTES struct {
+	Length             DWORD
+	SecurityDescriptor uintptr
+	InheritHandle      BOOL
+}
+
+func CreateEventEx(sa *SECURITY_ATTRIBUTES, name string, flags, access DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateEventEx.Call(uintptr(unsafe.Pointer(sa)),
+		StringToUintptr(name), uintptr(flags), uintptr(access))
+
+	return HANDLE(ret)
+}
+
+func OpenEvent(access DWORD, inherit bool, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procOpenEvent.Call(uintptr(access), BoolToPtr(inherit),
+		StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+func SetEvent(h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procSetEvent.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func ResetEvent(h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procResetEvent.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func CreateMutexEx(sa *SECURITY_ATTRIBUTES, name string, flags, access DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateMutexEx.Call(uintptr(unsafe.Pointer(sa)),
+		StringToUintptr(name), uintptr(flags), uintptr(access))
+
+	return HANDLE(ret)
+}
+
+func OpenMutex(access DWORD, inherit bool, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procOpenMutex.Call(uintptr(access), BoolToPtr(inherit),
+		StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+func ReleaseMutex(h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procReleaseMutex.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func CreateSemaphoreEx(sa *SECURITY_ATTRIBUTES, initial, max int32, name string,
+	flags, access DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateSemaphoreEx.Call(uintptr(unsafe.Pointer(sa)),
+		uintptr(initial), uintptr(max), StringToUintptr(name), uintptr(flags),
+		uintptr(access))
+
+	return HANDLE(ret)
+}
+
+func OpenSemaphore(access DWORD, inherit bool, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procOpenSemaphore.Call(uintptr(access), BoolToPtr(inherit),
+		StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+func ReleaseSemaphore(h HANDLE, count int32, prev *int32) bool {
+	var ret uintptr
+	ret, _, lastError = procReleaseSemaphore.Call(uintptr(h), uintptr(count),
+		uintptr(unsafe.Pointer(prev)))
+
+	return PtrToBool(ret)
+}
+
+func CreateWaitableTimerEx(sa *SECURITY_ATTRIBUTES, name string, flags, access DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateWaitableTimerEx.Call(uintptr(unsafe.Pointer(sa)),
+		StringToUintptr(name), uintptr(flags), uintptr(access))
+
+	return HANDLE(ret)
+}
+
+func OpenWaitableTimer(access DWORD, inherit bool, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procOpenWaitableTimer.Call(uintptr(access), BoolToPtr(inherit),
+		StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+// SetWaitableTimer activates the timer. dueTime is in 100 nanosecond
+// intervals, negative values are relative to the current time and
+// period is in milliseconds. Completion routines are not supported.
+func SetWaitableTimer(h HANDLE, dueTime *int64, period int32, resume bool) bool {
+	var ret uintptr
+	ret, _, lastError = procSetWaitableTimer.Call(uintptr(h),
+		uintptr(unsafe.Pointer(dueTime)), uintptr(period), 0, 0, BoolToPtr(resume))
+
+	return PtrToBool(ret)
+}
+
+func CancelWaitableTimer(h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procCancelWaitableTimer.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func WaitForSingleObject(h HANDLE, milliseconds DWORD) DWORD {
+	var ret uintptr
+	ret, _, lastError = procWaitForSingleObject.Call(uintptr(h), uintptr(milliseconds))
+
+	return DWORD(ret)
+}
+
+func WaitForMultipleObjects(handles []HANDLE, waitAll bool, milliseconds DWORD) DWORD {
+	if len(handles) == 0 {
+		lastError = ERROR_INVALID_PARAMETER
+		return WAIT_FAILED
+	}
+
+	var ret uintptr
+	ret, _, lastError = procWaitForMultipleObjects.Call(uintptr(len(handles)),
+		uintptr(unsafe.Pointer(&handles[0])), BoolToPtr(waitAll), uintptr(milliseconds))
+
+	return DWORD(ret)
+}
+
+// Wait function constants
+const (
+	INFINITE             = 0xFFFFFFFF
+	MAXIMUM_WAIT_OBJECTS = 64
+	WAIT_OBJECT_0        = 0x00000000
+	WAIT_ABANDONED_0     = 0x00000080
+	WAIT_TIMEOUT         = 0x00000102
+	WAIT_FAILED          = 0xFFFFFFFF
+)
+
+// Synchronization object access rights and creation flags
+const (
+	SYNCHRONIZE                        = 0x00100000
+	EVENT_MODIFY_STATE                 = 0x0002
+	EVENT_ALL_ACCESS                   = 0x1F0003
+	MUTEX_MODIFY_STATE                 = 0x0001
+	MUTEX_ALL_ACCESS                   = 0x1F0001
+	SEMAPHORE_MODIFY_STATE             = 0x0002
+	SEMAPHORE_ALL_ACCESS               = 0x1F0003
+	TIMER_QUERY_STATE                  = 0x0001
+	TIMER_MODIFY_STATE                 = 0x0002
+	TIMER_ALL_ACCESS                   = 0x1F0003
+	CREATE_EVENT_MANUAL_RESET          = 0x00000001
+	CREATE_EVENT_INITIAL_SET           = 0x00000002
+	CREATE_MUTEX_INITIAL_OWNER         = 0x00000001
+	CREATE_WAITABLE_TIMER_MANUAL_RESET = 0x00000001
+)
+
+func CreateFileMapping(file HANDLE, sa *SECURITY_ATTRIBUTES, protect DWORD,
+	maxSize uint64, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateFileMapping.Call(uintptr(file),
+		uintptr(unsafe.Pointer(sa)), uintptr(protect), uintptr(maxSize>>32),
+		uintptr(maxSize&0xffffffff), StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+func OpenFileMapping(access DWORD, inherit bool, name string) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procOpenFileMapping.Call(uintptr(access), BoolToPtr(inherit),
+		StringToUintptr(name))
+
+	return HANDLE(ret)
+}
+
+func MapViewOfFile(h HANDLE, access DWORD, offset uint64, size uintptr) uintptr {
+	var ret uintptr
+	ret, _, lastError = procMapViewOfFile.Call(uintptr(h), uintptr(access),
+		uintptr(offset>>32), uintptr(offset&0xffffffff), size)
+
+	return ret
+}
+
+func UnmapViewOfFile(addr uintptr) bool {
+	var ret uintptr
+	ret, _, lastError = procUnmapViewOfFile.Call(addr)
+
+	return PtrToBool(ret)
+}
+
+func FlushViewOfFile(addr uintptr, size uintptr) bool {
+	var ret uintptr
+	ret, _, lastError = procFlushViewOfFile.Call(addr, size)
+
+	return PtrToBool(ret)
+}
+
+func GetSystemTimeAsFileTime(ft *FILETIME) {
+	procGetSystemTimeAsFileTime.Call(uintptr(unsafe.Pointer(ft)))
+}
+
+func GetSystemTime(st *SYSTEMTIME) {
+	procGetSystemTime.Call(uintptr(unsafe.Pointer(st)))
+}
+
+func GetLocalTime(st *SYSTEMTIME) {
+	procGetLocalTime.Call(uintptr(unsafe.Pointer(st)))
+}
+
+func FileTimeToSystemTime(ft *FILETIME, st *SYSTEMTIME) bool {
+	var ret uintptr
+	ret, _, lastError = procFileTimeToSystemTime.Call(uintptr(unsafe.Pointer(ft)),
+		uintptr(unsafe.Pointer(st)))
+
+	return PtrToBool(ret)
+}
+
+func SystemTimeToFileTime(st *SYSTEMTIME, ft *FILETIME) bool {
+	var ret uintptr
+	ret, _, lastError = procSystemTimeToFileTime.Call(uintptr(unsafe.Pointer(st)),
+		uintptr(unsafe.Pointer(ft)))
+
+	return PtrToBool(ret)
+}
+
+func GetTickCount() DWORD {
+	ret, _, _ := procGetTickCount.Call()
+
+	return DWORD(ret)
+}
+
+func GetTickCount64() uint64 {
+	lo, hi, _ := procGetTickCount64.Call()
+	if is64Bit {
+		return uint64(lo)
+	}
+
+	// The result comes back in EDX:EAX on 386.
+	return uint64(hi)<<32 | uint64(lo)
+}
+
+func QueryPerformanceCounter(count *int64) bool {
+	var ret uintptr
+	ret, _, lastError = procQueryPerformanceCounter.Call(uintptr(unsafe.Pointer(count)))
+
+	return PtrToBool(ret)
+}
+
+func QueryPerformanceFrequency(freq *int64) bool {
+	var ret uintptr
+	ret, _, lastError = procQueryPerformanceFrequency.Call(uintptr(unsafe.Pointer(freq)))
+
+	return PtrToBool(ret)
+}
+
+func GetTimeZoneInformation(tzi *TIME_ZONE_INFORMATION) DWORD {
+	var ret uintptr
+	ret, _, lastError = procGetTimeZoneInformation.Call(uintptr(unsafe.Pointer(tzi)))
+
+	return DWORD(ret)
+}
+
+func GetDynamicTimeZoneInformation(tzi *DYNAMIC_TIME_ZONE_INFORMATION) DWORD {
+	var ret uintptr
+	ret, _, lastError = procGetDynamicTimeZoneInformation.Call(uintptr(unsafe.Pointer(tzi)))
+
+	return DWORD(ret)
+}
+
+func GetCurrentProcess() HANDLE {
+	ret, _, _ := procGetCurrentProcess.Call()
+
+	return HANDLE(ret)
+}
+
+type SYSTEM_INFO struct {
+	ProcessorArchitecture     uint16
+	Reserved                  uint16
+	PageSize                  DWORD
+	MinimumApplicationAddress uintptr
+	MaximumApplicationAddress uintptr
+	ActiveProcessorMask       uintptr
+	NumberOfProcessors        DWORD
+	ProcessorType             DWORD
+	AllocationGranularity     DWORD
+	ProcessorLevel            uint16
+	ProcessorRevision         uint16
+}
+
+func GetNativeSystemInfo(si *SYSTEM_INFO) {
+	procGetNativeSystemInfo.Call(uintptr(unsafe.Pointer(si)))
+}
+
+func IsWow64Process(h HANDLE, wow64 *BOOL) bool {
+	var ret uintptr
+	ret, _, lastError = procIsWow64Process.Call(uintptr(h), uintptr(unsafe.Pointer(wow64)))
+
+	return PtrToBool(ret)
+}
+
+// IsWow64Process2 requires Windows 10 1511 or later.
+func IsWow64Process2(h HANDLE, processMachine, nativeMachine *uint16) bool {
+	var ret uintptr
+	ret, _, lastError = procIsWow64Process2.Call(uintptr(h),
+		uintptr(unsafe.Pointer(processMachine)), uintptr(unsafe.Pointer(nativeMachine)))
+
+	return PtrToBool(ret)
+}
+
+func GetProductInfo(major, minor, spMajor, spMinor DWORD, productType *DWORD) bool {
+	var ret uintptr
+	ret, _, lastError = procGetProductInfo.Call(uintptr(major), uintptr(minor),
+		uintptr(spMajor), uintptr(spMinor), uintptr(unsafe.Pointer(productType)))
+
+	return PtrToBool(ret)
+}
+
+// Processor architecture constants
+const (
+	PROCESSOR_ARCHITECTURE_INTEL   = 0
+	PROCESSOR_ARCHITECTURE_ARM     = 5
+	PROCESSOR_ARCHITECTURE_IA64    = 6
+	PROCESSOR_ARCHITECTURE_AMD64   = 9
+	PROCESSOR_ARCHITECTURE_ARM64   = 12
+	PROCESSOR_ARCHITECTURE_UNKNOWN = 0xFFFF
+)
+
+// Image file machine constants
+const (
+	IMAGE_FILE_MACHINE_UNKNOWN = 0
+	IMAGE_FILE_MACHINE_I386    = 0x014C
+	IMAGE_FILE_MACHINE_ARMNT   = 0x01C4
+	IMAGE_FILE_MACHINE_IA64    = 0x0200
+	IMAGE_FILE_MACHINE_AMD64   = 0x8664
+	IMAGE_FILE_MACHINE_ARM64   = 0xAA64
+)
+
+// GetProductInfo product types
+const (
+	PRODUCT_UNDEFINED                    = 0x00000000
+	PRODUCT_ULTIMATE                     = 0x00000001
+	PRODUCT_HOME_BASIC                   = 0x00000002
+	PRODUCT_HOME_PREMIUM                 = 0x00000003
+	PRODUCT_ENTERPRISE                   = 0x00000004
+	PRODUCT_BUSINESS                     = 0x00000006
+	PRODUCT_STANDARD_SERVER              = 0x00000007
+	PRODUCT_DATACENTER_SERVER            = 0x00000008
+	PRODUCT_STARTER                      = 0x0000000B
+	PRODUCT_ENTERPRISE_SERVER            = 0x0000000A
+	PRODUCT_WEB_SERVER                   = 0x00000011
+	PRODUCT_PROFESSIONAL                 = 0x00000030
+	PRODUCT_CORE_N                       = 0x00000062
+	PRODUCT_CORE_COUNTRYSPECIFIC         = 0x00000063
+	PRODUCT_CORE_SINGLELANGUAGE          = 0x00000064
+	PRODUCT_CORE                         = 0x00000065
+	PRODUCT_EDUCATION                    = 0x00000079
+	PRODUCT_ENTERPRISE_S                 = 0x0000007D
+	PRODUCT_IOTENTERPRISE                = 0x000000BC
+	PRODUCT_PRO_WORKSTATION              = 0x000000A1
+	PRODUCT_SERVERRDSH                   = 0x000000AF
+	PRODUCT_DATACENTER_SERVER_CORE       = 0x0000000C
+	PRODUCT_STANDARD_SERVER_CORE         = 0x0000000D
+	PRODUCT_DATACENTER_EVALUATION_SERVER = 0x00000050
+	PRODUCT_STANDARD_EVALUATION_SERVER   = 0x0000004F
+)
+
+func CreateToolhelp32Snapshot(flags, pid DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procCreateToolhelp32Snapshot.Call(uintptr(flags), uintptr(pid))
+
+	return HANDLE(ret)
+}
+
+const MAX_PATH = 260
+
+const MAX_MODULE_NAME32 = 255
+
+type PROCESSENTRY32 struct {
+	Size            DWORD
+	Usage           DWORD
+	ProcessID       DWORD
+	DefaultHeapID   uintptr
+	ModuleID        DWORD
+	Threads         DWORD
+	ParentProcessID DWORD
+	PriClassBase    int32
+	Flags           DWORD
+	ExeFile         [MAX_PATH]uint16
+}
+
+type THREADENTRY32 struct {
+	Size           DWORD
+	Usage          DWORD
+	ThreadID       DWORD
+	OwnerProcessID DWORD
+	BasePri        int32
+	DeltaPri       int32
+	Flags          DWORD
+}
+
+type MODULEENTRY32 struct {
+	Size         DWORD
+	ModuleID     DWORD
+	ProcessID    DWORD
+	GlblcntUsage DWORD
+	ProccntUsage DWORD
+	ModBaseAddr  uintptr
+	ModBaseSize  DWORD
+	Module       HMODULE
+	ModuleName   [MAX_MODULE_NAME32 + 1]uint16
+	ExePath      [MAX_PATH]uint16
+}
+
+func Process32First(snapshot HANDLE, pe *PROCESSENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procProcess32First.Call(uintptr(snapshot), uintptr(unsafe.Pointer(pe)))
+
+	return PtrToBool(ret)
+}
+
+func Process32Next(snapshot HANDLE, pe *PROCESSENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procProcess32Next.Call(uintptr(snapshot), uintptr(unsafe.Pointer(pe)))
+
+	return PtrToBool(ret)
+}
+
+func Thread32First(snapshot HANDLE, te *THREADENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procThread32First.Call(uintptr(snapshot), uintptr(unsafe.Pointer(te)))
+
+	return PtrToBool(ret)
+}
+
+func Thread32Next(snapshot HANDLE, te *THREADENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procThread32Next.Call(uintptr(snapshot), uintptr(unsafe.Pointer(te)))
+
+	return PtrToBool(ret)
+}
+
+func Module32First(snapshot HANDLE, me *MODULEENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procModule32First.Call(uintptr(snapshot), uintptr(unsafe.Pointer(me)))
+
+	return PtrToBool(ret)
+}
+
+func Module32Next(snapshot HANDLE, me *MODULEENTRY32) bool {
+	var ret uintptr
+	ret, _, lastError = procModule32Next.Call(uintptr(snapshot), uintptr(unsafe.Pointer(me)))
+
+	return PtrToBool(ret)
+}
+
+// CreateToolhelp32Snapshot flags
+const (
+	TH32CS_SNAPHEAPLIST = 0x00000001
+	TH32CS_SNAPPROCESS  = 0x00000002
+	TH32CS_SNAPTHREAD   = 0x00000004
+	TH32CS_SNAPMODULE   = 0x00000008
+	TH32CS_SNAPMODULE32 = 0x00000010
+	TH32CS_SNAPALL      = 0x0000000F
+	TH32CS_INHERIT      = 0x80000000
+)
+
+// System error codes
+const (
+	ERROR_SUCCESS           syscall.Errno = 0
+	ERROR_NO_MORE_FILES     syscall.Errno = 18
+	ERROR_BAD_LENGTH        syscall.Errno = 24
+	ERROR_PARTIAL_COPY      syscall.Errno = 299
+	ERROR_ACCESS_DENIED     syscall.Errno = 5
+	ERROR_INVALID_HANDLE    syscall.Errno = 6
+	ERROR_INVALID_PARAMETER syscall.Errno = 87
+)
+
+func AllocConsole() bool {
+	var ret uintptr
+	ret, _, lastError = procAllocConsole.Call()
+
+	return PtrToBool(ret)
+}
+
+func AttachConsole(pid DWORD) bool {
+	var ret uintptr
+	ret, _, lastError = procAttachConsole.Call(uintptr(pid))
+
+	return PtrToBool(ret)
+}
+
+func FreeConsole() bool {
+	var ret uintptr
+	ret, _, lastError = procFreeConsole.Call()
+
+	return PtrToBool(ret)
+}
+
+func GetConsoleWindow() HWND {
+	ret, _, _ := procGetConsoleWindow.Call()
+
+	return HWND(ret)
+}
+
+func GetStdHandle(std DWORD) HANDLE {
+	var ret uintptr
+	ret, _, lastError = procGetStdHandle.Call(uintptr(std))
+
+	return HANDLE(ret)
+}
+
+func SetStdHandle(std DWORD, h HANDLE) bool {
+	var ret uintptr
+	ret, _, lastError = procSetStdHandle.Call(uintptr(std), uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func GetConsoleMode(h HANDLE, mode *DWORD) bool {
+	var ret uintptr
+	ret, _, lastError = procGetConsoleMode.Call(uintptr(h), uintptr(unsafe.Pointer(mode)))
+
+	return PtrToBool(ret)
+}
+
+func SetConsoleMode(h HANDLE, mode DWORD) bool {
+	var ret uintptr
+	ret, _, lastError = procSetConsoleMode.Call(uintptr(h), uintptr(mode))
+
+	return PtrToBool(ret)
+}
+
+type COORD struct {
+	X, Y int16
+}
+
+type SMALL_RECT struct {
+	Left, Top, Right, Bottom int16
+}
+
+type CONSOLE_SCREEN_BUFFER_INFO struct {
+	Size              COORD
+	CursorPosition    COORD
+	Attributes        WORD
+	Window            SMALL_RECT
+	MaximumWindowSize COORD
+}
+
+func GetConsoleScreenBufferInfo(h HANDLE, info *CONSOLE_SCREEN_BUFFER_INFO) bool {
+	var ret uintptr
+	ret, _, lastError = procGetConsoleScreenBufferInfo.Call(uintptr(h),
+		uintptr(unsafe.Pointer(info)))
+
+	return PtrToBool(ret)
+}
+
+func SetConsoleTextAttribute(h HANDLE, attr WORD) bool {
+	var ret uintptr
+	ret, _, lastError = procSetConsoleTextAttribute.Call(uintptr(h), uintptr(attr))
+
+	return PtrToBool(ret)
+}
+
+// SetConsoleCtrlHandler adds or removes a handler created with
+// syscall.NewCallback. See NotifyConsoleCtrl for a channel based
+// alternative.
+func SetConsoleCtrlHandler(handler uintptr, add bool) bool {
+	var ret uintptr
+	ret, _, lastError = procSetConsoleCtrlHandler.Call(handler, BoolToPtr(add))
+
+	return PtrToBool(ret)
+}
+
+// Standard device handles
+const (
+	STD_INPUT_HANDLE  DWORD = 0xFFFFFFF6 // (DWORD)-10
+	STD_OUTPUT_HANDLE DWORD = 0xFFFFFFF5 // (DWORD)-11
+	STD_ERROR_HANDLE  DWORD = 0xFFFFFFF4 // (DWORD)-12
+)
+
+const ATTACH_PARENT_PROCESS = ^DWORD(0)
+
+// Console input mode flags
+const (
+	ENABLE_PROCESSED_INPUT        = 0x0001
+	ENABLE_LINE_INPUT             = 0x0002
+	ENABLE_ECHO_INPUT             = 0x0004
+	ENABLE_WINDOW_INPUT           = 0x0008
+	ENABLE_MOUSE_INPUT            = 0x0010
+	ENABLE_INSERT_MODE            = 0x0020
+	ENABLE_QUICK_EDIT_MODE        = 0x0040
+	ENABLE_EXTENDED_FLAGS         = 0x0080
+	ENABLE_VIRTUAL_TERMINAL_INPUT = 0x0200
+)
+
+// Console output mode flags
+const (
+	ENABLE_PROCESSED_OUTPUT            = 0x0001
+	ENABLE_WRAP_AT_EOL_OUTPUT          = 0x0002
+	ENABLE_VIRTUAL_TERMINAL_PROCESSING = 0x0004
+	DISABLE_NEWLINE_AUTO_RETURN        = 0x0008
+	ENABLE_LVB_GRID_WORLDWIDE          = 0x0010
+)
+
+// Console character attributes
+const (
+	FOREGROUND_BLUE      = 0x0001
+	FOREGROUND_GREEN     = 0x0002
+	FOREGROUND_RED       = 0x0004
+	FOREGROUND_INTENSITY = 0x0008
+	BACKGROUND_BLUE      = 0x0010
+	BACKGROUND_GREEN     = 0x0020
+	BACKGROUND_RED       = 0x0040
+	BACKGROUND_INTENSITY = 0x0080
+)
+
+// Console control events
+const (
+	CTRL_C_EVENT        = 0
+	CTRL_BREAK_EVENT    = 1
+	CTRL_CLOSE_EVENT    = 2
+	CTRL_LOGOFF_EVENT   = 5
+	CTRL_SHUTDOWN_EVENT = 6
+)
+
+func GlobalAlloc(flags UINT, size uintptr) HGLOBAL {
+	var ret uintptr
+	ret, _, lastError = procGlobalAlloc.Call(uintptr(flags), size)
+
+	return HGLOBAL(ret)
+}
+
+func GlobalLock(h HGLOBAL) uintptr {
+	var ret uintptr
+	ret, _, lastError = procGlobalLock.Call(uintptr(h))
+
+	return ret
+}
+
+// GlobalUnlock returns false both on failure and when the lock count
+// drops to zero; LastError is 0 in the second case.
+func GlobalUnlock(h HGLOBAL) bool {
+	var ret uintptr
+	ret, _, lastError = procGlobalUnlock.Call(uintptr(h))
+
+	return PtrToBool(ret)
+}
+
+func GlobalSize(h HGLOBAL) uintptr {
+	var ret uintptr
+	ret, _, lastError = procGlobalSize.Call(uintptr(h))
+
+	return ret
+}
+
+// GlobalFree returns 0 on success and h on failure.
+func GlobalFree(h HGLOBAL) HGLOBAL {
+	var ret uintptr
+	ret, _, lastError = procGlobalFree.Call(uintptr(h))
+
+	return HGLOBAL(ret)
+}
+
+// GlobalAlloc flags
+const (
+	GMEM_FIXED    = 0x0000
+	GMEM_MOVEABLE = 0x0002
+	GMEM_ZEROINIT = 0x0040
+	GHND          = GMEM_MOVEABLE | GMEM_ZEROINIT
+	GPTR          = GMEM_FIXED | GMEM_ZEROINIT
+)
+
+const INVALID_HANDLE_VALUE = ^HANDLE(0)
+
+// Page protection constants
+const (
+	PAGE_NOACCESS          = 0x01
+	PAGE_READONLY          = 0x02
+	PAGE_READWRITE         = 0x04
+	PAGE_WRITECOPY         = 0x08
+	PAGE_EXECUTE_READ      = 0x20
+	PAGE_EXECUTE_READWRITE = 0x40
+)
+
+// File mapping access constants
+const (
+	FILE_MAP_COPY       = 0x0001
+	FILE_MAP_WRITE      = 0x0002
+	FILE_MAP_READ       = 0x0004
+	FILE_MAP_ALL_ACCESS = 0x000F001F
+)
+
 type (
 	LCID   uint32
 	LCTYPE uint32
@@ -0,0 +1,464 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var ErrFontSpecInvalid = errors.New("winapi: invalid font spec")
+
+// FontBuilder builds a LOGFONT from a device independent description:
+//
+//	lf := NewFontBuilder("Segoe UI").Points(10.5).Bold().Italic().LogFont(dpi)
+//
+// It starts out with FW_NORMAL, DEFAULT_CHARSET and no size, which lets
+// the font mapper pick a default height.
+type FontBuilder struct {
+	lf     LOGFONT
+	size   float64
+	pixels bool
+}
+
+func NewFontBuilder(face string) *FontBuilder {
+	b := &FontBuilder{}
+	b.lf.LfWeight = FW_NORMAL
+	b.lf.LfCharSet = DEFAULT_CHARSET
+	b.lf.SetFaceName(face)
+
+	return b
+}
+
+func (b *FontBuilder) Face(face string) *FontBuilder {
+	b.lf.SetFaceName(face)
+	return b
+}
+
+// Points sets the character height, excluding internal leading, in
+// points, which LogFont converts to pixels for the DPI of the device.
+func (b *FontBuilder) Points(pt float64) *FontBuilder {
+	b.size, b.pixels = pt, false
+	return b
+}
+
+// Pixels sets the character height, excluding internal leading, in
+// pixels regardless of the DPI.
+func (b *FontBuilder) Pixels(px int32) *FontBuilder {
+	b.size, b.pixels = float64(px), true
+	return b
+}
+
+// Weight sets the weight, one of the FW_ constants or any value from 0
+// to 1000.
+func (b *FontBuilder) Weight(w int32) *FontBuilder {
+	b.lf.LfWeight = w
+	return b
+}
+
+func (b *FontBuilder) Bold() *FontBuilder {
+	return b.Weight(FW_BOLD)
+}
+
+func (b *FontBuilder) Italic() *FontBuilder {
+	b.lf.LfItalic = 1
+	return b
+}
+
+func (b *FontBuilder) Underline() *FontBuilder {
+	b.lf.LfUnderline = 1
+	return b
+}
+
+func (b *FontBuilder) StrikeOut() *FontBuilder {
+	b.lf.LfStrikeOut = 1
+	return b
+}
+
+// CharSet sets the character set, one of the _CHARSET constants.
+func (b *FontBuilder) CharSet(cs byte) *FontBuilder {
+	b.lf.LfCharSet = cs
+	return b
+}
+
+// Quality sets the output quality, one of the _QUALITY constants.
+func (b *FontBuilder) Quality(q byte) *FontBuilder {
+	b.lf.LfQuality = q
+	return b
+}
+
+// Pitch sets DEFAULT_PITCH, FIXED_PITCH or VARIABLE_PITCH.
+func (b *FontBuilder) Pitch(p byte) *FontBuilder {
+	b.lf.LfPitchAndFamily = b.lf.LfPitchAndFamily&^0x0F | p&0x0F
+	return b
+}
+
+// Family sets the font family, one of the FF_ constants.
+func (b *FontBuilder) Family(f byte) *FontBuilder {
+	b.lf.LfPitchAndFamily = b.lf.LfPitchAndFamily&0x0F | f&0xF0
+	return b
+}
+
+// LogFont returns the LOGFONT for a device with dpi pixels per logical
+// inch vertically, the LOGPIXELSY of GetDeviceCaps. The height is
+// negative, so that it is matched against the character height.
+func (b *FontBuilder) LogFont(dpi int32) LOGFONT {
+	lf := b.lf
+	switch {
+	case b.pixels:
+		lf.LfHeight = -int32(b.size)
+	case b.size > 0:
+		lf.LfHeight = -gdiRound(b.size * float64(dpi) / 72)
+	}
+
+	return lf
+}
+
+// Create creates the font for hdc.
+func (b *FontBuilder) Create(hdc HDC) HFONT {
+	lf := b.LogFont(GetDeviceCaps(hdc, LOGPIXELSY))
+	return CreateFontIndirect(&lf)
+}
+
+// String returns the spec of b in the form ParseFont reads.
+func (b *FontBuilder) String() string {
+	lf := &b.lf
+
+	var words []string
+	switch name, ok := fontWeightNames[lf.LfWeight]; {
+	case ok:
+		words = append(words, name)
+	case lf.LfWeight != FW_NORMAL && lf.LfWeight != FW_DONTCARE:
+		words = append(words, strconv.Itoa(int(lf.LfWeight)))
+	}
+	if lf.LfItalic != 0 {
+		words = append(words, "italic")
+	}
+	if lf.LfUnderline != 0 {
+		words = append(words, "underline")
+	}
+	if lf.LfStrikeOut != 0 {
+		words = append(words, "strikeout")
+	}
+	if name, ok := fontQualityNames[lf.LfQuality]; ok {
+		words = append(words, name)
+	}
+	switch lf.LfPitchAndFamily & 0x03 {
+	case FIXED_PITCH:
+		words = append(words, "fixed")
+	case VARIABLE_PITCH:
+		words = append(words, "variable")
+	}
+	if lf.LfCharSet != DEFAULT_CHARSET {
+		name, ok := fontCharSetNames[lf.LfCharSet]
+		if !ok {
+			name = strconv.Itoa(int(lf.LfCharSet))
+		}
+		words = append(words, "charset="+name)
+	}
+
+	if b.size > 0 {
+		unit := "pt"
+		if b.pixels {
+			unit = "px"
+		}
+		words = append(words, strconv.FormatFloat(math.Round(b.size*100)/100, 'f', -1, 64)+unit)
+	}
+
+	var faces []string
+	if face := lf.FaceName(); face != "" {
+		q := "'"
+		if strings.Contains(face, q) {
+			q = `"`
+		}
+		faces = append(faces, q+face+q)
+	}
+	if name, ok := fontFamilyNames[lf.LfPitchAndFamily&0xF0]; ok {
+		faces = append(faces, name)
+	}
+	if len(faces) > 0 {
+		words = append(words, strings.Join(faces, ", "))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Format returns the spec of lf for a device with dpi pixels per logical
+// inch, as FontBuilder.String writes it. With a dpi of 0 the size is
+// given in pixels. A positive height, which GDI matches against the cell
+// height, is written as a character height. The width, escapement,
+// orientation and precisions are left out.
+func (lf *LOGFONT) Format(dpi int32) string {
+	b := &FontBuilder{lf: *lf}
+	h := lf.LfHeight
+	if h < 0 {
+		h = -h
+	}
+	if dpi > 0 {
+		b.Points(float64(h) * 72 / float64(dpi))
+	} else if h != 0 {
+		b.Pixels(h)
+	}
+
+	return b.String()
+}
+
+// ParseFont reads a font spec modelled on the CSS font shorthand, such as
+// "bold italic 10.5pt 'Segoe UI'": style keywords, an optional size and
+// then the face name, quoted or not, optionally followed by a comma and
+// a generic family.
+//
+// The keywords are a weight (thin, extralight, light, normal, medium,
+// semibold, bold, extrabold, heavy or a number from 1 to 1000), italic,
+// underline, strikeout, a quality (draft, proof, nonantialiased,
+// antialiased, cleartype), a pitch (fixed, variable) and a character set
+// written as charset= followed by the lower case name of a _CHARSET
+// constant without the suffix, or its value. Sizes are given in pt or
+// px. The generic families serif, sans-serif, monospace, cursive and
+// fantasy select FF_ROMAN, FF_SWISS, FF_MODERN, FF_SCRIPT and
+// FF_DECORATIVE.
+func ParseFont(spec string) (*FontBuilder, error) {
+	toks, err := fontTokens(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewFontBuilder("")
+	i := 0
+	for ; i < len(toks); i++ {
+		t := toks[i]
+		if t.quoted || t.text == "," {
+			break
+		}
+		w := strings.ToLower(t.text)
+		if !b.keyword(w) {
+			if strings.HasPrefix(w, "charset=") {
+				return nil, ErrFontSpecInvalid
+			}
+			if b.sizeToken(w) {
+				i++
+			}
+			break
+		}
+	}
+
+	var face, family bool
+	for i < len(toks) {
+		j := i
+		for j < len(toks) && toks[j].text != "," {
+			j++
+		}
+		group := toks[i:j]
+		i = j + 1
+		if j == len(toks)-1 {
+			// A trailing comma.
+			return nil, ErrFontSpecInvalid
+		}
+
+		switch {
+		case len(group) == 0:
+			return nil, ErrFontSpecInvalid
+		case len(group) == 1 && !group[0].quoted && fontFamilyValue(group[0].text) != 0:
+			if family {
+				return nil, ErrFontSpecInvalid
+			}
+			family = true
+			b.Family(fontFamilyValue(group[0].text))
+		default:
+			if face {
+				return nil, ErrFontSpecInvalid
+			}
+			face = true
+
+			var words []string
+			for _, t := range group {
+				if t.quoted && len(group) > 1 {
+					return nil, ErrFontSpecInvalid
+				}
+				words = append(words, t.text)
+			}
+			b.Face(strings.Join(words, " "))
+		}
+	}
+
+	return b, nil
+}
+
+// keyword applies a style keyword and reports whether w was one.
+func (b *FontBuilder) keyword(w string) bool {
+	for weight, name := range fontWeightNames {
+		if w == name {
+			b.Weight(weight)
+			return true
+		}
+	}
+	for q, name := range fontQualityNames {
+		if w == name {
+			b.Quality(q)
+			return true
+		}
+	}
+
+	switch w {
+	case "normal", "regular":
+		b.Weight(FW_NORMAL)
+	case "italic", "oblique":
+		b.Italic()
+	case "underline":
+		b.Underline()
+	case "strikeout", "line-through":
+		b.StrikeOut()
+	case "fixed":
+		b.Pitch(FIXED_PITCH)
+	case "variable":
+		b.Pitch(VARIABLE_PITCH)
+	default:
+		if n, err := strconv.Atoi(w); err == nil && n > 0 && n <= 1000 {
+			b.Weight(int32(n))
+			return true
+		}
+		if name, ok := strings.CutPrefix(w, "charset="); ok {
+			return b.charSet(name)
+		}
+		return false
+	}
+
+	return true
+}
+
+func (b *FontBuilder) charSet(name string) bool {
+	for cs, n := range fontCharSetNames {
+		if name == n {
+			b.CharSet(cs)
+			return true
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 0 && n <= 0xFF {
+		b.CharSet(byte(n))
+		return true
+	}
+
+	return false
+}
+
+// sizeToken applies a size such as 10.5pt or 16px and reports whether w
+// was one.
+func (b *FontBuilder) sizeToken(w string) bool {
+	num, pixels := strings.CutSuffix(w, "px")
+	if !pixels {
+		var ok bool
+		if num, ok = strings.CutSuffix(w, "pt"); !ok {
+			return false
+		}
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v <= 0 || math.IsInf(v, 0) {
+		return false
+	}
+	if pixels {
+		b.Pixels(int32(math.Round(v)))
+	} else {
+		b.Points(v)
+	}
+
+	return true
+}
+
+type fontToken struct {
+	text   string
+	quoted bool
+}
+
+// fontTokens splits a font spec into words, quoted strings and commas.
+func fontTokens(s string) ([]fontToken, error) {
+	var toks []fontToken
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == ',':
+			toks = append(toks, fontToken{text: ","})
+			i++
+		case c == '\'' || c == '"':
+			n := strings.IndexByte(s[i+1:], c)
+			if n < 0 {
+				return nil, ErrFontSpecInvalid
+			}
+			toks = append(toks, fontToken{s[i+1 : i+1+n], true})
+			i += n + 2
+		default:
+			n := strings.IndexAny(s[i:], " \t,'\"")
+			if n < 0 {
+				n = len(s) - i
+			}
+			toks = append(toks, fontToken{text: s[i : i+n]})
+			i += n
+		}
+	}
+
+	return toks, nil
+}
+
+func fontFamilyValue(name string) byte {
+	for f, n := range fontFamilyNames {
+		if strings.EqualFold(name, n) {
+			return f
+		}
+	}
+
+	return 0
+}
+
+var fontWeightNames = map[int32]string{
+	FW_THIN:       "thin",
+	FW_EXTRALIGHT: "extralight",
+	FW_LIGHT:      "light",
+	FW_MEDIUM:     "medium",
+	FW_SEMIBOLD:   "semibold",
+	FW_BOLD:       "bold",
+	FW_EXTRABOLD:  "extrabold",
+	FW_HEAVY:      "heavy",
+}
+
+var fontQualityNames = map[byte]string{
+	DRAFT_QUALITY:          "draft",
+	PROOF_QUALITY:          "proof",
+	NONANTIALIASED_QUALITY: "nonantialiased",
+	ANTIALIASED_QUALITY:    "antialiased",
+	CLEARTYPE_QUALITY:      "cleartype",
+}
+
+var fontFamilyNames = map[byte]string{
+	FF_ROMAN:      "serif",
+	FF_SWISS:      "sans-serif",
+	FF_MODERN:     "monospace",
+	FF_SCRIPT:     "cursive",
+	FF_DECORATIVE: "fantasy",
+}
+
+var fontCharSetNames = map[byte]string{
+	ANSI_CHARSET:        "ansi",
+	DEFAULT_CHARSET:     "default",
+	SYMBOL_CHARSET:      "symbol",
+	SHIFTJIS_CHARSET:    "shiftjis",
+	HANGUL_CHARSET:      "hangul",
+	GB2312_CHARSET:      "gb2312",
+	CHINESEBIG5_CHARSET: "chinesebig5",
+	GREEK_CHARSET:       "greek",
+	TURKISH_CHARSET:     "turkish",
+	HEBREW_CHARSET:      "hebrew",
+	ARABIC_CHARSET:      "arabic",
+	BALTIC_CHARSET:      "baltic",
+	RUSSIAN_CHARSET:     "russian",
+	THAI_CHARSET:        "thai",
+	EASTEUROPE_CHARSET:  "easteurope",
+	OEM_CHARSET:         "oem",
+	JOHAB_CHARSET:       "johab",
+	VIETNAMESE_CHARSET:  "vietnamese",
+	MAC_CHARSET:         "mac",
+}
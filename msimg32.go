@@ -0,0 +1,54 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+var (
+	modMsimg32         = newLazyDLL("Msimg32.dll")
+	procTransparentBlt = modMsimg32.NewProc("TransparentBlt")
+	procAlphaBlend     = modMsimg32.NewProc("AlphaBlend")
+)
+
+type BLENDFUNCTION struct {
+	BlendOp             byte
+	BlendFlags          byte
+	SourceConstantAlpha byte
+	AlphaFormat         byte
+}
+
+// BLENDFUNCTION.BlendOp and AlphaFormat
+const (
+	AC_SRC_OVER  = 0x00
+	AC_SRC_ALPHA = 0x01
+)
+
+// TransparentBlt copies a rectangle of hdcSrc to hdcDest, stretching it
+// if the sizes differ and leaving out the pixels of the color
+// transparent.
+func TransparentBlt(hdcDest HDC, xDest int32, yDest int32, wDest int32, hDest int32, hdcSrc HDC, xSrc int32, ySrc int32, wSrc int32, hSrc int32, transparent COLORREF) bool {
+	if dc := lookupSoftDC(hdcDest); dc != nil {
+		return dc.transparentBlt(xDest, yDest, wDest, hDest, lookupSoftDC(hdcSrc), xSrc, ySrc, wSrc, hSrc, transparent)
+	}
+
+	var ret uintptr
+	ret, _, lastError = procTransparentBlt.Call(uintptr(hdcDest), uintptr(xDest), uintptr(yDest), uintptr(wDest), uintptr(hDest), uintptr(hdcSrc), uintptr(xSrc), uintptr(ySrc), uintptr(wSrc), uintptr(hSrc), uintptr(transparent))
+
+	return PtrToBool(ret)
+}
+
+// AlphaBlend composites a rectangle of hdcSrc onto hdcDest. With
+// AC_SRC_ALPHA the source must have premultiplied alpha.
+func AlphaBlend(hdcDest HDC, xDest int32, yDest int32, wDest int32, hDest int32, hdcSrc HDC, xSrc int32, ySrc int32, wSrc int32, hSrc int32, bf BLENDFUNCTION) bool {
+	if dc := lookupSoftDC(hdcDest); dc != nil {
+		return dc.alphaBlend(xDest, yDest, wDest, hDest, lookupSoftDC(hdcSrc), xSrc, ySrc, wSrc, hSrc, bf)
+	}
+
+	// The structure is passed by value, which fits in a register.
+	packed := uintptr(bf.BlendOp) | uintptr(bf.BlendFlags)<<8 | uintptr(bf.SourceConstantAlpha)<<16 | uintptr(bf.AlphaFormat)<<24
+
+	var ret uintptr
+	ret, _, lastError = procAlphaBlend.Call(uintptr(hdcDest), uintptr(xDest), uintptr(yDest), uintptr(wDest), uintptr(hDest), uintptr(hdcSrc), uintptr(xSrc), uintptr(ySrc), uintptr(wSrc), uintptr(hSrc), packed)
+
+	return PtrToBool(ret)
+}
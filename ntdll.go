@@ -0,0 +1,84 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+var (
+	modNtdll          = newLazyDLL("ntdll.dll")
+	procRtlGetVersion = modNtdll.NewProc("RtlGetVersion")
+)
+
+// NTSTATUS is the status code returned by the native API. Failure codes
+// are returned as errors.
+type NTSTATUS uint32
+
+const STATUS_SUCCESS NTSTATUS = 0
+
+func (s NTSTATUS) Error() string {
+	return fmt.Sprintf("winapi: NTSTATUS %#08x", uint32(s))
+}
+
+type OSVERSIONINFOEX struct {
+	OSVersionInfoSize DWORD
+	MajorVersion      DWORD
+	MinorVersion      DWORD
+	BuildNumber       DWORD
+	PlatformId        DWORD
+	CSDVersion        [128]uint16
+	ServicePackMajor  uint16
+	ServicePackMinor  uint16
+	SuiteMask         uint16
+	ProductType       byte
+	Reserved          byte
+}
+
+// RtlGetVersion fills info with the real version of the running system.
+// Unlike GetVersionEx it is not affected by the compatibility manifest.
+// OSVersionInfoSize is set by RtlGetVersion itself. On failure
+// LastError returns the NTSTATUS, as the native API does not set the
+// last error.
+func RtlGetVersion(info *OSVERSIONINFOEX) bool {
+	if err := procRtlGetVersion.Find(); err != nil {
+		lastError = err
+		return false
+	}
+
+	info.OSVersionInfoSize = DWORD(unsafe.Sizeof(*info))
+	ret, _, _ := procRtlGetVersion.Call(uintptr(unsafe.Pointer(info)))
+	if status := NTSTATUS(ret); status != STATUS_SUCCESS {
+		lastError = status
+		return false
+	}
+
+	return true
+}
+
+// OSVERSIONINFOEX product types
+const (
+	VER_NT_WORKSTATION       = 0x1
+	VER_NT_DOMAIN_CONTROLLER = 0x2
+	VER_NT_SERVER            = 0x3
+)
+
+// OSVERSIONINFOEX suite masks
+const (
+	VER_SUITE_SMALLBUSINESS            = 0x0001
+	VER_SUITE_ENTERPRISE               = 0x0002
+	VER_SUITE_BACKOFFICE               = 0x0004
+	VER_SUITE_TERMINAL                 = 0x0010
+	VER_SUITE_SMALLBUSINESS_RESTRICTED = 0x0020
+	VER_SUITE_EMBEDDEDNT               = 0x0040
+	VER_SUITE_DATACENTER               = 0x0080
+	VER_SUITE_SINGLEUSERTS             = 0x0100
+	VER_SUITE_PERSONAL                 = 0x0200
+	VER_SUITE_BLADE                    = 0x0400
+	VER_SUITE_STORAGE_SERVER           = 0x2000
+	VER_SUITE_COMPUTE_SERVER           = 0x4000
+	VER_SUITE_WH_SERVER                = 0x8000
+)
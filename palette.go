@@ -0,0 +1,103 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+	"image/color"
+)
+
+var ErrPaletteInvalid = errors.New("winapi: invalid palette")
+
+// PaletteEntries returns the colors of p as palette entries. Colors are
+// converted without premultiplied alpha, which is then dropped.
+func PaletteEntries(p color.Palette) []PALETTEENTRY {
+	entries := make([]PALETTEENTRY, len(p))
+	for i, c := range p {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		entries[i] = PALETTEENTRY{PeRed: n.R, PeGreen: n.G, PeBlue: n.B}
+	}
+
+	return entries
+}
+
+// PaletteColors returns entries as an opaque color.Palette.
+func PaletteColors(entries []PALETTEENTRY) color.Palette {
+	p := make(color.Palette, len(entries))
+	for i, e := range entries {
+		p[i] = color.RGBA{e.PeRed, e.PeGreen, e.PeBlue, 0xFF}
+	}
+
+	return p
+}
+
+// NearestPaletteIndex returns the index of the entry closest to c, or
+// -1 if there are no entries, the way GetNearestPaletteIndex picks it:
+// the distance is the sum of the squared differences of the red, green
+// and blue values, ties go to the lowest index, and the high byte of c,
+// which marks PALETTEINDEX and PALETTERGB colors, is ignored.
+func NearestPaletteIndex(entries []PALETTEENTRY, c COLORREF) int {
+	index, best := -1, int32(1<<31-1)
+	for i, e := range entries {
+		r := int32(e.PeRed) - int32(c.GetRValue())
+		g := int32(e.PeGreen) - int32(c.GetGValue())
+		b := int32(e.PeBlue) - int32(c.GetBValue())
+		if d := r*r + g*g + b*b; d < best {
+			index, best = i, d
+			if d == 0 {
+				break
+			}
+		}
+	}
+
+	return index
+}
+
+// PaletteMessage handles the palette messages for a window drawn with
+// hpal and reports whether m was one of them:
+//
+//   - WM_QUERYNEWPALETTE, sent when the window is about to get the
+//     focus, realizes hpal as the foreground palette and returns TRUE.
+//   - WM_PALETTECHANGED, sent to all top level windows after a window
+//     realized its palette, realizes hpal as a background palette
+//     unless the window itself caused the change, and returns 0.
+//
+// When realizing changes the mapping of any entry the window is
+// invalidated so it is drawn with the new colors. On displays without a
+// palette, which is all but 8 bit modes, both messages are handled by
+// returning 0.
+func PaletteMessage(m *MSG, hpal HPALETTE) (LRESULT, bool) {
+	switch m.Msg {
+	case WM_QUERYNEWPALETTE:
+	case WM_PALETTECHANGED:
+		if HWND(m.WParam) == m.HWnd {
+			return 0, true
+		}
+	default:
+		return 0, false
+	}
+
+	hdc := GetDC(m.HWnd)
+	if hdc == 0 {
+		return 0, true
+	}
+	defer ReleaseDC(m.HWnd, hdc)
+
+	if GetDeviceCaps(hdc, RASTERCAPS)&RC_PALETTE == 0 {
+		return 0, true
+	}
+
+	old := SelectPalette(hdc, hpal, m.Msg == WM_PALETTECHANGED)
+	if n := RealizePalette(hdc); n != 0 && n != GDI_ERROR {
+		InvalidateRect(m.HWnd, nil, true)
+	}
+	SelectPalette(hdc, old, true)
+
+	if m.Msg == WM_QUERYNEWPALETTE {
+		return 1, true
+	}
+
+	return 0, true
+}
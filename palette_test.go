@@ -0,0 +1,64 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestNearestPaletteIndex(t *testing.T) {
+	rgb := []PALETTEENTRY{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 10, 0}}
+	dup := []PALETTEENTRY{{1, 1, 1, 0}, {0, 0, 0, 0xFF}, {0, 0, 0, 0}}
+	tests := []struct {
+		name    string
+		entries []PALETTEENTRY
+		c       COLORREF
+		want    int
+	}{
+		{"empty", nil, RGB(0, 0, 0), -1},
+		{"exact", rgb, RGB(0, 0, 10), 2},
+		{"nearest", rgb, RGB(0, 8, 3), 1},
+		{"tie of three", rgb, RGB(0, 0, 0), 0},
+		{"tie of two", rgb, RGB(0, 5, 5), 1},
+		{"duplicate", dup, RGB(0, 0, 0), 1},
+		{"farthest", []PALETTEENTRY{{0, 0, 0, 0}}, RGB(255, 255, 255), 0},
+		{"white", []PALETTEENTRY{{0, 0, 0, 0}, {254, 255, 255, 0}}, RGB(255, 255, 255), 1},
+		{"PALETTERGB", rgb, PALETTERGB(0, 10, 0), 1},
+		{"PALETTEINDEX", rgb, PALETTEINDEX(2), 0},
+		{"high byte", rgb, 0xFF00000A, 0},
+	}
+	for _, tt := range tests {
+		if got := NearestPaletteIndex(tt.entries, tt.c); got != tt.want {
+			t.Errorf("%s: NearestPaletteIndex(%#08x) = %d, want %d", tt.name, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestPaletteEntries(t *testing.T) {
+	p := color.Palette{
+		color.RGBA{0x10, 0x20, 0x30, 0xFF},
+		color.NRGBA{0x80, 0x40, 0x20, 0x80},
+		color.Gray{0x55},
+		color.Transparent,
+	}
+	want := []PALETTEENTRY{{0x10, 0x20, 0x30, 0}, {0x80, 0x40, 0x20, 0}, {0x55, 0x55, 0x55, 0}, {0, 0, 0, 0}}
+	entries := PaletteEntries(p)
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("PaletteEntries = %v, want %v", entries, want)
+	}
+
+	colors := PaletteColors(entries)
+	for i, c := range colors {
+		e := entries[i]
+		if c != (color.RGBA{e.PeRed, e.PeGreen, e.PeBlue, 0xFF}) {
+			t.Errorf("PaletteColors[%d] = %v, want the opaque color of %v", i, c, e)
+		}
+	}
+	if got := PaletteEntries(colors); !reflect.DeepEqual(got, entries) {
+		t.Errorf("PaletteEntries(PaletteColors(e)) = %v, want %v", got, entries)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+)
+
+var ErrPathInvalid = errors.New("winapi: invalid path")
+
+// Path records figures the way a GDI path does, as points tagged with
+// the PT_ types GetPath returns. Each figure starts with a PT_MOVETO
+// point, which is only added once something is drawn, and its last
+// point carries PT_CLOSEFIGURE if the figure was closed. The zero value
+// is an empty path with the current position at the origin.
+type Path struct {
+	pts   []POINT
+	types []byte
+	pos   POINT
+	moved bool
+}
+
+// pathFigure is one figure of a flattened path.
+type pathFigure struct {
+	pts    []POINT
+	closed bool
+}
+
+// DecodePath returns the path described by the points and types
+// GetPath returns.
+func DecodePath(pts []POINT, types []byte) (*Path, error) {
+	if len(pts) != len(types) {
+		return nil, ErrPathInvalid
+	}
+
+	p := &Path{}
+	for i := 0; i < len(types); i++ {
+		switch types[i] &^ PT_CLOSEFIGURE {
+		case PT_MOVETO:
+			if types[i]&PT_CLOSEFIGURE != 0 {
+				return nil, ErrPathInvalid
+			}
+		case PT_LINETO:
+			if i == 0 {
+				return nil, ErrPathInvalid
+			}
+		case PT_BEZIERTO:
+			// Only the end point of a curve may close the figure.
+			if i == 0 || i+2 >= len(types) || types[i] != PT_BEZIERTO || types[i+1] != PT_BEZIERTO || types[i+2]&^PT_CLOSEFIGURE != PT_BEZIERTO {
+				return nil, ErrPathInvalid
+			}
+			i += 2
+		default:
+			return nil, ErrPathInvalid
+		}
+	}
+
+	p.pts = append(p.pts, pts...)
+	p.types = append(p.types, types...)
+	if n := len(pts); n > 0 {
+		p.pos = pts[n-1]
+		p.moved = types[n-1]&PT_CLOSEFIGURE != 0
+	}
+
+	return p, nil
+}
+
+// Points returns the points of p and their types, as GetPath does.
+func (p *Path) Points() ([]POINT, []byte) {
+	return append([]POINT(nil), p.pts...), append([]byte(nil), p.types...)
+}
+
+// CurrentPosition returns the point the next LineTo or PolyBezierTo
+// starts from.
+func (p *Path) CurrentPosition() POINT {
+	return p.pos
+}
+
+// MoveTo sets the current position, starting a new figure with the
+// next line or curve.
+func (p *Path) MoveTo(x, y int32) {
+	p.pos = POINT{x, y}
+	p.moved = true
+}
+
+// startFigure adds a PT_MOVETO point unless the last figure is open and
+// ends at the current position.
+func (p *Path) startFigure() {
+	n := len(p.pts)
+	if !p.moved && n > 0 && p.types[n-1]&PT_CLOSEFIGURE == 0 && p.pts[n-1] == p.pos {
+		return
+	}
+
+	p.moved = false
+	p.add(PT_MOVETO, p.pos)
+}
+
+func (p *Path) add(typ byte, pts ...POINT) {
+	for _, pt := range pts {
+		p.pts = append(p.pts, pt)
+		p.types = append(p.types, typ)
+	}
+}
+
+func (p *Path) LineTo(x, y int32) {
+	p.startFigure()
+	p.pos = POINT{x, y}
+	p.add(PT_LINETO, p.pos)
+}
+
+func (p *Path) PolylineTo(pts []POINT) {
+	for _, pt := range pts {
+		p.LineTo(pt.X, pt.Y)
+	}
+}
+
+// PolyBezierTo adds cubic Bézier curves from the current position. The
+// number of points must be a multiple of 3: two control points and an
+// end point for each curve.
+func (p *Path) PolyBezierTo(pts []POINT) bool {
+	if len(pts) == 0 || len(pts)%3 != 0 {
+		return false
+	}
+
+	p.startFigure()
+	p.add(PT_BEZIERTO, pts...)
+	p.pos = pts[len(pts)-1]
+
+	return true
+}
+
+// Polyline adds a figure of connected lines. Like its GDI counterpart it
+// does not use or change the current position.
+func (p *Path) Polyline(pts []POINT) bool {
+	if len(pts) < 2 {
+		return false
+	}
+
+	p.add(PT_MOVETO, pts[0])
+	p.add(PT_LINETO, pts[1:]...)
+
+	return true
+}
+
+// PolyBezier adds a figure of cubic Bézier curves starting at pts[0],
+// without using or changing the current position.
+func (p *Path) PolyBezier(pts []POINT) bool {
+	if len(pts) < 4 || (len(pts)-1)%3 != 0 {
+		return false
+	}
+
+	p.add(PT_MOVETO, pts[0])
+	p.add(PT_BEZIERTO, pts[1:]...)
+
+	return true
+}
+
+// Polygon adds a closed figure.
+func (p *Path) Polygon(pts []POINT) bool {
+	if !p.Polyline(pts) {
+		return false
+	}
+	p.CloseFigure()
+
+	return true
+}
+
+// CloseFigure closes the last figure with a line back to its first
+// point. The next line or curve starts a new figure.
+func (p *Path) CloseFigure() {
+	if n := len(p.types); n > 0 && p.types[n-1] != PT_MOVETO {
+		p.types[n-1] |= PT_CLOSEFIGURE
+	}
+	p.moved = true
+}
+
+// Flatten returns p with its curves replaced by lines, as FlattenPath
+// does.
+func (p *Path) Flatten() *Path {
+	out := &Path{pos: p.pos, moved: p.moved}
+	for i := 0; i < len(p.pts); i++ {
+		if p.types[i] != PT_BEZIERTO {
+			out.add(p.types[i], p.pts[i])
+			continue
+		}
+
+		flat := flattenBezier([]POINT{p.pts[i-1], p.pts[i], p.pts[i+1], p.pts[i+2]})
+		out.add(PT_LINETO, flat[1:]...)
+		out.types[len(out.types)-1] |= p.types[i+2] & PT_CLOSEFIGURE
+		i += 2
+	}
+
+	return out
+}
+
+// figures returns the figures of p with curves flattened.
+func (p *Path) figures() []pathFigure {
+	flat := p.Flatten()
+
+	var figs []pathFigure
+	for i, pt := range flat.pts {
+		if flat.types[i] == PT_MOVETO {
+			figs = append(figs, pathFigure{})
+		}
+		f := &figs[len(figs)-1]
+		f.pts = append(f.pts, pt)
+		f.closed = flat.types[i]&PT_CLOSEFIGURE != 0
+	}
+
+	return figs
+}
+
+// Polygons returns the figures of p with curves flattened, each as the
+// polygon that filling the path fills.
+func (p *Path) Polygons() [][]POINT {
+	var polys [][]POINT
+	for _, f := range p.figures() {
+		polys = append(polys, f.pts)
+	}
+
+	return polys
+}
+
+// Region returns the area of p filled with mode, ALTERNATE or WINDING,
+// as PathToRegion does.
+func (p *Path) Region(mode int32) *Region {
+	return NewPolyPolygonRegion(p.Polygons(), mode)
+}
@@ -0,0 +1,129 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The point counts of GDI's fixed point subdivision, as Wine implements
+// it, including the start point.
+func TestFlattenBezier(t *testing.T) {
+	tests := []struct {
+		name  string
+		pts   []POINT
+		count int
+		want  []POINT
+	}{
+		{"straight", []POINT{{0, 0}, {10, 0}, {20, 0}, {30, 0}}, 2, []POINT{{0, 0}, {30, 0}}},
+		{"small", []POINT{{0, 0}, {2, 3}, {4, 1}, {5, 5}}, 3, []POINT{{0, 0}, {3, 2}, {5, 5}}},
+		{"arch", []POINT{{0, 0}, {0, 100}, {100, 100}, {100, 0}}, 18, nil},
+		{"s curve", []POINT{{10, 10}, {10, 50}, {50, 10}, {50, 50}}, 11, nil},
+		{"large loop", []POINT{{0, 0}, {1000, 0}, {1000, 1000}, {0, 1000}}, 60, nil},
+		{"two curves", []POINT{{0, 0}, {0, 100}, {100, 100}, {100, 0}, {100, -100}, {200, -100}, {200, 0}}, 34, nil},
+		{"control behind start", []POINT{{0, 0}, {-40, 0}, {0, 0}, {10, 0}}, 7, nil},
+	}
+	for _, tt := range tests {
+		got := flattenBezier(tt.pts)
+		if len(got) != tt.count {
+			t.Errorf("%s: %d points, want %d", tt.name, len(got), tt.count)
+		}
+		if len(got) > 0 && (got[0] != tt.pts[0] || got[len(got)-1] != tt.pts[len(tt.pts)-1]) {
+			t.Errorf("%s: flattened from %v to %v, want %v to %v", tt.name, got[0], got[len(got)-1], tt.pts[0], tt.pts[len(tt.pts)-1])
+		}
+		if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: points = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	for _, n := range []int{0, 1, 3, 5} {
+		if got := flattenBezier(make([]POINT, n)); got != nil {
+			t.Errorf("flattenBezier of %d points = %v, want nil", n, got)
+		}
+	}
+}
+
+func TestPathFlatten(t *testing.T) {
+	p := &Path{}
+	p.MoveTo(0, 0)
+	p.PolyBezierTo([]POINT{{0, 100}, {100, 100}, {100, 0}})
+	p.CloseFigure()
+	p.LineTo(50, 50)
+
+	flat := p.Flatten()
+	pts, types := flat.Points()
+	if len(pts) != 20 {
+		t.Fatalf("flattened to %d points, want 20", len(pts))
+	}
+	if types[0] != PT_MOVETO || types[17] != PT_LINETO|PT_CLOSEFIGURE || types[18] != PT_MOVETO || types[19] != PT_LINETO {
+		t.Errorf("types = %v", types)
+	}
+	for i := 1; i < 17; i++ {
+		if types[i] != PT_LINETO {
+			t.Errorf("type %d = %#x, want PT_LINETO", i, types[i])
+		}
+	}
+	if pts[17] != (POINT{100, 0}) || pts[18] != (POINT{100, 0}) {
+		t.Errorf("curve ends at %v, next figure starts at %v, want both at {100 0}", pts[17], pts[18])
+	}
+	if flat.CurrentPosition() != (POINT{50, 50}) {
+		t.Errorf("CurrentPosition = %v, want {50 50}", flat.CurrentPosition())
+	}
+}
+
+func TestDecodePath(t *testing.T) {
+	pts := []POINT{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {5, 5}, {20, 20}, {30, 20}, {30, 30}, {20, 30}}
+	types := []byte{
+		PT_MOVETO, PT_LINETO, PT_BEZIERTO, PT_BEZIERTO, PT_BEZIERTO | PT_CLOSEFIGURE,
+		PT_MOVETO, PT_LINETO, PT_LINETO, PT_LINETO,
+	}
+
+	p, err := DecodePath(pts, types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotPts, gotTypes := p.Points()
+	if !reflect.DeepEqual(gotPts, pts) || !reflect.DeepEqual(gotTypes, types) {
+		t.Errorf("Points = %v, %v, want %v, %v", gotPts, gotTypes, pts, types)
+	}
+	if p.CurrentPosition() != (POINT{20, 30}) {
+		t.Errorf("CurrentPosition = %v, want {20 30}", p.CurrentPosition())
+	}
+	if polys := p.Polygons(); len(polys) != 2 {
+		t.Errorf("%d polygons, want 2", len(polys))
+	}
+
+	if p, err := DecodePath(nil, nil); err != nil || len(p.pts) != 0 {
+		t.Errorf("DecodePath of an empty path = %v, %v", p, err)
+	}
+}
+
+func TestDecodePathInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []byte
+	}{
+		{"line first", []byte{PT_LINETO, PT_LINETO}},
+		{"curve first", []byte{PT_BEZIERTO, PT_BEZIERTO, PT_BEZIERTO}},
+		{"closed move", []byte{PT_MOVETO | PT_CLOSEFIGURE, PT_LINETO}},
+		{"short curve", []byte{PT_MOVETO, PT_BEZIERTO, PT_BEZIERTO}},
+		{"curve of four", []byte{PT_MOVETO, PT_BEZIERTO, PT_BEZIERTO, PT_BEZIERTO, PT_BEZIERTO}},
+		{"curve with a line", []byte{PT_MOVETO, PT_BEZIERTO, PT_LINETO, PT_BEZIERTO}},
+		{"closed control point", []byte{PT_MOVETO, PT_BEZIERTO | PT_CLOSEFIGURE, PT_BEZIERTO, PT_BEZIERTO}},
+		{"closed second control point", []byte{PT_MOVETO, PT_BEZIERTO, PT_BEZIERTO | PT_CLOSEFIGURE, PT_BEZIERTO}},
+		{"zero type", []byte{PT_MOVETO, 0}},
+		{"unknown type", []byte{PT_MOVETO, 0x08}},
+	}
+	for _, tt := range tests {
+		if p, err := DecodePath(make([]POINT, len(tt.types)), tt.types); err != ErrPathInvalid {
+			t.Errorf("%s: DecodePath = %v, %v, want ErrPathInvalid", tt.name, p, err)
+		}
+	}
+
+	if _, err := DecodePath(make([]POINT, 3), []byte{PT_MOVETO, PT_LINETO}); err != ErrPathInvalid {
+		t.Errorf("DecodePath with more points than types: err = %v, want ErrPathInvalid", err)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import "sort"
+
+// bresenham calls plot for each pixel of the line from p to q, excluding
+// q, as GDI draws cosmetic lines. Lines in octants 3, 5, 6 and 8, i.e.
+// those heading left or up, round their error term the other way, so a
+// line covers the same pixels in both directions.
+func bresenham(p, q POINT, plot func(x, y int32)) {
+	dx, dy := q.X-p.X, q.Y-p.Y
+	adx, ady := abs32(dx), abs32(dy)
+	xinc, yinc := sign32(dx), sign32(dy)
+
+	var bias int32
+	if lineOctantMask(dx, dy)&0xb4 != 0 {
+		bias = 1
+	}
+
+	x, y := p.X, p.Y
+	if adx > ady {
+		err := 2*ady - adx
+		for n := adx; n > 0; n-- {
+			plot(x, y)
+			if err+bias > 0 {
+				y += yinc
+				err += 2*ady - 2*adx
+			} else {
+				err += 2 * ady
+			}
+			x += xinc
+		}
+		return
+	}
+
+	err := 2*adx - ady
+	for n := ady; n > 0; n-- {
+		plot(x, y)
+		if err+bias > 0 {
+			x += xinc
+			err += 2*adx - 2*ady
+		} else {
+			err += 2 * adx
+		}
+		y += yinc
+	}
+}
+
+// lineOctantMask returns 1<<(octant-1) with octants numbered
+// counterclockwise from the positive x axis in device space.
+func lineOctantMask(dx, dy int32) uint32 {
+	var octant uint32
+	switch {
+	case dy > 0 && dx > 0:
+		octant = 2
+		if dx > dy {
+			octant = 1
+		}
+	case dy > 0:
+		octant = 3
+		if -dx > dy {
+			octant = 4
+		}
+	case dx < 0:
+		octant = 6
+		if -dx > -dy {
+			octant = 5
+		}
+	default:
+		octant = 7
+		if dx > -dy {
+			octant = 8
+		}
+	}
+
+	return 1 << (octant - 1)
+}
+
+// Polygon fill modes
+const (
+	ALTERNATE = 1
+	WINDING   = 2
+)
+
+type polyEdge struct {
+	x0, y0, x1, y1 int32
+	dir            int
+}
+
+type polyCrossing struct {
+	x   int32
+	dir int
+}
+
+// polygonSpans scan converts the closed polygons polys and calls span
+// for each run of pixels [x0, x1) on row y, top to bottom. A pixel is
+// inside if its top left corner is inside the polygon, counting points
+// on left and top edges as inside and points on right and bottom edges
+// as outside, which is how GDI converts polygons to regions.
+func polygonSpans(polys [][]POINT, mode int32, span func(y, x0, x1 int32)) {
+	var edges []polyEdge
+	first := true
+	var top, bottom int32
+	for _, pts := range polys {
+		for i, p := range pts {
+			q := pts[(i+1)%len(pts)]
+			switch {
+			case p.Y < q.Y:
+				edges = append(edges, polyEdge{p.X, p.Y, q.X, q.Y, 1})
+			case p.Y > q.Y:
+				edges = append(edges, polyEdge{q.X, q.Y, p.X, p.Y, -1})
+			default:
+				continue
+			}
+			e := edges[len(edges)-1]
+			if first || e.y0 < top {
+				top = e.y0
+			}
+			if first || e.y1 > bottom {
+				bottom = e.y1
+			}
+			first = false
+		}
+	}
+
+	var xs []polyCrossing
+	for y := top; y < bottom; y++ {
+		xs = xs[:0]
+		for _, e := range edges {
+			if y < e.y0 || y >= e.y1 {
+				continue
+			}
+			num := int64(y-e.y0) * int64(e.x1-e.x0)
+			xs = append(xs, polyCrossing{e.x0 + int32(ceilDiv(num, int64(e.y1-e.y0))), e.dir})
+		}
+		sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+
+		if mode == WINDING {
+			w := 0
+			var x0 int32
+			for _, c := range xs {
+				if w == 0 {
+					x0 = c.x
+				}
+				w += c.dir
+				if w == 0 && c.x > x0 {
+					span(y, x0, c.x)
+				}
+			}
+			continue
+		}
+
+		for i := 0; i+1 < len(xs); i += 2 {
+			if xs[i].x < xs[i+1].x {
+				span(y, xs[i].x, xs[i+1].x)
+			}
+		}
+	}
+}
+
+// Bézier curves are flattened the way Wine's GDI does: in fixed point
+// with four fractional bits, splitting each curve at its midpoint until
+// the control points lie within a pixel of the chord or the recursion
+// limit is reached.
+const (
+	bezierShiftBits = 4
+	bezierPixel     = 1 << bezierShiftBits
+	bezierMaxDepth  = 8
+)
+
+// flattenBezier returns the polyline approximating the cubic Bézier
+// curves in pts, whose length must be 3n+1. The first point is pts[0]
+// and each curve ends exactly on its end point.
+func flattenBezier(pts []POINT) []POINT {
+	if len(pts) < 4 || (len(pts)-1)%3 != 0 {
+		return nil
+	}
+
+	out := []POINT{pts[0]}
+	for i := 0; i+3 < len(pts); i += 3 {
+		var c [4]POINT
+		for j := range c {
+			c[j] = POINT{pts[i+j].X << bezierShiftBits, pts[i+j].Y << bezierShiftBits}
+		}
+		out = bezierSplit(c, bezierMaxDepth, out)
+	}
+
+	return out
+}
+
+func bezierSplit(c [4]POINT, level int, out []POINT) []POINT {
+	if level == 0 || bezierFlat(&c) {
+		return append(out, POINT{bezierShiftDown(c[3].X), bezierShiftDown(c[3].Y)})
+	}
+
+	var d [4]POINT
+	d[3] = c[3]
+	d[2] = bezierMiddle(c[2], c[3])
+	d[0] = bezierMiddle(c[1], c[2])
+	d[1] = bezierMiddle(d[0], d[2])
+	c[1] = bezierMiddle(c[0], c[1])
+	c[2] = bezierMiddle(c[1], d[0])
+	c[3] = bezierMiddle(c[2], d[1])
+	d[0] = c[3]
+
+	out = bezierSplit(c, level-1, out)
+
+	return bezierSplit(d, level-1, out)
+}
+
+// bezierFlat reports whether the control points of c lie between its
+// end points along the major axis and within a pixel of the chord.
+func bezierFlat(c *[4]POINT) bool {
+	major := func(p POINT) int32 { return p.X }
+	minor := func(p POINT) int32 { return p.Y }
+	if abs32(c[3].Y-c[0].Y) > abs32(c[3].X-c[0].X) {
+		major, minor = minor, major
+	}
+
+	for _, p := range c[1:3] {
+		if major(p) < major(c[0]) {
+			if major(p) < major(c[3]) {
+				return false
+			}
+		} else if major(p) > major(c[3]) {
+			return false
+		}
+	}
+
+	d := bezierShiftDown(major(c[3]) - major(c[0]))
+	if d == 0 {
+		return true
+	}
+
+	slope := (minor(c[3]) - minor(c[0])) / d
+	for _, p := range c[1:3] {
+		off := minor(p) - minor(c[0]) - slope*bezierShiftDown(major(p)-major(c[0]))
+		if abs32(off) > bezierPixel {
+			return false
+		}
+	}
+
+	return true
+}
+
+func bezierMiddle(p, q POINT) POINT {
+	return POINT{(p.X + q.X + 1) / 2, (p.Y + q.Y + 1) / 2}
+}
+
+func bezierShiftDown(v int32) int32 {
+	return (v + 1<<(bezierShiftBits-1)) >> bezierShiftBits
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func sign32(v int32) int32 {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+
+	return 0
+}
+
+// ceilDiv returns a/b rounded up, for b > 0.
+func ceilDiv(a, b int64) int64 {
+	q := a / b
+	if a%b > 0 {
+		q++
+	}
+
+	return q
+}
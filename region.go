@@ -0,0 +1,465 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+var ErrRegionDataInvalid = errors.New("winapi: invalid region data")
+
+const rgnDataHeaderSize = 32
+
+// Region is a set of pixels kept the way GDI keeps regions and RGNDATA
+// exchanges them: rectangles with exclusive right and bottom edges,
+// sorted top to bottom into bands that share their top and bottom, each
+// band sorted left to right with no touching rectangles, and adjacent
+// bands with the same horizontal spans merged. A set of pixels has
+// exactly one such form, so regions with the same pixels have the same
+// rectangles. The zero value is an empty region.
+type Region struct {
+	rects []RECT
+}
+
+// span is a run of pixels [left, right) of a band.
+type span struct {
+	left, right int32
+}
+
+type band struct {
+	top, bottom int32
+	spans       []span
+}
+
+// NewRectRegion returns the region covering a rectangle, which may be
+// given with its corners in any order.
+func NewRectRegion(left, top, right, bottom int32) *Region {
+	left, right = min(left, right), max(left, right)
+	top, bottom = min(top, bottom), max(top, bottom)
+
+	r := &Region{}
+	r.appendBand(top, bottom, []span{{left, right}})
+
+	return r
+}
+
+// NewRoundRectRegion returns the region covering a rectangle with
+// rounded corners drawn with an ellipse of the given size. Like
+// CreateRoundRectRgn it leaves out the last column and row.
+func NewRoundRectRegion(left, top, right, bottom, width, height int32) *Region {
+	left, right = min(left, right), max(left, right)
+	top, bottom = min(top, bottom), max(top, bottom)
+	right--
+	bottom--
+
+	width = min(right-left, abs32(width))
+	height = min(bottom-top, abs32(height))
+	if width < 2 || height < 2 {
+		return NewRectRegion(left, top, right, bottom)
+	}
+
+	// Walk a quarter of the ellipse with Alois Zingl's algorithm,
+	// recording the inset of each row of the lower half.
+	rows := make([]span, height)
+	a, b := int64(width-1), int64(height-1)
+	asq, bsq := 8*a*a, 8*b*b
+	dx := 4 * b * b * (1 - a)
+	dy := 4 * a * a * (1 + b%2)
+	e := dx + dy + a*a*(b%2)
+
+	x, y := int32(0), height/2
+	rows[y] = span{left, right}
+	for x <= width/2 {
+		e2 := 2 * e
+		if e2 >= dx {
+			x++
+			dx += bsq
+			e += dx
+		}
+		if e2 <= dy {
+			y++
+			dy += asq
+			e += dy
+			rows[y] = span{left + x, right - x}
+		}
+	}
+	for i := int32(0); i < height/2; i++ {
+		rows[i] = rows[int32(b)-i]
+	}
+
+	r := &Region{}
+	for i := int32(0); i < height/2; i++ {
+		r.appendBand(top+i, top+i+1, []span{rows[i]})
+	}
+	r.appendBand(top+height/2, bottom-height+height/2+1, []span{rows[height/2]})
+	for i := height/2 + 1; i < height; i++ {
+		y := bottom - height + i
+		r.appendBand(y, y+1, []span{rows[i]})
+	}
+
+	return r
+}
+
+// NewPolygonRegion returns the region covering a polygon filled with
+// mode, ALTERNATE or WINDING, as CreatePolygonRgn does.
+func NewPolygonRegion(pts []POINT, mode int32) *Region {
+	return NewPolyPolygonRegion([][]POINT{pts}, mode)
+}
+
+// NewPolyPolygonRegion returns the region covering several polygons
+// filled together with mode.
+func NewPolyPolygonRegion(polys [][]POINT, mode int32) *Region {
+	r := &Region{}
+
+	row := int32(0)
+	var spans []span
+	polygonSpans(polys, mode, func(y, x0, x1 int32) {
+		if y != row && len(spans) > 0 {
+			r.appendBand(row, row+1, unionSpans(spans))
+			spans = spans[:0]
+		}
+		row = y
+		spans = append(spans, span{x0, x1})
+	})
+	if len(spans) > 0 {
+		r.appendBand(row, row+1, unionSpans(spans))
+	}
+
+	return r
+}
+
+// unionSpans merges the overlapping and touching spans of a row.
+func unionSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].left < spans[j].left })
+
+	out := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.left <= last.right {
+			last.right = max(last.right, s.right)
+			continue
+		}
+		out = append(out, s)
+	}
+
+	return out
+}
+
+// appendBand adds spans, which must be sorted and not touch, as the
+// band [top, bottom) below the existing ones, merging it into the last
+// band if that ends at top with the same spans.
+func (r *Region) appendBand(top, bottom int32, spans []span) {
+	if top >= bottom {
+		return
+	}
+
+	n := 0
+	for _, s := range spans {
+		if s.left < s.right {
+			spans[n] = s
+			n++
+		}
+	}
+	spans = spans[:n]
+	if len(spans) == 0 {
+		return
+	}
+
+	last := len(r.rects)
+	for last > 0 && r.rects[last-1].Top == r.rects[len(r.rects)-1].Top {
+		last--
+	}
+	if prev := r.rects[last:]; len(prev) == len(spans) && prev[0].Bottom == top {
+		same := true
+		for i, s := range spans {
+			if prev[i].Left != s.left || prev[i].Right != s.right {
+				same = false
+				break
+			}
+		}
+		if same {
+			for i := range prev {
+				prev[i].Bottom = bottom
+			}
+			return
+		}
+	}
+
+	for _, s := range spans {
+		r.rects = append(r.rects, RECT{s.left, top, s.right, bottom})
+	}
+}
+
+func (r *Region) bands() []band {
+	var bands []band
+	for _, rc := range r.rects {
+		if len(bands) == 0 || bands[len(bands)-1].top != rc.Top {
+			bands = append(bands, band{top: rc.Top, bottom: rc.Bottom})
+		}
+		b := &bands[len(bands)-1]
+		b.spans = append(b.spans, span{rc.Left, rc.Right})
+	}
+
+	return bands
+}
+
+// Rects returns the rectangles of r in band order.
+func (r *Region) Rects() []RECT {
+	return append([]RECT(nil), r.rects...)
+}
+
+// Bounds returns the smallest rectangle containing r, or an empty
+// rectangle if r is empty.
+func (r *Region) Bounds() RECT {
+	if len(r.rects) == 0 {
+		return RECT{}
+	}
+
+	b := RECT{r.rects[0].Left, r.rects[0].Top, r.rects[0].Right, r.rects[len(r.rects)-1].Bottom}
+	for _, rc := range r.rects[1:] {
+		b.Left = min(b.Left, rc.Left)
+		b.Right = max(b.Right, rc.Right)
+	}
+
+	return b
+}
+
+// Type returns NULLREGION, SIMPLEREGION or COMPLEXREGION, as the GDI
+// region functions do.
+func (r *Region) Type() int32 {
+	switch len(r.rects) {
+	case 0:
+		return NULLREGION
+	case 1:
+		return SIMPLEREGION
+	}
+
+	return COMPLEXREGION
+}
+
+// Contains reports whether pixel x, y is in r.
+func (r *Region) Contains(x, y int32) bool {
+	i := sort.Search(len(r.rects), func(i int) bool { return r.rects[i].Bottom > y })
+	for ; i < len(r.rects) && r.rects[i].Top <= y; i++ {
+		if x >= r.rects[i].Left && x < r.rects[i].Right {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Equal reports whether r and o contain the same pixels.
+func (r *Region) Equal(o *Region) bool {
+	if len(r.rects) != len(o.rects) {
+		return false
+	}
+	for i := range r.rects {
+		if r.rects[i] != o.rects[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Offset returns r moved by dx, dy.
+func (r *Region) Offset(dx, dy int32) *Region {
+	out := &Region{rects: r.Rects()}
+	for i := range out.rects {
+		out.rects[i].Left += dx
+		out.rects[i].Top += dy
+		out.rects[i].Right += dx
+		out.rects[i].Bottom += dy
+	}
+
+	return out
+}
+
+// Combine returns the combination of r and o with mode, as CombineRgn
+// does: RGN_AND, RGN_OR, RGN_XOR, RGN_DIFF, which keeps the pixels of r
+// that are not in o, or RGN_COPY, which returns a copy of r. It returns
+// nil for other modes.
+func (r *Region) Combine(o *Region, mode int32) *Region {
+	var op func(a, b bool) bool
+	switch mode {
+	case RGN_AND:
+		op = func(a, b bool) bool { return a && b }
+	case RGN_OR:
+		op = func(a, b bool) bool { return a || b }
+	case RGN_XOR:
+		op = func(a, b bool) bool { return a != b }
+	case RGN_DIFF:
+		op = func(a, b bool) bool { return a && !b }
+	case RGN_COPY:
+		return &Region{rects: r.Rects()}
+	default:
+		return nil
+	}
+
+	return combineRegions(r, o, op)
+}
+
+// combineRegions evaluates op for every band between consecutive top
+// or bottom edges of a and b.
+func combineRegions(a, b *Region, op func(a, b bool) bool) *Region {
+	ba, bb := a.bands(), b.bands()
+
+	var ys []int32
+	for _, bs := range [][]band{ba, bb} {
+		for _, b := range bs {
+			ys = append(ys, b.top, b.bottom)
+		}
+	}
+	sort.Slice(ys, func(i, j int) bool { return ys[i] < ys[j] })
+
+	out := &Region{}
+	var ia, ib int
+	for i := 0; i+1 < len(ys); i++ {
+		top, bottom := ys[i], ys[i+1]
+		if top == bottom {
+			continue
+		}
+		for ia < len(ba) && ba[ia].bottom <= top {
+			ia++
+		}
+		for ib < len(bb) && bb[ib].bottom <= top {
+			ib++
+		}
+
+		var sa, sb []span
+		if ia < len(ba) && ba[ia].top <= top {
+			sa = ba[ia].spans
+		}
+		if ib < len(bb) && bb[ib].top <= top {
+			sb = bb[ib].spans
+		}
+		out.appendBand(top, bottom, combineSpans(sa, sb, op))
+	}
+
+	return out
+}
+
+// combineSpans evaluates op between consecutive edges of two sorted
+// rows of spans and returns the spans where it holds.
+func combineSpans(a, b []span, op func(a, b bool) bool) []span {
+	var xs []int32
+	for _, s := range a {
+		xs = append(xs, s.left, s.right)
+	}
+	for _, s := range b {
+		xs = append(xs, s.left, s.right)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+
+	var out []span
+	var ia, ib int
+	for i := 0; i+1 < len(xs); i++ {
+		left, right := xs[i], xs[i+1]
+		if left == right {
+			continue
+		}
+		for ia < len(a) && a[ia].right <= left {
+			ia++
+		}
+		for ib < len(b) && b[ib].right <= left {
+			ib++
+		}
+
+		inA := ia < len(a) && a[ia].left <= left
+		inB := ib < len(b) && b[ib].left <= left
+		if !op(inA, inB) {
+			continue
+		}
+		if n := len(out); n > 0 && out[n-1].right == left {
+			out[n-1].right = right
+			continue
+		}
+		out = append(out, span{left, right})
+	}
+
+	return out
+}
+
+// Data encodes r as the RGNDATA GetRegionData returns.
+func (r *Region) Data() []byte {
+	b := make([]byte, rgnDataHeaderSize+16*len(r.rects))
+	bounds := r.Bounds()
+	for i, v := range []uint32{
+		rgnDataHeaderSize, RDH_RECTANGLES, uint32(len(r.rects)), uint32(16 * len(r.rects)),
+		uint32(bounds.Left), uint32(bounds.Top), uint32(bounds.Right), uint32(bounds.Bottom),
+	} {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	for i, rc := range r.rects {
+		putRect(b[rgnDataHeaderSize+16*i:], rc)
+	}
+
+	return b
+}
+
+// DecodeRegionData decodes RGNDATA as ExtCreateRegion does, accepting
+// rectangles in any order and overlapping.
+func DecodeRegionData(b []byte) (*Region, error) {
+	if len(b) < rgnDataHeaderSize {
+		return nil, ErrRegionDataInvalid
+	}
+
+	size := binary.LittleEndian.Uint32(b)
+	typ := binary.LittleEndian.Uint32(b[4:])
+	count := binary.LittleEndian.Uint32(b[8:])
+	if size < rgnDataHeaderSize || typ != RDH_RECTANGLES || uint64(len(b)) < uint64(size)+16*uint64(count) {
+		return nil, ErrRegionDataInvalid
+	}
+
+	r := &Region{}
+	for i := uint32(0); i < count; i++ {
+		rc := getRect(b[size+16*i:])
+		if rc.Left >= rc.Right || rc.Top >= rc.Bottom {
+			continue
+		}
+		r = r.Combine(NewRectRegion(rc.Left, rc.Top, rc.Right, rc.Bottom), RGN_OR)
+	}
+
+	return r, nil
+}
+
+func putRect(b []byte, rc RECT) {
+	binary.LittleEndian.PutUint32(b, uint32(rc.Left))
+	binary.LittleEndian.PutUint32(b[4:], uint32(rc.Top))
+	binary.LittleEndian.PutUint32(b[8:], uint32(rc.Right))
+	binary.LittleEndian.PutUint32(b[12:], uint32(rc.Bottom))
+}
+
+func getRect(b []byte) RECT {
+	return RECT{
+		int32(binary.LittleEndian.Uint32(b)),
+		int32(binary.LittleEndian.Uint32(b[4:])),
+		int32(binary.LittleEndian.Uint32(b[8:])),
+		int32(binary.LittleEndian.Uint32(b[12:])),
+	}
+}
+
+// HRGN creates a native region with the pixels of r.
+func (r *Region) HRGN() (HRGN, error) {
+	h := ExtCreateRegion(nil, r.Data())
+	if h == 0 {
+		return 0, lastError
+	}
+
+	return h, nil
+}
+
+// RegionFromHRGN returns the pixels of a native region.
+func RegionFromHRGN(h HRGN) (*Region, error) {
+	b := GetRegionData(h)
+	if b == nil {
+		return nil, lastError
+	}
+
+	return DecodeRegionData(b)
+}
@@ -0,0 +1,231 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// checkBands fails unless the rectangles of r are in the form GDI keeps
+// them: non-empty, in bands sorted top to bottom that do not overlap,
+// sorted left to right without touching within a band, and with no band
+// continuing the previous one with the same spans.
+func checkBands(t *testing.T, name string, r *Region) {
+	t.Helper()
+
+	bands := r.bands()
+	for i, b := range bands {
+		if b.top >= b.bottom {
+			t.Errorf("%s: empty band %d in %v", name, i, r.rects)
+		}
+		for j, s := range b.spans {
+			if s.left >= s.right || j > 0 && s.left <= b.spans[j-1].right {
+				t.Errorf("%s: band %d not sorted or touching in %v", name, i, r.rects)
+			}
+		}
+		if i == 0 {
+			continue
+		}
+		prev := bands[i-1]
+		if b.top < prev.bottom {
+			t.Errorf("%s: band %d overlaps the one above in %v", name, i, r.rects)
+		}
+		if b.top == prev.bottom && reflect.DeepEqual(b.spans, prev.spans) {
+			t.Errorf("%s: band %d not merged with the one above in %v", name, i, r.rects)
+		}
+	}
+	for _, rc := range r.rects {
+		for _, b := range bands {
+			if rc.Top == b.top && rc.Bottom != b.bottom {
+				t.Errorf("%s: rectangles of a band differ in height in %v", name, r.rects)
+			}
+		}
+	}
+}
+
+func TestRegionCombine(t *testing.T) {
+	a, b := NewRectRegion(0, 0, 10, 10), NewRectRegion(5, 5, 15, 15)
+	tests := []struct {
+		mode int32
+		want []RECT
+	}{
+		{RGN_AND, []RECT{{5, 5, 10, 10}}},
+		{RGN_OR, []RECT{{0, 0, 10, 5}, {0, 5, 15, 10}, {5, 10, 15, 15}}},
+		{RGN_XOR, []RECT{{0, 0, 10, 5}, {0, 5, 5, 10}, {10, 5, 15, 10}, {5, 10, 15, 15}}},
+		{RGN_DIFF, []RECT{{0, 0, 10, 5}, {0, 5, 5, 10}}},
+		{RGN_COPY, []RECT{{0, 0, 10, 10}}},
+	}
+	for _, tt := range tests {
+		r := a.Combine(b, tt.mode)
+		if got := r.Rects(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Combine mode %d = %v, want %v", tt.mode, got, tt.want)
+		}
+		checkBands(t, "Combine", r)
+	}
+
+	if r := a.Combine(b, 0); r != nil {
+		t.Errorf("Combine mode 0 = %v, want nil", r)
+	}
+}
+
+func TestRegionNormalize(t *testing.T) {
+	rect := NewRectRegion
+	tests := []struct {
+		name string
+		r    *Region
+		want []RECT
+	}{
+		{"stacked", rect(0, 0, 10, 5).Combine(rect(0, 5, 10, 10), RGN_OR), []RECT{{0, 0, 10, 10}}},
+		{"side by side", rect(0, 0, 5, 10).Combine(rect(5, 0, 10, 10), RGN_OR), []RECT{{0, 0, 10, 10}}},
+		{"notch", rect(0, 0, 10, 10).Combine(rect(3, 0, 6, 10), RGN_DIFF), []RECT{{0, 0, 3, 10}, {6, 0, 10, 10}}},
+		{"hole", rect(0, 0, 9, 9).Combine(rect(3, 3, 6, 6), RGN_XOR),
+			[]RECT{{0, 0, 9, 3}, {0, 3, 3, 6}, {6, 3, 9, 6}, {0, 6, 9, 9}}},
+		{"filled hole", rect(0, 0, 9, 9).Combine(rect(3, 3, 6, 6), RGN_DIFF).Combine(rect(3, 3, 6, 6), RGN_OR),
+			[]RECT{{0, 0, 9, 9}}},
+		{"self xor", rect(0, 0, 9, 9).Combine(rect(0, 0, 9, 9), RGN_XOR), nil},
+		{"disjoint and", rect(0, 0, 5, 5).Combine(rect(5, 5, 9, 9), RGN_AND), nil},
+		{"corners", rect(9, 9, 0, 0), []RECT{{0, 0, 9, 9}}},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Rects(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rects = %v, want %v", tt.name, got, tt.want)
+		}
+		checkBands(t, tt.name, tt.r)
+	}
+
+	if typ := rect(0, 0, 9, 9).Combine(rect(0, 0, 9, 9), RGN_XOR).Type(); typ != NULLREGION {
+		t.Errorf("Type of an empty region = %d, want NULLREGION", typ)
+	}
+}
+
+// TestRegionCombinePixels combines random regions and compares every
+// pixel with the operation, checking the result stays normalized.
+func TestRegionCombinePixels(t *testing.T) {
+	ops := map[int32]func(a, b bool) bool{
+		RGN_AND:  func(a, b bool) bool { return a && b },
+		RGN_OR:   func(a, b bool) bool { return a || b },
+		RGN_XOR:  func(a, b bool) bool { return a != b },
+		RGN_DIFF: func(a, b bool) bool { return a && !b },
+	}
+	rnd := rand.New(rand.NewSource(1))
+	random := func() *Region {
+		r := &Region{}
+		for i := rnd.Intn(5); i >= 0; i-- {
+			x, y := rnd.Int31n(12), rnd.Int31n(12)
+			r = r.Combine(NewRectRegion(x, y, x+1+rnd.Int31n(6), y+1+rnd.Int31n(6)), RGN_OR)
+		}
+		return r
+	}
+
+	for i := 0; i < 200; i++ {
+		a, b := random(), random()
+		for mode, op := range ops {
+			r := a.Combine(b, mode)
+			checkBands(t, "random", r)
+			for y := int32(-1); y < 19; y++ {
+				for x := int32(-1); x < 19; x++ {
+					if got, want := r.Contains(x, y), op(a.Contains(x, y), b.Contains(x, y)); got != want {
+						t.Fatalf("%v mode %d %v: pixel %d,%d = %v, want %v", a.rects, mode, b.rects, x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRegionData(t *testing.T) {
+	r := NewRectRegion(0, 0, 9, 9).Combine(NewRectRegion(3, 3, 6, 6), RGN_XOR).Offset(-2, 1)
+	b := r.Data()
+
+	le := binary.LittleEndian
+	header := []uint32{rgnDataHeaderSize, RDH_RECTANGLES, 4, 64}
+	for i, v := range header {
+		if got := le.Uint32(b[4*i:]); got != v {
+			t.Errorf("header field %d = %d, want %d", i, got, v)
+		}
+	}
+	if got := getRect(b[16:]); got != (RECT{-2, 1, 7, 10}) {
+		t.Errorf("bounds = %v, want {-2 1 7 10}", got)
+	}
+	if len(b) != rgnDataHeaderSize+64 {
+		t.Errorf("len = %d, want %d", len(b), rgnDataHeaderSize+64)
+	}
+
+	d, err := DecodeRegionData(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equal(r) || !bytes.Equal(d.Data(), b) {
+		t.Errorf("DecodeRegionData(Data()) = %v, want %v", d.rects, r.rects)
+	}
+
+	empty, err := DecodeRegionData((&Region{}).Data())
+	if err != nil || empty.Type() != NULLREGION {
+		t.Errorf("empty region round trip = %v, %v", empty, err)
+	}
+}
+
+func TestDecodeRegionDataUnordered(t *testing.T) {
+	// ExtCreateRegion accepts rectangles in any order, overlapping and
+	// empty; they are normalized.
+	rects := []RECT{{3, 3, 6, 9}, {0, 0, 6, 6}, {4, 4, 4, 8}, {0, 0, 3, 3}}
+	b := make([]byte, rgnDataHeaderSize+16*len(rects))
+	le := binary.LittleEndian
+	le.PutUint32(b, rgnDataHeaderSize)
+	le.PutUint32(b[4:], RDH_RECTANGLES)
+	le.PutUint32(b[8:], uint32(len(rects)))
+	for i, rc := range rects {
+		putRect(b[rgnDataHeaderSize+16*i:], rc)
+	}
+
+	r, err := DecodeRegionData(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []RECT{{0, 0, 6, 6}, {3, 6, 6, 9}}; !reflect.DeepEqual(r.Rects(), want) {
+		t.Errorf("rects = %v, want %v", r.Rects(), want)
+	}
+}
+
+func TestDecodeRegionDataInvalid(t *testing.T) {
+	valid := NewRectRegion(0, 0, 4, 4).Data()
+	edit := func(off int, v uint32) []byte {
+		b := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(b[off:], v)
+		return b
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"short header", valid[:rgnDataHeaderSize-1]},
+		{"missing rectangle", valid[:len(valid)-1]},
+		{"header size", edit(0, 16)},
+		{"type", edit(4, 2)},
+		{"count", edit(8, 1<<31)},
+		{"header past data", edit(0, 1<<31)},
+	}
+	for _, tt := range tests {
+		if r, err := DecodeRegionData(tt.data); err != ErrRegionDataInvalid {
+			t.Errorf("%s: DecodeRegionData = %v, %v, want ErrRegionDataInvalid", tt.name, r, err)
+		}
+	}
+}
+
+func TestExtCreateRegionShort(t *testing.T) {
+	for _, data := range [][]byte{nil, make([]byte, rgnDataHeaderSize-1)} {
+		if h := ExtCreateRegion(nil, data); h != 0 {
+			t.Errorf("ExtCreateRegion of %d bytes = %#x, want 0", len(data), h)
+		}
+		if err := LastError(); err != ErrRegionDataInvalid {
+			t.Errorf("ExtCreateRegion of %d bytes: LastError = %v, want ErrRegionDataInvalid", len(data), err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Ring is a single-producer/single-consumer message queue laid out in a
+// byte slice, normally the view of a Section shared by two processes.
+//
+// The slice starts with a 64 byte header holding a magic number, the
+// data capacity and two monotonically increasing byte counters: head,
+// advanced only by the producer, and tail, advanced only by the
+// consumer. Messages are stored after the header as a little-endian
+// uint32 length followed by the payload, wrapping around the end of the
+// data area.
+type Ring struct {
+	head *uint64
+	tail *uint64
+	data []byte
+}
+
+const (
+	ringMagic      = 0x474e5257 // "WRNG"
+	ringHeaderSize = 64
+	ringLenSize    = 4
+)
+
+var (
+	ErrRingFull    = errors.New("winapi: ring buffer full")
+	ErrRingEmpty   = errors.New("winapi: ring buffer empty")
+	ErrRingInvalid = errors.New("winapi: invalid ring buffer")
+)
+
+// NewRing initializes buf as an empty ring. Only one side, usually the
+// creator of the section, should call it; the other side uses
+// AttachRing.
+func NewRing(buf []byte) (*Ring, error) {
+	if len(buf) <= ringHeaderSize+ringLenSize || uintptr(unsafe.Pointer(&buf[0]))%8 != 0 {
+		return nil, ErrRingInvalid
+	}
+
+	magic := (*uint32)(unsafe.Pointer(&buf[0]))
+	atomic.StoreUint32(magic, 0)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(len(buf)-ringHeaderSize))
+	r := newRing(buf)
+	atomic.StoreUint64(r.head, 0)
+	atomic.StoreUint64(r.tail, 0)
+	atomic.StoreUint32(magic, ringMagic)
+
+	return r, nil
+}
+
+// AttachRing uses a ring previously initialized by NewRing.
+func AttachRing(buf []byte) (*Ring, error) {
+	if len(buf) <= ringHeaderSize+ringLenSize || uintptr(unsafe.Pointer(&buf[0]))%8 != 0 {
+		return nil, ErrRingInvalid
+	}
+	if atomic.LoadUint32((*uint32)(unsafe.Pointer(&buf[0]))) != ringMagic {
+		return nil, ErrRingInvalid
+	}
+	if binary.LittleEndian.Uint64(buf[8:]) != uint64(len(buf)-ringHeaderSize) {
+		return nil, ErrRingInvalid
+	}
+
+	return newRing(buf), nil
+}
+
+func newRing(buf []byte) *Ring {
+	return &Ring{
+		head: (*uint64)(unsafe.Pointer(&buf[16])),
+		tail: (*uint64)(unsafe.Pointer(&buf[24])),
+		data: buf[ringHeaderSize:],
+	}
+}
+
+// Cap returns the size of the largest message the ring can hold.
+func (r *Ring) Cap() int {
+	return len(r.data) - ringLenSize
+}
+
+// Buffered returns the number of bytes, including framing, waiting to be
+// read.
+func (r *Ring) Buffered() int {
+	return int(atomic.LoadUint64(r.head) - atomic.LoadUint64(r.tail))
+}
+
+// Write appends msg as one message. It returns ErrRingFull without
+// writing anything if there is not enough free space. Only the producer
+// may call Write.
+func (r *Ring) Write(msg []byte) error {
+	n := uint64(ringLenSize + len(msg))
+	if n > uint64(len(r.data)) {
+		return io.ErrShortBuffer
+	}
+
+	head := atomic.LoadUint64(r.head)
+	tail := atomic.LoadUint64(r.tail)
+	if uint64(len(r.data))-(head-tail) < n {
+		return ErrRingFull
+	}
+
+	var size [ringLenSize]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(msg)))
+	r.copyIn(head, size[:])
+	r.copyIn(head+ringLenSize, msg)
+	atomic.StoreUint64(r.head, head+n)
+
+	return nil
+}
+
+// Read copies the next message into p and returns its length. It
+// returns ErrRingEmpty if no message is waiting and io.ErrShortBuffer,
+// leaving the message queued, if p is too small. Only the consumer may
+// call Read.
+//
+// The counters and lengths come from memory the producer can write, so
+// Read returns ErrRingInvalid, consuming nothing, if they describe a
+// message extending past the written data.
+func (r *Ring) Read(p []byte) (int, error) {
+	tail := atomic.LoadUint64(r.tail)
+	head := atomic.LoadUint64(r.head)
+	if head == tail {
+		return 0, ErrRingEmpty
+	}
+
+	avail := head - tail
+	if avail < ringLenSize || avail > uint64(len(r.data)) {
+		return 0, ErrRingInvalid
+	}
+
+	var size [ringLenSize]byte
+	r.copyOut(tail, size[:])
+	n := int(binary.LittleEndian.Uint32(size[:]))
+	if uint64(n) > avail-ringLenSize {
+		return 0, ErrRingInvalid
+	}
+	if n > len(p) {
+		return n, io.ErrShortBuffer
+	}
+
+	r.copyOut(tail+ringLenSize, p[:n])
+	atomic.StoreUint64(r.tail, tail+uint64(ringLenSize+n))
+
+	return n, nil
+}
+
+// Next returns the next message in a newly allocated slice.
+func (r *Ring) Next() ([]byte, error) {
+	n, err := r.Read(nil)
+	if err != io.ErrShortBuffer {
+		return nil, err
+	}
+
+	msg := make([]byte, n)
+	_, err = r.Read(msg)
+
+	return msg, err
+}
+
+func (r *Ring) copyIn(pos uint64, b []byte) {
+	i := int(pos % uint64(len(r.data)))
+	n := copy(r.data[i:], b)
+	copy(r.data, b[n:])
+}
+
+func (r *Ring) copyOut(pos uint64, b []byte) {
+	i := int(pos % uint64(len(r.data)))
+	n := copy(b, r.data[i:])
+	copy(b[n:], r.data)
+}
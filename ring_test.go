@@ -0,0 +1,55 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"testing"
+	"unsafe"
+)
+
+// ringBuffer returns an 8 byte aligned buffer of n bytes.
+func ringBuffer(n int) []byte {
+	words := make([]uint64, (n+7)/8)
+
+	return unsafe.Slice((*byte)(unsafe.Pointer(&words[0])), n)
+}
+
+func TestRingAttach(t *testing.T) {
+	buf := ringBuffer(ringHeaderSize + 16)
+	w, err := NewRing(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := AttachRing(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 16)
+	if n, err := r.Read(p); err != nil || string(p[:n]) != "hello" {
+		t.Errorf("Read = %q, %v, want hello", p[:n], err)
+	}
+
+	// Buffers too small for a length prefix are rejected by both sides,
+	// even with a valid looking header.
+	for _, n := range []int{ringHeaderSize, ringHeaderSize + ringLenSize} {
+		small := ringBuffer(n + 8)[:n]
+		if _, err := NewRing(small); err != ErrRingInvalid {
+			t.Errorf("NewRing of %d bytes: %v, want ErrRingInvalid", n, err)
+		}
+		binary.LittleEndian.PutUint32(small, ringMagic)
+		binary.LittleEndian.PutUint64(small[8:], uint64(n-ringHeaderSize))
+		if _, err := AttachRing(small); err != ErrRingInvalid {
+			t.Errorf("AttachRing of %d bytes: %v, want ErrRingInvalid", n, err)
+		}
+	}
+
+	if _, err := AttachRing(ringBuffer(ringHeaderSize + 16)); err != ErrRingInvalid {
+		t.Errorf("AttachRing of an uninitialized buffer: %v, want ErrRingInvalid", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+// Section is a named shared memory section backed by the paging file,
+// mapped into the address space of the current process.
+type Section struct {
+	h    HANDLE
+	addr uintptr
+	data []byte
+}
+
+// CreateSection creates a section of size bytes and maps all of it
+// read-write. If a section with the same name already exists it is
+// opened instead and size must not exceed its size.
+func CreateSection(name string, size int) (*Section, error) {
+	h := CreateFileMapping(INVALID_HANDLE_VALUE, nil, PAGE_READWRITE,
+		uint64(size), name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return mapSection(h, FILE_MAP_ALL_ACCESS, size)
+}
+
+// OpenSection opens an existing named section and maps its first size
+// bytes.
+func OpenSection(name string, size int, writable bool) (*Section, error) {
+	var access DWORD = FILE_MAP_READ
+	if writable {
+		access |= FILE_MAP_WRITE
+	}
+
+	h := OpenFileMapping(access, false, name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return mapSection(h, access, size)
+}
+
+func mapSection(h HANDLE, access DWORD, size int) (*Section, error) {
+	addr := MapViewOfFile(h, access, 0, uintptr(size))
+	if addr == 0 {
+		err := lastError
+		CloseHandle(h)
+		return nil, err
+	}
+
+	return &Section{h: h, addr: addr, data: bytesAt(addr, size)}, nil
+}
+
+// Bytes returns the mapped view. The slice must not be used after Close.
+func (s *Section) Bytes() []byte {
+	return s.data
+}
+
+func (s *Section) Handle() HANDLE {
+	return s.h
+}
+
+// Flush writes the dirty pages of the view back to the section.
+func (s *Section) Flush() error {
+	if !FlushViewOfFile(s.addr, 0) {
+		return lastError
+	}
+
+	return nil
+}
+
+// Close unmaps the view and closes the section handle.
+func (s *Section) Close() error {
+	if s.addr != 0 {
+		if !UnmapViewOfFile(s.addr) {
+			return lastError
+		}
+		s.addr = 0
+		s.data = nil
+	}
+
+	return closeObject(&s.h)
+}
@@ -0,0 +1,824 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// SoftDC is a device context that renders into an image.RGBA in Go, so
+// drawing code can run and be checked pixel for pixel without a Windows
+// display. Its handle, returned by HDC, is accepted by the drawing
+// functions of this package in place of a real device context.
+//
+// Lines are drawn with a one pixel cosmetic pen, excluding their last
+// pixel, and polygons are filled using GDI's rules for which pixels on
+// the edges are inside.
+type SoftDC struct {
+	hdc       HDC
+	img       *image.RGBA
+	pos       POINT
+	pen       SoftPen
+	brush     SoftBrush
+	textColor COLORREF
+	bkColor   COLORREF
+	bkMode    int32
+	fillMode  int32
+	stretch   int32
+	face      SoftFace
+	xform     *Transform
+
+	// The path in device space, recording between BeginPath and EndPath
+	// while pathOpen is set.
+	path     *Path
+	pathOpen bool
+
+	// Objects selected with SelectObject, or 0 for the initial pen and
+	// brush and those set with SelectPen and SelectBrush.
+	penObj   HGDIOBJ
+	brushObj HGDIOBJ
+	palette  HPALETTE
+
+	// The states pushed by SaveDC.
+	saved []SoftDC
+}
+
+// SoftPen and SoftBrush describe the pen and brush of a SoftDC. A null
+// pen or brush draws nothing.
+type SoftPen struct {
+	Color COLORREF
+	Null  bool
+}
+
+type SoftBrush struct {
+	Color COLORREF
+	Null  bool
+}
+
+// SoftFace supplies the glyphs TextOut draws on a SoftDC.
+type SoftFace interface {
+	// Glyph returns the coverage mask of r, positioned relative to the
+	// top left corner of its character cell, and its advance width. A
+	// nil mask draws nothing.
+	Glyph(r rune) (mask image.Image, advance int)
+	// Height returns the height of a character cell.
+	Height() int
+}
+
+// Soft DC handles must never equal the handle of a real device
+// context. GDI handles are 32 bit values, sign-extended on 64 bit
+// Windows, so there soft DC handles are counted up from softDCBase,
+// far above 1<<32. On 32 bit Windows any value may be a handle, so each
+// SoftDC instead takes the handle of a memory DC it keeps until it is
+// closed. Other systems have no real handles to collide with.
+const (
+	softDCBase    = HDC(^uintptr(0)>>1) &^ 0xFFFFF
+	softDCCounted = unsafe.Sizeof(uintptr(0)) == 8 || runtime.GOOS != "windows"
+)
+
+var (
+	softDCMutex sync.Mutex
+	softDCs     = map[HDC]*SoftDC{}
+	softDCLast  = softDCBase
+)
+
+// NewSoftDC returns a device context drawing into img, with device
+// point (0, 0) at img.Bounds().Min. Like a new DC it has a black pen, a
+// white brush, black text on an opaque white background, the ALTERNATE
+// fill mode, the BLACKONWHITE stretch mode and MM_TEXT mapping on a 96
+// DPI device. It must be closed to release its handle. On 32 bit
+// Windows it returns nil if no handle can be reserved.
+func NewSoftDC(img *image.RGBA) *SoftDC {
+	var hdc HDC
+	if !softDCCounted {
+		if hdc = CreateCompatibleDC(0); hdc == 0 {
+			return nil
+		}
+	}
+
+	softDCMutex.Lock()
+	defer softDCMutex.Unlock()
+
+	if softDCCounted {
+		softDCLast++
+		hdc = softDCLast
+	}
+
+	w, h := int32(img.Bounds().Dx()), int32(img.Bounds().Dy())
+	dc := &SoftDC{
+		hdc:       hdc,
+		img:       img,
+		pen:       SoftPen{Color: RGB(0, 0, 0)},
+		brush:     SoftBrush{Color: RGB(255, 255, 255)},
+		textColor: RGB(0, 0, 0),
+		bkColor:   RGB(255, 255, 255),
+		bkMode:    OPAQUE,
+		fillMode:  ALTERNATE,
+		stretch:   BLACKONWHITE,
+		xform:     NewTransform(w, h, mulDiv(w, 254, softDCDPI*10), mulDiv(h, 254, softDCDPI*10)),
+	}
+	softDCs[dc.hdc] = dc
+
+	return dc
+}
+
+// lookupSoftDC returns the SoftDC with handle hdc, or nil for a real
+// device context.
+func lookupSoftDC(hdc HDC) *SoftDC {
+	if softDCCounted && hdc <= softDCBase {
+		return nil
+	}
+
+	softDCMutex.Lock()
+	defer softDCMutex.Unlock()
+
+	return softDCs[hdc]
+}
+
+func (dc *SoftDC) HDC() HDC {
+	return dc.hdc
+}
+
+func (dc *SoftDC) Image() *image.RGBA {
+	return dc.img
+}
+
+// Close releases the handle of dc.
+func (dc *SoftDC) Close() {
+	softDCMutex.Lock()
+	defer softDCMutex.Unlock()
+
+	if softDCs[dc.hdc] != dc {
+		return
+	}
+	delete(softDCs, dc.hdc)
+	if !softDCCounted {
+		procDeleteDC.Call(uintptr(dc.hdc))
+	}
+}
+
+// SelectPen sets the pen and returns the previous one.
+func (dc *SoftDC) SelectPen(p SoftPen) SoftPen {
+	old := dc.pen
+	dc.pen = p
+	dc.penObj = 0
+
+	return old
+}
+
+// SelectBrush sets the brush and returns the previous one.
+func (dc *SoftDC) SelectBrush(b SoftBrush) SoftBrush {
+	old := dc.brush
+	dc.brush = b
+	dc.brushObj = 0
+
+	return old
+}
+
+// selectObject selects a pen or brush and returns the previous one and
+// a function reinstating it, or 0 and nil if obj cannot be selected.
+// The initial pen and brush and those set with SelectPen and
+// SelectBrush are not GDI objects, so for them the stock BLACK_PEN or
+// WHITE_BRUSH a new device context starts with is returned; the
+// function still reinstates the exact pen or brush.
+func (dc *SoftDC) selectObject(obj HGDIOBJ) (HGDIOBJ, func()) {
+	switch GetObjectType(obj) {
+	case OBJ_PEN, OBJ_EXTPEN:
+		lp, err := GetObjectPen(HPEN(obj))
+		if err != nil {
+			return 0, nil
+		}
+		pen, penObj := dc.pen, dc.penObj
+		dc.pen = SoftPen{Color: lp.ElpColor, Null: lp.ElpPenStyle&PS_STYLE_MASK == PS_NULL}
+		dc.penObj = obj
+		restore := func() { dc.pen, dc.penObj = pen, penObj }
+		if penObj == 0 {
+			return GetStockObject(BLACK_PEN), restore
+		}
+		return penObj, restore
+	case OBJ_BRUSH:
+		lb, err := GetObjectBrush(HBRUSH(obj))
+		if err != nil {
+			return 0, nil
+		}
+		brush, brushObj := dc.brush, dc.brushObj
+		dc.brush = SoftBrush{Color: lb.LbColor, Null: lb.LbStyle == BS_NULL}
+		dc.brushObj = obj
+		restore := func() { dc.brush, dc.brushObj = brush, brushObj }
+		if brushObj == 0 {
+			return GetStockObject(WHITE_BRUSH), restore
+		}
+		return brushObj, restore
+	}
+
+	return 0, nil
+}
+
+// SetFace sets the glyphs used by TextOut.
+func (dc *SoftDC) SetFace(f SoftFace) {
+	dc.face = f
+}
+
+const softDCDPI = 96
+
+func (dc *SoftDC) deviceCaps(index int32) int32 {
+	switch index {
+	case TECHNOLOGY:
+		return DT_RASDISPLAY
+	case HORZSIZE:
+		return dc.xform.size.Cx
+	case VERTSIZE:
+		return dc.xform.size.Cy
+	case HORZRES:
+		return dc.xform.res.Cx
+	case VERTRES:
+		return dc.xform.res.Cy
+	case BITSPIXEL:
+		return 32
+	case PLANES:
+		return 1
+	case LOGPIXELSX, LOGPIXELSY:
+		return softDCDPI
+	}
+
+	return 0
+}
+
+// toDevice returns a copy of the logical points pts in device space.
+func (dc *SoftDC) toDevice(pts ...POINT) []POINT {
+	dev := append([]POINT(nil), pts...)
+	dc.xform.LPtoDP(dev)
+
+	return dev
+}
+
+func (dc *SoftDC) moveTo(x, y int32, old *POINT) bool {
+	if old != nil {
+		*old = dc.pos
+	}
+	dc.pos = POINT{x, y}
+	if dc.pathOpen {
+		dev := dc.toDevice(dc.pos)[0]
+		dc.path.MoveTo(dev.X, dev.Y)
+	}
+
+	return true
+}
+
+func (dc *SoftDC) lineTo(x, y int32) bool {
+	dev := dc.toDevice(dc.pos, POINT{x, y})
+	if dc.pathOpen {
+		dc.path.LineTo(dev[1].X, dev[1].Y)
+	} else {
+		dc.line(dev[0], dev[1])
+	}
+	dc.pos = POINT{x, y}
+
+	return true
+}
+
+func (dc *SoftDC) polyline(pts []POINT) bool {
+	if len(pts) < 2 {
+		return false
+	}
+
+	if dc.pathOpen {
+		return dc.path.Polyline(dc.toDevice(pts...))
+	}
+	dc.deviceLines(dc.toDevice(pts...))
+
+	return true
+}
+
+func (dc *SoftDC) deviceLines(pts []POINT) {
+	for i := 1; i < len(pts); i++ {
+		dc.line(pts[i-1], pts[i])
+	}
+}
+
+func (dc *SoftDC) polylineTo(pts []POINT) bool {
+	for _, p := range pts {
+		dc.lineTo(p.X, p.Y)
+	}
+
+	return true
+}
+
+func (dc *SoftDC) polygon(pts []POINT) bool {
+	if len(pts) < 2 {
+		return false
+	}
+
+	pts = dc.toDevice(pts...)
+	if dc.pathOpen {
+		return dc.path.Polygon(pts)
+	}
+	dc.fill([][]POINT{pts})
+	dc.closedLines(pts)
+
+	return true
+}
+
+// deviceBox returns the corners of the logical rectangle l, t, r, b in
+// device space, excluding its right and bottom edges as Rectangle and
+// Ellipse do.
+func (dc *SoftDC) deviceBox(l, t, r, b int32) RECT {
+	p := dc.toDevice(POINT{l, t}, POINT{r, b})
+	rc := RECT{min(p[0].X, p[1].X), min(p[0].Y, p[1].Y), max(p[0].X, p[1].X), max(p[0].Y, p[1].Y)}
+	rc.Right--
+	rc.Bottom--
+
+	return rc
+}
+
+func (dc *SoftDC) rectangle(l, t, r, b int32) bool {
+	rc := dc.deviceBox(l, t, r, b)
+	pts := []POINT{{rc.Left, rc.Top}, {rc.Right, rc.Top}, {rc.Right, rc.Bottom}, {rc.Left, rc.Bottom}}
+	if dc.pathOpen {
+		return dc.path.Polygon(pts)
+	}
+	dc.fill([][]POINT{pts})
+	dc.closedLines(pts)
+
+	return true
+}
+
+// ellipse draws the ellipse inscribed in the rectangle as four Bézier
+// curves.
+func (dc *SoftDC) ellipse(l, t, r, b int32) bool {
+	rc := dc.deviceBox(l, t, r, b)
+
+	const kappa = 0.5522847498
+	cx, cy := float64(rc.Left+rc.Right)/2, float64(rc.Top+rc.Bottom)/2
+	kx, ky := kappa*float64(rc.Right-rc.Left)/2, kappa*float64(rc.Bottom-rc.Top)/2
+	pt := func(x, y float64) POINT { return POINT{gdiRound(x), gdiRound(y)} }
+	l, t, r, b = rc.Left, rc.Top, rc.Right, rc.Bottom
+	ctl := []POINT{
+		{r, gdiRound(cy)},
+		pt(float64(r), cy-ky), pt(cx+kx, float64(t)), pt(cx, float64(t)),
+		pt(cx-kx, float64(t)), pt(float64(l), cy-ky), pt(float64(l), cy),
+		pt(float64(l), cy+ky), pt(cx-kx, float64(b)), pt(cx, float64(b)),
+		pt(cx+kx, float64(b)), pt(float64(r), cy+ky), {r, gdiRound(cy)},
+	}
+	if dc.pathOpen {
+		dc.path.PolyBezier(ctl)
+		dc.path.CloseFigure()
+		return true
+	}
+
+	pts := flattenBezier(ctl)
+	dc.fill([][]POINT{pts})
+	dc.closedLines(pts)
+
+	return true
+}
+
+// fill fills the device space polygons polys with the brush.
+func (dc *SoftDC) fill(polys [][]POINT) {
+	if dc.brush.Null {
+		return
+	}
+
+	c := colorRGBA(dc.brush.Color)
+	polygonSpans(polys, dc.fillMode, func(y, x0, x1 int32) {
+		for x := x0; x < x1; x++ {
+			dc.set(x, y, c)
+		}
+	})
+}
+
+// closedLines draws the outline of a device space polygon.
+func (dc *SoftDC) closedLines(pts []POINT) {
+	for i, p := range pts {
+		dc.line(p, pts[(i+1)%len(pts)])
+	}
+}
+
+func (dc *SoftDC) polyBezier(pts []POINT) bool {
+	if dc.pathOpen {
+		return dc.path.PolyBezier(dc.toDevice(pts...))
+	}
+
+	flat := flattenBezier(dc.toDevice(pts...))
+	if flat == nil {
+		return false
+	}
+	dc.deviceLines(flat)
+
+	return true
+}
+
+func (dc *SoftDC) polyBezierTo(pts []POINT) bool {
+	if dc.pathOpen {
+		if !dc.path.PolyBezierTo(dc.toDevice(pts...)) {
+			return false
+		}
+		dc.pos = pts[len(pts)-1]
+		return true
+	}
+
+	flat := flattenBezier(dc.toDevice(append([]POINT{dc.pos}, pts...)...))
+	if flat == nil {
+		return false
+	}
+	dc.deviceLines(flat)
+	dc.pos = pts[len(pts)-1]
+
+	return true
+}
+
+func (dc *SoftDC) beginPath() bool {
+	dc.path = &Path{}
+	dc.pathOpen = true
+	dev := dc.toDevice(dc.pos)[0]
+	dc.path.MoveTo(dev.X, dev.Y)
+
+	return true
+}
+
+func (dc *SoftDC) endPath() bool {
+	if !dc.pathOpen {
+		return false
+	}
+	dc.pathOpen = false
+
+	return true
+}
+
+func (dc *SoftDC) abortPath() bool {
+	dc.path = nil
+	dc.pathOpen = false
+
+	return true
+}
+
+func (dc *SoftDC) closeFigure() bool {
+	if !dc.pathOpen {
+		return false
+	}
+	dc.path.CloseFigure()
+
+	return true
+}
+
+// closedPath returns the path completed by EndPath, or nil.
+func (dc *SoftDC) closedPath() *Path {
+	if dc.pathOpen {
+		return nil
+	}
+
+	return dc.path
+}
+
+func (dc *SoftDC) flattenPath() bool {
+	p := dc.closedPath()
+	if p == nil {
+		return false
+	}
+	dc.path = p.Flatten()
+
+	return true
+}
+
+// strokeAndFillPath draws and discards the path. Filling closes every
+// figure; stroking only those closed with CloseFigure.
+func (dc *SoftDC) strokeAndFillPath(stroke, fill bool) bool {
+	p := dc.closedPath()
+	if p == nil {
+		return false
+	}
+	dc.path = nil
+
+	if fill {
+		dc.fill(p.Polygons())
+	}
+	if stroke {
+		for _, f := range p.figures() {
+			if f.closed {
+				dc.closedLines(f.pts)
+			} else {
+				dc.deviceLines(f.pts)
+			}
+		}
+	}
+
+	return true
+}
+
+// getPath returns the path in logical coordinates.
+func (dc *SoftDC) getPath() ([]POINT, []byte) {
+	p := dc.closedPath()
+	if p == nil {
+		return nil, nil
+	}
+
+	pts, types := p.Points()
+	dc.xform.DPtoLP(pts)
+
+	return pts, types
+}
+
+// pathRegion returns and discards the area of the path.
+func (dc *SoftDC) pathRegion() *Region {
+	p := dc.closedPath()
+	if p == nil {
+		return nil
+	}
+	dc.path = nil
+
+	return p.Region(dc.fillMode)
+}
+
+// extTextOut draws s with the top left corner of its first character
+// cell at x, y, as ExtTextOut does. Glyphs are not scaled by the
+// mapping mode, and glyph indexes are not supported.
+func (dc *SoftDC) extTextOut(x, y int32, options UINT, rc *RECT, s string, dx []int32) bool {
+	if dc.face == nil || options&ETO_GLYPH_INDEX != 0 {
+		return false
+	}
+
+	min := dc.img.Bounds().Min
+	dst := dc.img
+	if rc != nil && options&(ETO_OPAQUE|ETO_CLIPPED) != 0 {
+		r, _, _ := dc.deviceRect(rc.Left, rc.Top, rc.Right-rc.Left, rc.Bottom-rc.Top)
+		if options&ETO_OPAQUE != 0 {
+			draw.Draw(dc.img, r, image.NewUniform(colorRGBA(dc.bkColor)), image.Point{}, draw.Src)
+		}
+		if options&ETO_CLIPPED != 0 {
+			dst = dc.img.SubImage(r).(*image.RGBA)
+		}
+	}
+
+	type placed struct {
+		mask image.Image
+		at   image.Point
+	}
+	var glyphs []placed
+
+	org := dc.toDevice(POINT{x, y})[0]
+	start := image.Pt(int(org.X), int(org.Y)).Add(min)
+	pen := start
+	unit := 0
+	for _, r := range s {
+		mask, adv := dc.face.Glyph(r)
+		glyphs = append(glyphs, placed{mask, pen})
+
+		n := 1
+		if r >= 0x10000 {
+			n = 2
+		}
+		switch {
+		case dx == nil:
+			pen.X += adv
+		case options&ETO_PDY != 0:
+			for i := unit; i < unit+n; i++ {
+				pen.X += int(dx[2*i])
+				pen.Y -= int(dx[2*i+1])
+			}
+		default:
+			for i := unit; i < unit+n; i++ {
+				pen.X += int(dx[i])
+			}
+		}
+		unit += n
+	}
+
+	if dc.bkMode == OPAQUE {
+		r := image.Rect(start.X, start.Y, pen.X, start.Y+dc.face.Height())
+		draw.Draw(dst, r, image.NewUniform(colorRGBA(dc.bkColor)), image.Point{}, draw.Src)
+	}
+
+	fg := image.NewUniform(colorRGBA(dc.textColor))
+	for _, g := range glyphs {
+		if g.mask != nil {
+			b := g.mask.Bounds()
+			draw.DrawMask(dst, b.Add(g.at), fg, image.Point{}, g.mask, b.Min, draw.Over)
+		}
+	}
+
+	return true
+}
+
+// deviceRect returns the logical rectangle at x, y of size w, h in
+// image coordinates, and whether the mapping mirrors it.
+func (dc *SoftDC) deviceRect(x, y, w, h int32) (r image.Rectangle, flipX, flipY bool) {
+	p := dc.toDevice(POINT{x, y}, POINT{x + w, y + h})
+	r = image.Rect(int(p[0].X), int(p[0].Y), int(p[1].X), int(p[1].Y)).Add(dc.img.Bounds().Min)
+
+	return r, p[1].X < p[0].X, p[1].Y < p[0].Y
+}
+
+// pattern returns the brush as the pattern of a raster operation.
+func (dc *SoftDC) pattern() image.Image {
+	if dc.brush.Null {
+		return nil
+	}
+
+	return image.NewUniform(colorRGBA(dc.brush.Color))
+}
+
+// source returns the rectangle of src scaled to the size of dr, mirrored
+// as the two rectangles require, or nil if src is nil.
+func (dc *SoftDC) source(dr image.Rectangle, flipX, flipY bool, src *SoftDC, x, y, w, h int32, mode int32) image.Image {
+	if src == nil {
+		return nil
+	}
+
+	sr, sx, sy := src.deviceRect(x, y, w, h)
+
+	return stretchRGBA(src.img, sr, dr.Dx(), dr.Dy(), mode, flipX != sx, flipY != sy)
+}
+
+func (dc *SoftDC) stretchBlt(x, y, w, h int32, src *SoftDC, xSrc, ySrc, wSrc, hSrc int32, rop DWORD) bool {
+	return dc.blt(x, y, w, h, src, xSrc, ySrc, wSrc, hSrc, nil, image.Point{}, MAKEROP4(rop, rop))
+}
+
+func (dc *SoftDC) maskBlt(x, y, w, h int32, src *SoftDC, xSrc, ySrc int32, mask HBITMAP, xMask, yMask int32, rop DWORD) bool {
+	if mask == 0 {
+		return dc.stretchBlt(x, y, w, h, src, xSrc, ySrc, w, h, rop)
+	}
+
+	img, err := ImageFromHBITMAP(mask)
+	if err != nil {
+		return false
+	}
+
+	return dc.blt(x, y, w, h, src, xSrc, ySrc, w, h, img, image.Pt(int(xMask), int(yMask)), rop)
+}
+
+// blt applies the foreground and background raster operations of rop,
+// as MaskBltImage does.
+func (dc *SoftDC) blt(x, y, w, h int32, src *SoftDC, xSrc, ySrc, wSrc, hSrc int32, mask image.Image, mp image.Point, rop DWORD) bool {
+	if !ropUsesSource(rop) && !ropUsesSource(rop>>8) {
+		src = nil
+	} else if src == nil {
+		return false
+	}
+
+	dr, flipX, flipY := dc.deviceRect(x, y, w, h)
+	scaled := dc.source(dr, flipX, flipY, src, xSrc, ySrc, wSrc, hSrc, dc.stretch)
+	MaskBltImage(dc.img, dr, scaled, image.Point{}, mask, mp, dc.pattern(), rop)
+
+	return true
+}
+
+func (dc *SoftDC) transparentBlt(x, y, w, h int32, src *SoftDC, xSrc, ySrc, wSrc, hSrc int32, transparent COLORREF) bool {
+	if src == nil {
+		return false
+	}
+
+	dr, flipX, flipY := dc.deviceRect(x, y, w, h)
+	scaled := dc.source(dr, flipX, flipY, src, xSrc, ySrc, wSrc, hSrc, COLORONCOLOR)
+	TransparentBltImage(dc.img, dr, scaled, scaled.Bounds(), transparent)
+
+	return true
+}
+
+func (dc *SoftDC) alphaBlend(x, y, w, h int32, src *SoftDC, xSrc, ySrc, wSrc, hSrc int32, bf BLENDFUNCTION) bool {
+	if src == nil || bf.BlendOp != AC_SRC_OVER {
+		return false
+	}
+
+	dr, flipX, flipY := dc.deviceRect(x, y, w, h)
+	scaled := dc.source(dr, flipX, flipY, src, xSrc, ySrc, wSrc, hSrc, COLORONCOLOR)
+	AlphaBlendImage(dc.img, dr, scaled, scaled.Bounds(), bf)
+
+	return true
+}
+
+// stretchDIBits draws the formats DecodeDIB supports, taking the color
+// masks of BI_BITFIELDS DIBs from masks. The source rectangle is
+// measured from the bottom left corner of bottom-up DIBs.
+func (dc *SoftDC) stretchDIBits(x, y, w, h, xSrc, ySrc, wSrc, hSrc int32, bits []byte, hdr *BITMAPINFOHEADER, masks [3]DWORD, rop DWORD) int32 {
+	v5 := V5Header(hdr, masks[0], masks[1], masks[2], 0)
+	img, err := DecodeDIB(&v5, bits)
+	if err != nil {
+		return 0
+	}
+
+	if hdr.BiHeight > 0 {
+		ySrc = hdr.BiHeight - ySrc - hSrc
+	}
+	sr := image.Rect(int(xSrc), int(ySrc), int(xSrc+wSrc), int(ySrc+hSrc))
+	flipSrcX, flipSrcY := wSrc < 0, hSrc < 0
+
+	dr, flipX, flipY := dc.deviceRect(x, y, w, h)
+	scaled := stretchRGBA(img, sr, dr.Dx(), dr.Dy(), dc.stretch, flipX != flipSrcX, flipY != flipSrcY)
+	BltImage(dc.img, dr, scaled, image.Point{}, dc.pattern(), rop)
+
+	return int32(sr.Dy())
+}
+
+// saveDC pushes the state of dc, including its path.
+func (dc *SoftDC) saveDC() int32 {
+	s := *dc
+	x := *dc.xform
+	s.xform = &x
+	if dc.path != nil {
+		s.path = &Path{}
+		*s.path = *dc.path
+		s.path.pts = append([]POINT(nil), dc.path.pts...)
+		s.path.types = append([]byte(nil), dc.path.types...)
+	}
+	s.saved = nil
+	dc.saved = append(dc.saved, s)
+
+	return int32(len(dc.saved))
+}
+
+// restoreDC pops states up to the one saved as number n, or with a
+// negative n, -n states back.
+func (dc *SoftDC) restoreDC(n int32) bool {
+	if n < 0 {
+		n += int32(len(dc.saved)) + 1
+	}
+	if n < 1 || n > int32(len(dc.saved)) {
+		return false
+	}
+
+	s := dc.saved[n-1]
+	s.saved = dc.saved[:n-1]
+	*dc = s
+
+	return true
+}
+
+// drawText draws text as DrawTextEx does, measuring it with the face.
+func (dc *SoftDC) drawText(text string, rc *RECT, format UINT, dtp *DRAWTEXTPARAMS) int32 {
+	if dc.face == nil {
+		return 0
+	}
+
+	lay := LayoutText(text, *rc, format, dtp, dc.textMeasure())
+	if dtp != nil {
+		dtp.UiLengthDrawn = UINT(lay.Drawn)
+	}
+	if format&DT_CALCRECT != 0 {
+		*rc = lay.Rect
+		return lay.Height
+	}
+
+	var options UINT = ETO_CLIPPED
+	if format&DT_NOCLIP != 0 {
+		options = 0
+	}
+	c := colorRGBA(dc.textColor)
+	for _, l := range lay.Lines {
+		if format&DT_PREFIXONLY == 0 {
+			dc.extTextOut(l.X, l.Y, options, rc, l.Text, l.Dx)
+		}
+		if l.Prefix < 0 {
+			continue
+		}
+
+		x := l.X
+		for _, w := range l.Dx[:l.Prefix] {
+			x += w
+		}
+		y := l.Y + int32(dc.face.Height()) - 1
+		dev := dc.toDevice(POINT{x, y}, POINT{x + l.Dx[l.Prefix], y})
+		bresenham(dev[0], dev[1], func(x, y int32) {
+			dc.set(x, y, c)
+		})
+	}
+
+	return lay.Height
+}
+
+// textMeasure measures text with the face, taking the width of 'x' as
+// the average character width.
+func (dc *SoftDC) textMeasure() TextMeasure {
+	advance := func(r rune) int32 {
+		_, adv := dc.face.Glyph(r)
+		return int32(adv)
+	}
+
+	return TextMeasure{Advance: advance, Height: int32(dc.face.Height()), AveCharWidth: advance('x')}
+}
+
+func (dc *SoftDC) line(p, q POINT) {
+	if dc.pen.Null {
+		return
+	}
+
+	c := colorRGBA(dc.pen.Color)
+	bresenham(p, q, func(x, y int32) {
+		dc.set(x, y, c)
+	})
+}
+
+func (dc *SoftDC) set(x, y int32, c color.RGBA) {
+	min := dc.img.Bounds().Min
+	dc.img.SetRGBA(min.X+int(x), min.Y+int(y), c)
+}
+
+func colorRGBA(c COLORREF) color.RGBA {
+	return color.RGBA{c.GetRValue(), c.GetGValue(), c.GetBValue(), 0xFF}
+}
@@ -0,0 +1,229 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+// softDCPicture returns the pixels of dc as rows of '#' for black, '.'
+// for white, 'o' for gray and '?' for any other color.
+func softDCPicture(dc *SoftDC) []string {
+	img := dc.Image()
+	b := img.Bounds()
+	var rows []string
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := make([]byte, 0, b.Dx())
+		for x := b.Min.X; x < b.Max.X; x++ {
+			switch img.RGBAAt(x, y) {
+			case color.RGBA{0, 0, 0, 0xFF}:
+				row = append(row, '#')
+			case color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}:
+				row = append(row, '.')
+			case color.RGBA{0x80, 0x80, 0x80, 0xFF}:
+				row = append(row, 'o')
+			default:
+				row = append(row, '?')
+			}
+		}
+		rows = append(rows, string(row))
+	}
+
+	return rows
+}
+
+func checkSoftDCPicture(t *testing.T, dc *SoftDC, want []string) {
+	t.Helper()
+
+	if got := softDCPicture(dc); !reflect.DeepEqual(got, want) {
+		t.Errorf("got picture")
+		for _, row := range got {
+			t.Errorf("\t%s", row)
+		}
+		t.Errorf("want")
+		for _, row := range want {
+			t.Errorf("\t%s", row)
+		}
+	}
+}
+
+var whiteRGBA = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+
+func TestSoftDCLineTo(t *testing.T) {
+	dc := newTestSoftDC(t, 8, 5, whiteRGBA)
+
+	var old POINT
+	MoveToEx(dc.HDC(), 0, 0, nil)
+	if !LineTo(dc.HDC(), 7, 3) {
+		t.Fatal("LineTo failed")
+	}
+	if !MoveToEx(dc.HDC(), 7, 4, &old) || old != (POINT{7, 3}) {
+		t.Fatalf("MoveToEx returned previous position %v, want {7 3}", old)
+	}
+	LineTo(dc.HDC(), 3, 4)
+
+	// Neither line sets its last pixel, (7, 3) and (3, 4).
+	checkSoftDCPicture(t, dc, []string{
+		"##......",
+		"..##....",
+		"....##..",
+		"......#.",
+		"....####",
+	})
+}
+
+func TestSoftDCPolyline(t *testing.T) {
+	dc := newTestSoftDC(t, 6, 6, whiteRGBA)
+
+	if Polyline(dc.HDC(), []POINT{{1, 1}}) {
+		t.Error("Polyline succeeded with a single point")
+	}
+	if !Polyline(dc.HDC(), []POINT{{1, 1}, {4, 1}, {4, 4}, {1, 4}}) {
+		t.Fatal("Polyline failed")
+	}
+
+	// Each segment draws the vertex it starts at, but the figure is left
+	// open and the final point (1, 4) is not drawn.
+	checkSoftDCPicture(t, dc, []string{
+		"......",
+		".####.",
+		"....#.",
+		"....#.",
+		"..###.",
+		"......",
+	})
+}
+
+func TestSoftDCPolygonFillMode(t *testing.T) {
+	// A pentagram, whose center is enclosed twice.
+	star := []POINT{{8, 0}, {13, 16}, {0, 6}, {16, 6}, {3, 16}}
+
+	alternate := newTestSoftDC(t, 17, 17, whiteRGBA)
+	alternate.SelectPen(SoftPen{Null: true})
+	alternate.SelectBrush(SoftBrush{Color: RGB(0, 0, 0)})
+	if !Polygon(alternate.HDC(), star) {
+		t.Fatal("Polygon failed")
+	}
+
+	winding := newTestSoftDC(t, 17, 17, whiteRGBA)
+	winding.SelectPen(SoftPen{Null: true})
+	winding.SelectBrush(SoftBrush{Color: RGB(0, 0, 0)})
+	if old := SetPolyFillMode(winding.HDC(), WINDING); old != ALTERNATE {
+		t.Errorf("SetPolyFillMode returned %d, want ALTERNATE", old)
+	}
+	Polygon(winding.HDC(), star)
+
+	// Under ALTERNATE the pentagon in the middle is outside; under
+	// WINDING it is filled.
+	checkSoftDCPicture(t, alternate, []string{
+		".................",
+		"........#........",
+		"........#........",
+		"........#........",
+		".......###.......",
+		".......###.......",
+		"#######...######.",
+		"..####.....####..",
+		"...###.....###...",
+		"....##.....##....",
+		".....#.....#.....",
+		".....##...##.....",
+		".....###.###.....",
+		"....###...###....",
+		"....##.....##....",
+		"....#.......#....",
+		".................",
+	})
+	checkSoftDCPicture(t, winding, []string{
+		".................",
+		"........#........",
+		"........#........",
+		"........#........",
+		".......###.......",
+		".......###.......",
+		"################.",
+		"..#############..",
+		"...###########...",
+		"....#########....",
+		".....#######.....",
+		".....#######.....",
+		".....#######.....",
+		"....###...###....",
+		"....##.....##....",
+		"....#.......#....",
+		".................",
+	})
+}
+
+func TestSoftDCPolyBezier(t *testing.T) {
+	dc := newTestSoftDC(t, 16, 10, whiteRGBA)
+
+	if PolyBezier(dc.HDC(), []POINT{{0, 0}, {1, 1}}) {
+		t.Error("PolyBezier succeeded with two points")
+	}
+	if !PolyBezier(dc.HDC(), []POINT{{0, 9}, {0, 0}, {15, 0}, {15, 9}}) {
+		t.Fatal("PolyBezier failed")
+	}
+
+	// The curve ends at (15, 9), which is not drawn.
+	checkSoftDCPicture(t, dc, []string{
+		"................",
+		"................",
+		".......###......",
+		"....###...##....",
+		"..##........##..",
+		"..#..........#..",
+		".#............#.",
+		".#............#.",
+		"#..............#",
+		"#...............",
+	})
+}
+
+// blockFace draws every rune as a solid w by h block advancing by w+1.
+type blockFace struct{ w, h int }
+
+func (f blockFace) Glyph(r rune) (image.Image, int) {
+	if r == ' ' {
+		return nil, f.w + 1
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, f.w, f.h))
+	for i := range mask.Pix {
+		mask.Pix[i] = 0xFF
+	}
+
+	return mask, f.w + 1
+}
+
+func (f blockFace) Height() int {
+	return f.h + 1
+}
+
+func TestSoftDCTextOut(t *testing.T) {
+	dc := newTestSoftDC(t, 13, 5, color.RGBA{0x80, 0x80, 0x80, 0xFF})
+
+	if TextOut(dc.HDC(), 0, 0, "a") {
+		t.Error("TextOut succeeded without a face")
+	}
+	dc.SetFace(blockFace{2, 2})
+	if !TextOut(dc.HDC(), 1, 1, "a b") {
+		t.Fatal("TextOut failed")
+	}
+	SetBkMode(dc.HDC(), TRANSPARENT)
+	TextOut(dc.HDC(), 10, 1, "c")
+
+	// The opaque background covers the cells, including the space; the
+	// transparent one leaves the gray around the glyph.
+	checkSoftDCPicture(t, dc, []string{
+		"ooooooooooooo",
+		"o##....##.##o",
+		"o##....##.##o",
+		"o.........ooo",
+		"ooooooooooooo",
+	})
+}
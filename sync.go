@@ -0,0 +1,355 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWaitAbandoned is returned when a wait acquires a mutex whose
+	// owning thread exited without releasing it.
+	ErrWaitAbandoned = errors.New("winapi: wait abandoned")
+
+	// ErrTooManyHandles is returned when more handles are passed to a
+	// wait than WaitForMultipleObjects accepts.
+	ErrTooManyHandles = errors.New("winapi: too many wait handles")
+)
+
+// WaitAny blocks until one of handles is signaled, ch receives a value
+// or ctx is done. It returns the index of the signaled handle, or
+// len(handles) if ch fired. A nil ch is never selected.
+//
+// Signaling a handle and receiving from ch are both consuming operations
+// (an auto-reset event is reset, a mutex is acquired), so when both
+// happen at once the value received from ch is dropped in favour of the
+// handle. Use a channel that is closed rather than sent on if that
+// matters.
+func WaitAny(ctx context.Context, ch <-chan struct{}, handles ...HANDLE) (int, error) {
+	if len(handles) > MAXIMUM_WAIT_OBJECTS-1 {
+		return -1, ErrTooManyHandles
+	}
+
+	if ctx.Done() == nil && ch == nil {
+		return waitResult(WaitForMultipleObjects(handles, false, INFINITE), len(handles))
+	}
+
+	cancel := CreateEventEx(nil, "", CREATE_EVENT_MANUAL_RESET, EVENT_ALL_ACCESS)
+	if cancel == 0 {
+		return -1, lastError
+	}
+	defer CloseHandle(cancel)
+
+	// The helper goroutine calls the proc directly so it never races on
+	// lastError with the wait below.
+	stop := make(chan struct{})
+	fired := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			procSetEvent.Call(uintptr(cancel))
+			fired <- false
+		case <-ch:
+			procSetEvent.Call(uintptr(cancel))
+			fired <- true
+		case <-stop:
+			fired <- false
+		}
+	}()
+
+	all := make([]HANDLE, 0, len(handles)+1)
+	all = append(all, handles...)
+	all = append(all, cancel)
+	i, err := waitResult(WaitForMultipleObjects(all, false, INFINITE), len(all))
+	close(stop)
+	fromChan := <-fired
+
+	if err != nil || i < len(handles) {
+		return i, err
+	}
+	if fromChan {
+		return len(handles), nil
+	}
+
+	return -1, ctx.Err()
+}
+
+func waitResult(ret DWORD, n int) (int, error) {
+	switch {
+	case ret < WAIT_OBJECT_0+DWORD(n):
+		return int(ret - WAIT_OBJECT_0), nil
+	case ret >= WAIT_ABANDONED_0 && ret < WAIT_ABANDONED_0+DWORD(n):
+		return int(ret - WAIT_ABANDONED_0), ErrWaitAbandoned
+	}
+
+	return -1, lastError
+}
+
+func waitOne(ctx context.Context, h HANDLE) error {
+	_, err := WaitAny(ctx, nil, h)
+
+	return err
+}
+
+func closeObject(h *HANDLE) error {
+	if *h == 0 {
+		return nil
+	}
+	if !CloseHandle(*h) {
+		return lastError
+	}
+	*h = 0
+
+	return nil
+}
+
+// Event is a kernel event object.
+type Event struct {
+	h HANDLE
+}
+
+// NewEvent creates an event. An empty name creates an unnamed event.
+func NewEvent(name string, manualReset, initialState bool) (*Event, error) {
+	var flags DWORD
+	if manualReset {
+		flags |= CREATE_EVENT_MANUAL_RESET
+	}
+	if initialState {
+		flags |= CREATE_EVENT_INITIAL_SET
+	}
+
+	h := CreateEventEx(nil, name, flags, EVENT_ALL_ACCESS)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Event{h}, nil
+}
+
+// OpenNamedEvent opens an existing named event.
+func OpenNamedEvent(name string) (*Event, error) {
+	h := OpenEvent(SYNCHRONIZE|EVENT_MODIFY_STATE, false, name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Event{h}, nil
+}
+
+func (e *Event) Handle() HANDLE {
+	return e.h
+}
+
+func (e *Event) Set() error {
+	if !SetEvent(e.h) {
+		return lastError
+	}
+
+	return nil
+}
+
+func (e *Event) Reset() error {
+	if !ResetEvent(e.h) {
+		return lastError
+	}
+
+	return nil
+}
+
+// Wait blocks until the event is signaled or ctx is done.
+func (e *Event) Wait(ctx context.Context) error {
+	return waitOne(ctx, e.h)
+}
+
+func (e *Event) Close() error {
+	return closeObject(&e.h)
+}
+
+// Mutex is a kernel mutex object. Ownership belongs to the OS thread
+// that acquired it, so callers must hold runtime.LockOSThread between
+// Wait and Release.
+type Mutex struct {
+	h HANDLE
+}
+
+// NewMutex creates a mutex. An empty name creates an unnamed mutex.
+func NewMutex(name string, initialOwner bool) (*Mutex, error) {
+	var flags DWORD
+	if initialOwner {
+		flags = CREATE_MUTEX_INITIAL_OWNER
+	}
+
+	h := CreateMutexEx(nil, name, flags, MUTEX_ALL_ACCESS)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Mutex{h}, nil
+}
+
+// OpenNamedMutex opens an existing named mutex.
+func OpenNamedMutex(name string) (*Mutex, error) {
+	h := OpenMutex(SYNCHRONIZE|MUTEX_MODIFY_STATE, false, name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Mutex{h}, nil
+}
+
+func (m *Mutex) Handle() HANDLE {
+	return m.h
+}
+
+// Wait acquires the mutex. It returns ErrWaitAbandoned, with the mutex
+// acquired, if the previous owner exited while holding it.
+func (m *Mutex) Wait(ctx context.Context) error {
+	return waitOne(ctx, m.h)
+}
+
+func (m *Mutex) Release() error {
+	if !ReleaseMutex(m.h) {
+		return lastError
+	}
+
+	return nil
+}
+
+func (m *Mutex) Close() error {
+	return closeObject(&m.h)
+}
+
+// Semaphore is a kernel semaphore object.
+type Semaphore struct {
+	h HANDLE
+}
+
+// NewSemaphore creates a semaphore. An empty name creates an unnamed
+// semaphore.
+func NewSemaphore(name string, initial, max int32) (*Semaphore, error) {
+	h := CreateSemaphoreEx(nil, initial, max, name, 0, SEMAPHORE_ALL_ACCESS)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Semaphore{h}, nil
+}
+
+// OpenNamedSemaphore opens an existing named semaphore.
+func OpenNamedSemaphore(name string) (*Semaphore, error) {
+	h := OpenSemaphore(SYNCHRONIZE|SEMAPHORE_MODIFY_STATE, false, name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &Semaphore{h}, nil
+}
+
+func (s *Semaphore) Handle() HANDLE {
+	return s.h
+}
+
+// Wait decrements the semaphore count, blocking while it is zero.
+func (s *Semaphore) Wait(ctx context.Context) error {
+	return waitOne(ctx, s.h)
+}
+
+// Release increments the count by n and returns the previous count.
+func (s *Semaphore) Release(n int32) (int32, error) {
+	var prev int32
+	if !ReleaseSemaphore(s.h, n, &prev) {
+		return 0, lastError
+	}
+
+	return prev, nil
+}
+
+func (s *Semaphore) Close() error {
+	return closeObject(&s.h)
+}
+
+// WaitableTimer is a kernel waitable timer object.
+type WaitableTimer struct {
+	h HANDLE
+}
+
+// NewWaitableTimer creates a timer. An empty name creates an unnamed
+// timer.
+func NewWaitableTimer(name string, manualReset bool) (*WaitableTimer, error) {
+	var flags DWORD
+	if manualReset {
+		flags = CREATE_WAITABLE_TIMER_MANUAL_RESET
+	}
+
+	h := CreateWaitableTimerEx(nil, name, flags, TIMER_ALL_ACCESS)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &WaitableTimer{h}, nil
+}
+
+// OpenNamedWaitableTimer opens an existing named timer.
+func OpenNamedWaitableTimer(name string) (*WaitableTimer, error) {
+	h := OpenWaitableTimer(SYNCHRONIZE|TIMER_MODIFY_STATE, false, name)
+	if h == 0 {
+		return nil, lastError
+	}
+
+	return &WaitableTimer{h}, nil
+}
+
+func (t *WaitableTimer) Handle() HANDLE {
+	return t.h
+}
+
+// Set signals the timer after due and then every period. A zero period
+// signals it once. The period is rounded down to milliseconds.
+func (t *WaitableTimer) Set(due, period time.Duration) error {
+	dueTime := -int64(due / 100)
+	if dueTime == 0 {
+		// Zero would be read as an absolute time in 1601.
+		dueTime = -1
+	}
+	if !SetWaitableTimer(t.h, &dueTime, int32(period/time.Millisecond), false) {
+		return lastError
+	}
+
+	return nil
+}
+
+// SetAt signals the timer at t and then every period.
+func (t *WaitableTimer) SetAt(at time.Time, period time.Duration) error {
+	ft, err := NewFILETIME(at)
+	if err != nil {
+		return err
+	}
+
+	dueTime := int64(ft.Ticks())
+	if !SetWaitableTimer(t.h, &dueTime, int32(period/time.Millisecond), false) {
+		return lastError
+	}
+
+	return nil
+}
+
+func (t *WaitableTimer) Cancel() error {
+	if !CancelWaitableTimer(t.h) {
+		return lastError
+	}
+
+	return nil
+}
+
+// Wait blocks until the timer is signaled or ctx is done.
+func (t *WaitableTimer) Wait(ctx context.Context) error {
+	return waitOne(ctx, t.h)
+}
+
+func (t *WaitableTimer) Close() error {
+	return closeObject(&t.h)
+}
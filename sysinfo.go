@@ -0,0 +1,151 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"fmt"
+)
+
+// OSVersion is a Windows version number.
+type OSVersion struct {
+	Major, Minor, Build uint32
+}
+
+// Windows releases by the version RtlGetVersion reports for them.
+var (
+	Windows7       = OSVersion{6, 1, 7600}
+	Windows8       = OSVersion{6, 2, 9200}
+	Windows8_1     = OSVersion{6, 3, 9600}
+	Windows10      = OSVersion{10, 0, 10240}
+	Windows10_1511 = OSVersion{10, 0, 10586}
+	Windows10_1607 = OSVersion{10, 0, 14393}
+	Windows10_1703 = OSVersion{10, 0, 15063}
+	Windows10_1709 = OSVersion{10, 0, 16299}
+	Windows10_1803 = OSVersion{10, 0, 17134}
+	Windows10_1809 = OSVersion{10, 0, 17763}
+	Windows10_1903 = OSVersion{10, 0, 18362}
+	Windows10_1909 = OSVersion{10, 0, 18363}
+	Windows10_2004 = OSVersion{10, 0, 19041}
+	Windows10_20H2 = OSVersion{10, 0, 19042}
+	Windows10_21H1 = OSVersion{10, 0, 19043}
+	Windows10_21H2 = OSVersion{10, 0, 19044}
+	Windows10_22H2 = OSVersion{10, 0, 19045}
+	Windows11      = OSVersion{10, 0, 22000}
+	Windows11_22H2 = OSVersion{10, 0, 22621}
+	Windows11_23H2 = OSVersion{10, 0, 22631}
+	Windows11_24H2 = OSVersion{10, 0, 26100}
+)
+
+// AtLeast reports whether v is the same as or newer than w.
+func (v OSVersion) AtLeast(w OSVersion) bool {
+	if v.Major != w.Major {
+		return v.Major > w.Major
+	}
+	if v.Minor != w.Minor {
+		return v.Minor > w.Minor
+	}
+
+	return v.Build >= w.Build
+}
+
+func (v OSVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Build)
+}
+
+// SystemInfo describes the running system.
+type SystemInfo struct {
+	Version     OSVersion
+	ServicePack string
+	ProductType byte   // VER_NT_*
+	SuiteMask   uint16 // VER_SUITE_*
+	Edition     DWORD  // PRODUCT_*
+
+	// Architecture is the native processor architecture, one of the
+	// PROCESSOR_ARCHITECTURE_* constants. ProcessMachine and
+	// NativeMachine are IMAGE_FILE_MACHINE_* values; ProcessMachine is
+	// IMAGE_FILE_MACHINE_UNKNOWN unless the process runs under WOW64.
+	Architecture   uint16
+	ProcessMachine uint16
+	NativeMachine  uint16
+	WOW64          bool
+
+	PageSize              uint32
+	AllocationGranularity uint32
+	NumberOfProcessors    uint32
+}
+
+// QuerySystemInfo collects version and hardware information from
+// RtlGetVersion, GetProductInfo, GetNativeSystemInfo and
+// IsWow64Process2, falling back to IsWow64Process before Windows 10
+// 1511. If RtlGetVersion fails, its NTSTATUS is returned as the error.
+func QuerySystemInfo() (*SystemInfo, error) {
+	var vi OSVERSIONINFOEX
+	if !RtlGetVersion(&vi) {
+		return nil, lastError
+	}
+
+	var si SYSTEM_INFO
+	GetNativeSystemInfo(&si)
+
+	info := &SystemInfo{
+		Version:               OSVersion{uint32(vi.MajorVersion), uint32(vi.MinorVersion), uint32(vi.BuildNumber)},
+		ServicePack:           utf16ToString(vi.CSDVersion[:]),
+		ProductType:           vi.ProductType,
+		SuiteMask:             vi.SuiteMask,
+		Architecture:          si.ProcessorArchitecture,
+		PageSize:              uint32(si.PageSize),
+		AllocationGranularity: uint32(si.AllocationGranularity),
+		NumberOfProcessors:    uint32(si.NumberOfProcessors),
+	}
+
+	GetProductInfo(vi.MajorVersion, vi.MinorVersion, DWORD(vi.ServicePackMajor),
+		DWORD(vi.ServicePackMinor), &info.Edition)
+
+	if procIsWow64Process2.Find() == nil {
+		if !IsWow64Process2(GetCurrentProcess(), &info.ProcessMachine, &info.NativeMachine) {
+			return nil, lastError
+		}
+		info.WOW64 = info.ProcessMachine != IMAGE_FILE_MACHINE_UNKNOWN
+	} else {
+		var wow64 BOOL
+		if !IsWow64Process(GetCurrentProcess(), &wow64) {
+			return nil, lastError
+		}
+		info.NativeMachine = architectureMachine(info.Architecture)
+		if wow64 != 0 {
+			info.WOW64 = true
+			info.ProcessMachine = IMAGE_FILE_MACHINE_I386
+		}
+	}
+
+	return info, nil
+}
+
+// AtLeast reports whether the system is version v or newer.
+func (s *SystemInfo) AtLeast(v OSVersion) bool {
+	return s.Version.AtLeast(v)
+}
+
+// IsServer reports whether the system is a server edition.
+func (s *SystemInfo) IsServer() bool {
+	return s.ProductType != VER_NT_WORKSTATION
+}
+
+func architectureMachine(arch uint16) uint16 {
+	switch arch {
+	case PROCESSOR_ARCHITECTURE_INTEL:
+		return IMAGE_FILE_MACHINE_I386
+	case PROCESSOR_ARCHITECTURE_ARM:
+		return IMAGE_FILE_MACHINE_ARMNT
+	case PROCESSOR_ARCHITECTURE_IA64:
+		return IMAGE_FILE_MACHINE_IA64
+	case PROCESSOR_ARCHITECTURE_AMD64:
+		return IMAGE_FILE_MACHINE_AMD64
+	case PROCESSOR_ARCHITECTURE_ARM64:
+		return IMAGE_FILE_MACHINE_ARM64
+	}
+
+	return IMAGE_FILE_MACHINE_UNKNOWN
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unsafe"
+)
+
+var ErrTextDx = errors.New("winapi: dx does not match the length of the text")
+
+// POLYTEXT is the native form of a PolyText.
+type POLYTEXT struct {
+	X       int32
+	Y       int32
+	N       UINT
+	Lpstr   *uint16
+	UiFlags UINT
+	Rcl     RECT
+	Pdx     *int32
+}
+
+// PolyText is one string drawn by PolyTextOut, with the arguments
+// ExtTextOut takes for it. Options may only contain ETO_OPAQUE and
+// ETO_CLIPPED, which use Rect.
+type PolyText struct {
+	X       int32
+	Y       int32
+	Text    string
+	Options UINT
+	Rect    RECT
+	Dx      []int32
+}
+
+// utf16Units returns s in UTF-16 without a terminating NUL, as the
+// functions taking a counted string expect it.
+func utf16Units(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+// utf16Ptr returns a pointer to the first unit of u, or 0 if u is
+// empty.
+func utf16Ptr(u []uint16) uintptr {
+	if len(u) == 0 {
+		return 0
+	}
+
+	return uintptr(unsafe.Pointer(&u[0]))
+}
+
+// checkDx reports whether dx holds an advance, or with ETO_PDY an
+// advance pair, for each of n UTF-16 code units. A nil dx is valid.
+func checkDx(dx []int32, n int, options UINT) bool {
+	if dx == nil {
+		return true
+	}
+	if options&ETO_PDY != 0 {
+		n *= 2
+	}
+
+	return len(dx) == n
+}
+
+// polyTextItems converts texts to POLYTEXT structures. The UTF-16
+// buffers are returned so the caller can keep them alive.
+func polyTextItems(texts []PolyText) ([]POLYTEXT, [][]uint16, bool) {
+	items := make([]POLYTEXT, len(texts))
+	bufs := make([][]uint16, len(texts))
+	for i, t := range texts {
+		u := utf16Units(t.Text)
+		if !checkDx(t.Dx, len(u), t.Options) || t.Options&ETO_PDY != 0 {
+			return nil, nil, false
+		}
+		bufs[i] = u
+
+		items[i] = POLYTEXT{X: t.X, Y: t.Y, N: UINT(len(u)), UiFlags: t.Options, Rcl: t.Rect}
+		if len(u) > 0 {
+			items[i].Lpstr = &u[0]
+		}
+		if len(t.Dx) > 0 {
+			items[i].Pdx = &t.Dx[0]
+		}
+	}
+
+	return items, bufs, true
+}
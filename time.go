@@ -0,0 +1,169 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"errors"
+	"time"
+)
+
+// FILETIME is the number of 100 nanosecond intervals since January 1,
+// 1601 UTC.
+type FILETIME struct {
+	LowDateTime  uint32
+	HighDateTime uint32
+}
+
+type SYSTEMTIME struct {
+	Year         uint16
+	Month        uint16
+	DayOfWeek    uint16
+	Day          uint16
+	Hour         uint16
+	Minute       uint16
+	Second       uint16
+	Milliseconds uint16
+}
+
+// Seconds between the FILETIME epoch and the Unix epoch.
+const filetimeEpochDelta = 11644473600
+
+const filetimeTicksPerSecond = 10000000
+
+// Windows rejects FILETIMEs with the top bit set.
+const filetimeMaxTicks = 1<<63 - 1
+
+var (
+	ErrFILETIMERange   = errors.New("winapi: time out of FILETIME range")
+	ErrSYSTEMTIMERange = errors.New("winapi: time out of SYSTEMTIME range")
+)
+
+// NewFILETIME converts t to a FILETIME, truncating it to 100
+// nanoseconds. Times before 1601 or after the last tick below 1<<63 are
+// out of range.
+func NewFILETIME(t time.Time) (FILETIME, error) {
+	sec := t.Unix() + filetimeEpochDelta
+	if sec < 0 || uint64(sec) > filetimeMaxTicks/filetimeTicksPerSecond {
+		return FILETIME{}, ErrFILETIMERange
+	}
+
+	ticks := uint64(sec)*filetimeTicksPerSecond + uint64(t.Nanosecond()/100)
+	if ticks > filetimeMaxTicks {
+		return FILETIME{}, ErrFILETIMERange
+	}
+
+	return FILETIMEFromTicks(ticks), nil
+}
+
+func FILETIMEFromTicks(ticks uint64) FILETIME {
+	return FILETIME{LowDateTime: uint32(ticks), HighDateTime: uint32(ticks >> 32)}
+}
+
+// Ticks returns the number of 100 nanosecond intervals since 1601.
+func (ft FILETIME) Ticks() uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
+
+// Time returns ft as a UTC time. The conversion is exact, so for any
+// ft below 1<<63, the range Windows accepts, NewFILETIME(ft.Time())
+// returns ft. Larger values convert but cannot be converted back.
+func (ft FILETIME) Time() time.Time {
+	ticks := ft.Ticks()
+	sec := int64(ticks/filetimeTicksPerSecond) - filetimeEpochDelta
+	nsec := int64(ticks%filetimeTicksPerSecond) * 100
+
+	return time.Unix(sec, nsec).UTC()
+}
+
+// NewSYSTEMTIME converts the wall clock of t, in t's location, to a
+// SYSTEMTIME, truncating it to milliseconds.
+func NewSYSTEMTIME(t time.Time) (SYSTEMTIME, error) {
+	if t.Year() < 1601 || t.Year() > 30827 {
+		return SYSTEMTIME{}, ErrSYSTEMTIMERange
+	}
+
+	return SYSTEMTIME{
+		Year:         uint16(t.Year()),
+		Month:        uint16(t.Month()),
+		DayOfWeek:    uint16(t.Weekday()),
+		Day:          uint16(t.Day()),
+		Hour:         uint16(t.Hour()),
+		Minute:       uint16(t.Minute()),
+		Second:       uint16(t.Second()),
+		Milliseconds: uint16(t.Nanosecond() / int(time.Millisecond)),
+	}, nil
+}
+
+// Time interprets st as a wall clock time in loc. DayOfWeek is ignored.
+func (st SYSTEMTIME) Time(loc *time.Location) time.Time {
+	return time.Date(int(st.Year), time.Month(st.Month), int(st.Day),
+		int(st.Hour), int(st.Minute), int(st.Second),
+		int(st.Milliseconds)*int(time.Millisecond), loc)
+}
+
+// TickDiff returns the time between two GetTickCount values, allowing
+// for the counter wrapping around after 49.7 days.
+func TickDiff(later, earlier DWORD) time.Duration {
+	return time.Duration(later-earlier) * time.Millisecond
+}
+
+// TickTime converts the tick count tick to a time, given that the tick
+// count was now at time t.
+func TickTime(tick, now DWORD, t time.Time) time.Time {
+	return t.Add(-TickDiff(now, tick))
+}
+
+// Timestamp returns the time the message was posted.
+func (m *WinMSG) Timestamp() time.Time {
+	return TickTime(m.Time, GetTickCount(), time.Now())
+}
+
+// PerfDuration converts a QueryPerformanceCounter interval to a
+// duration without overflowing for large counts.
+func PerfDuration(ticks, freq int64) time.Duration {
+	sec := ticks / freq
+	rem := ticks % freq
+
+	return time.Duration(sec)*time.Second + time.Duration(rem*int64(time.Second)/freq)
+}
+
+// Clock is a monotonic clock backed by the performance counter.
+type Clock struct {
+	freq int64
+	base int64
+}
+
+// NewClock returns a clock whose zero is the current counter value.
+func NewClock() (*Clock, error) {
+	c := &Clock{}
+	if !QueryPerformanceFrequency(&c.freq) || !QueryPerformanceCounter(&c.base) {
+		return nil, lastError
+	}
+
+	return c, nil
+}
+
+// Frequency returns the counter frequency in ticks per second.
+func (c *Clock) Frequency() int64 {
+	return c.freq
+}
+
+// Ticks returns the raw counter value.
+func (c *Clock) Ticks() int64 {
+	var ticks int64
+	QueryPerformanceCounter(&ticks)
+
+	return ticks
+}
+
+// Since returns the time elapsed since the counter was at ticks.
+func (c *Clock) Since(ticks int64) time.Duration {
+	return PerfDuration(c.Ticks()-ticks, c.freq)
+}
+
+// Elapsed returns the time elapsed since the clock was created.
+func (c *Clock) Elapsed() time.Duration {
+	return c.Since(c.base)
+}
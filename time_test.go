@@ -0,0 +1,139 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFILETIMERoundTrip(t *testing.T) {
+	tests := []struct {
+		t     time.Time
+		ticks uint64
+	}{
+		{time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1601, 1, 1, 0, 0, 0, 100, time.UTC), 1},
+		{time.Unix(0, 0).UTC(), filetimeEpochDelta * filetimeTicksPerSecond},
+		{time.Date(2013, 5, 17, 12, 30, 45, 123456700, time.UTC), 130132674451234567},
+		{time.Unix(922337203685-filetimeEpochDelta, 477580700).UTC(), 1<<63 - 1},
+	}
+	for _, tt := range tests {
+		ft, err := NewFILETIME(tt.t)
+		if err != nil {
+			t.Errorf("NewFILETIME(%v): %v", tt.t, err)
+			continue
+		}
+		if ft.Ticks() != tt.ticks {
+			t.Errorf("NewFILETIME(%v) = %d ticks, want %d", tt.t, ft.Ticks(), tt.ticks)
+		}
+		if got := ft.Time(); !got.Equal(tt.t) {
+			t.Errorf("FILETIME(%d).Time() = %v, want %v", tt.ticks, got, tt.t)
+		}
+		if ft != FILETIMEFromTicks(tt.ticks) {
+			t.Errorf("FILETIMEFromTicks(%d) = %+v, want %+v", tt.ticks, FILETIMEFromTicks(tt.ticks), ft)
+		}
+	}
+
+	// Sub-tick precision and the location are dropped.
+	loc := time.FixedZone("X", 3600)
+	in := time.Date(2013, 5, 17, 13, 30, 45, 123456789, loc)
+	ft, _ := NewFILETIME(in)
+	if got, want := ft.Time(), time.Date(2013, 5, 17, 12, 30, 45, 123456700, time.UTC); got != want {
+		t.Errorf("round trip of %v = %v, want %v", in, got, want)
+	}
+}
+
+func TestFILETIMERange(t *testing.T) {
+	tests := []time.Time{
+		time.Date(1600, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		// One tick past 1<<63 - 1, in the last whole second.
+		time.Unix(922337203685-filetimeEpochDelta, 477580800),
+		// The second after the last whole second.
+		time.Unix(922337203686-filetimeEpochDelta, 0),
+		time.Date(100000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, tt := range tests {
+		if ft, err := NewFILETIME(tt); err != ErrFILETIMERange {
+			t.Errorf("NewFILETIME(%v) = %d, %v, want ErrFILETIMERange", tt, ft.Ticks(), err)
+		}
+	}
+
+	// Values with the top bit set still convert to a time.
+	ft := FILETIMEFromTicks(1 << 63)
+	if got, want := ft.Time(), time.Unix(922337203685-filetimeEpochDelta, 477580800).UTC(); got != want {
+		t.Errorf("FILETIME(1<<63).Time() = %v, want %v", got, want)
+	}
+	if _, err := NewFILETIME(ft.Time()); err != ErrFILETIMERange {
+		t.Errorf("NewFILETIME of 1<<63 ticks: %v, want ErrFILETIMERange", err)
+	}
+}
+
+func TestSYSTEMTIME(t *testing.T) {
+	in := time.Date(2013, 5, 17, 12, 30, 45, 123456789, time.UTC)
+	st, err := NewSYSTEMTIME(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SYSTEMTIME{2013, 5, uint16(time.Friday), 17, 12, 30, 45, 123}
+	if st != want {
+		t.Errorf("NewSYSTEMTIME = %+v, want %+v", st, want)
+	}
+	if got := st.Time(time.UTC); got != in.Truncate(time.Millisecond) {
+		t.Errorf("Time = %v, want %v", got, in.Truncate(time.Millisecond))
+	}
+
+	for _, y := range []int{1600, 30828} {
+		if _, err := NewSYSTEMTIME(time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)); err != ErrSYSTEMTIMERange {
+			t.Errorf("NewSYSTEMTIME in %d: %v, want ErrSYSTEMTIMERange", y, err)
+		}
+	}
+}
+
+func TestTickDiff(t *testing.T) {
+	tests := []struct {
+		later, earlier DWORD
+		want           time.Duration
+	}{
+		{1500, 1000, 500 * time.Millisecond},
+		{1000, 1000, 0},
+		{5, 0xFFFFFFFB, 10 * time.Millisecond},
+		{0, 0xFFFFFFFF, time.Millisecond},
+		{0x7FFFFFFF, 0x80000000, 0xFFFFFFFF * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := TickDiff(tt.later, tt.earlier); got != tt.want {
+			t.Errorf("TickDiff(%#x, %#x) = %v, want %v", tt.later, tt.earlier, got, tt.want)
+		}
+	}
+
+	now := time.Date(2013, 5, 17, 12, 0, 0, 0, time.UTC)
+	if got, want := TickTime(0xFFFFFFF6, 10, now), now.Add(-20*time.Millisecond); got != want {
+		t.Errorf("TickTime across the wraparound = %v, want %v", got, want)
+	}
+}
+
+func TestPerfDuration(t *testing.T) {
+	const year = 365 * 24 * time.Hour
+	tests := []struct {
+		ticks, freq int64
+		want        time.Duration
+	}{
+		{0, 1000, 0},
+		{3, 2, 1500 * time.Millisecond},
+		{-3, 2, -1500 * time.Millisecond},
+		{10000001, 10000000, time.Second + 100},
+		{1, 3, 333333333},
+		// ticks * time.Second overflows int64 here.
+		{3000000000 * int64(year/time.Second), 3000000000, year},
+		{3000000000*int64(year/time.Second) + 1500000000, 3000000000, year + 500*time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := PerfDuration(tt.ticks, tt.freq); got != tt.want {
+			t.Errorf("PerfDuration(%d, %d) = %v, want %v", tt.ticks, tt.freq, got, tt.want)
+		}
+	}
+}
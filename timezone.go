@@ -0,0 +1,179 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TIME_ZONE_INFORMATION struct {
+	Bias         int32
+	StandardName [32]uint16
+	StandardDate SYSTEMTIME
+	StandardBias int32
+	DaylightName [32]uint16
+	DaylightDate SYSTEMTIME
+	DaylightBias int32
+}
+
+type DYNAMIC_TIME_ZONE_INFORMATION struct {
+	TIME_ZONE_INFORMATION
+	TimeZoneKeyName             [128]uint16
+	DynamicDaylightTimeDisabled byte
+}
+
+// GetTimeZoneInformation return values
+const (
+	TIME_ZONE_ID_UNKNOWN  = 0
+	TIME_ZONE_ID_STANDARD = 1
+	TIME_ZONE_ID_DAYLIGHT = 2
+	TIME_ZONE_ID_INVALID  = 0xFFFFFFFF
+)
+
+// ErrTZAbsoluteRule is returned for time zone rules that name a
+// specific year instead of recurring every year.
+var ErrTZAbsoluteRule = errors.New("winapi: absolute time zone rules are not supported")
+
+// TZString returns the rules of tzi as a POSIX TZ string such as
+// "<+01>-1<+02>,M3.5.0/2,M10.5.0/3". Zones are named after their UTC
+// offset, as the Windows display names are not valid abbreviations.
+func (tzi *TIME_ZONE_INFORMATION) TZString() (string, error) {
+	std := tzi.Bias + tzi.StandardBias
+	s := "<" + tzName(std) + ">" + tzOffset(std)
+	if tzi.DaylightDate.Month == 0 {
+		return s, nil
+	}
+
+	if tzi.DaylightDate.Year != 0 || tzi.StandardDate.Year != 0 {
+		return "", ErrTZAbsoluteRule
+	}
+
+	dst := tzi.Bias + tzi.DaylightBias
+	s += "<" + tzName(dst) + ">" + tzOffset(dst)
+
+	return s + tzRule(tzi.DaylightDate) + tzRule(tzi.StandardDate), nil
+}
+
+// Location builds a location named name that follows the rules of tzi.
+func (tzi *TIME_ZONE_INFORMATION) Location(name string) (*time.Location, error) {
+	tz, err := tzi.TZString()
+	if err != nil {
+		return nil, err
+	}
+
+	std := tzi.Bias + tzi.StandardBias
+
+	return time.LoadLocationFromTZData(name, tzifData(tzName(std), -std*60, tz))
+}
+
+// Location builds a location from the rules of tzi. It is named after
+// the IANA zone matching TimeZoneKeyName, or the key name itself if
+// there is none.
+func (tzi *DYNAMIC_TIME_ZONE_INFORMATION) Location() (*time.Location, error) {
+	key := utf16ToString(tzi.TimeZoneKeyName[:])
+	name, ok := WindowsZoneToIANA(key)
+	if !ok {
+		name = key
+	}
+
+	rules := tzi.TIME_ZONE_INFORMATION
+	if tzi.DynamicDaylightTimeDisabled != 0 {
+		rules.DaylightDate = SYSTEMTIME{}
+	}
+
+	return rules.Location(name)
+}
+
+// LocalTimeZone returns the location configured on this host.
+func LocalTimeZone() (*time.Location, error) {
+	var tzi DYNAMIC_TIME_ZONE_INFORMATION
+	if GetDynamicTimeZoneInformation(&tzi) == TIME_ZONE_ID_INVALID {
+		return nil, lastError
+	}
+
+	return tzi.Location()
+}
+
+// tzName names a zone after its UTC offset, as tzdata does for zones
+// without an established abbreviation, e.g. "+0530".
+func tzName(bias int32) string {
+	sign := '+'
+	if bias > 0 {
+		sign = '-'
+	} else {
+		bias = -bias
+	}
+
+	if bias%60 == 0 {
+		return fmt.Sprintf("%c%02d", sign, bias/60)
+	}
+
+	return fmt.Sprintf("%c%02d%02d", sign, bias/60, bias%60)
+}
+
+// tzOffset formats a bias in minutes, which like a POSIX offset is
+// positive west of Greenwich.
+func tzOffset(bias int32) string {
+	sign := ""
+	if bias < 0 {
+		sign = "-"
+		bias = -bias
+	}
+
+	if bias%60 == 0 {
+		return fmt.Sprintf("%s%d", sign, bias/60)
+	}
+
+	return fmt.Sprintf("%s%d:%02d", sign, bias/60, bias%60)
+}
+
+// tzRule converts a recurring transition date. Both formats count the
+// week in the month with 5 meaning the last one, and give the time in
+// the local time in effect before the transition. Windows writes
+// midnight as 23:59:59.999 in some zones, so the time is rounded to the
+// nearest second.
+func tzRule(st SYSTEMTIME) string {
+	sec := int(st.Hour)*3600 + int(st.Minute)*60 + int(st.Second)
+	if st.Milliseconds >= 500 {
+		sec++
+	}
+
+	rule := fmt.Sprintf(",M%d.%d.%d/%d", st.Month, st.Day, st.DayOfWeek, sec/3600)
+	switch {
+	case sec%60 != 0:
+		rule += fmt.Sprintf(":%02d:%02d", sec/60%60, sec%60)
+	case sec%3600 != 0:
+		rule += fmt.Sprintf(":%02d", sec/60%60)
+	}
+
+	return rule
+}
+
+// tzifData returns a version 2 TZif file with a single zone type and no
+// transitions, so every instant is resolved through the TZ string in
+// the footer.
+func tzifData(abbr string, offset int32, tz string) []byte {
+	block := func(b []byte) []byte {
+		b = append(b, "TZif2"...)
+		b = append(b, make([]byte, 15)...)
+		for _, n := range []uint32{0, 0, 0, 0, 1, uint32(len(abbr) + 1)} {
+			b = binary.BigEndian.AppendUint32(b, n)
+		}
+		b = binary.BigEndian.AppendUint32(b, uint32(offset))
+		b = append(b, 0, 0)
+		b = append(b, abbr...)
+
+		return append(b, 0)
+	}
+
+	b := block(block(nil))
+	b = append(b, '\n')
+	b = append(b, tz...)
+
+	return append(b, '\n')
+}
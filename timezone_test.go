@@ -0,0 +1,156 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"testing"
+	"time"
+)
+
+// Rules as GetTimeZoneInformation reports them.
+var (
+	// W. Europe Standard Time: summer time from the last Sunday of March
+	// at 2:00 to the last Sunday of October at 3:00.
+	tziWEurope = TIME_ZONE_INFORMATION{
+		Bias:         -60,
+		StandardDate: SYSTEMTIME{Month: 10, Day: 5, Hour: 3},
+		DaylightDate: SYSTEMTIME{Month: 3, Day: 5, Hour: 2},
+		DaylightBias: -60,
+	}
+
+	// AUS Eastern Standard Time: summer time from the first Sunday of
+	// October at 2:00 to the first Sunday of April at 3:00.
+	tziAUSEastern = TIME_ZONE_INFORMATION{
+		Bias:         -600,
+		StandardDate: SYSTEMTIME{Month: 4, Day: 1, Hour: 3},
+		DaylightDate: SYSTEMTIME{Month: 10, Day: 1, Hour: 2},
+		DaylightBias: -60,
+	}
+
+	// A zone switching at the end of the last Thursday of March, which
+	// Windows writes as 23:59:59.999, and back on the last Friday of
+	// October at 1:00.
+	tziMidnight = TIME_ZONE_INFORMATION{
+		Bias:         -120,
+		StandardDate: SYSTEMTIME{Month: 10, DayOfWeek: 5, Day: 5, Hour: 1},
+		DaylightDate: SYSTEMTIME{Month: 3, DayOfWeek: 4, Day: 5, Hour: 23, Minute: 59, Second: 59, Milliseconds: 999},
+		DaylightBias: -60,
+	}
+)
+
+func TestTZString(t *testing.T) {
+	tests := []struct {
+		name string
+		tzi  TIME_ZONE_INFORMATION
+		want string
+	}{
+		{"W. Europe", tziWEurope, "<+01>-1<+02>-2,M3.5.0/2,M10.5.0/3"},
+		{"AUS Eastern", tziAUSEastern, "<+10>-10<+11>-11,M10.1.0/2,M4.1.0/3"},
+		{"midnight", tziMidnight, "<+02>-2<+03>-3,M3.5.4/24,M10.5.5/1"},
+		{"India", TIME_ZONE_INFORMATION{Bias: -330}, "<+0530>-5:30"},
+		{"Newfoundland", TIME_ZONE_INFORMATION{
+			Bias:         210,
+			StandardDate: SYSTEMTIME{Month: 11, Day: 1, Hour: 2},
+			DaylightDate: SYSTEMTIME{Month: 3, Day: 2, Hour: 2},
+			DaylightBias: -60,
+		}, "<-0330>3:30<-0230>2:30,M3.2.0/2,M11.1.0/2"},
+	}
+	for _, tt := range tests {
+		got, err := tt.tzi.TZString()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: TZString = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	abs := tziWEurope
+	abs.DaylightDate.Year = 2013
+	if _, err := abs.TZString(); err != ErrTZAbsoluteRule {
+		t.Errorf("absolute rule: %v, want ErrTZAbsoluteRule", err)
+	}
+}
+
+func TestTZLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		tzi         TIME_ZONE_INFORMATION
+		transitions []time.Time
+		offsets     []int // before the first transition, then after each
+	}{
+		{"W. Europe", tziWEurope, []time.Time{
+			time.Date(2013, 3, 31, 1, 0, 0, 0, time.UTC),
+			time.Date(2013, 10, 27, 1, 0, 0, 0, time.UTC),
+		}, []int{3600, 7200, 3600}},
+		{"AUS Eastern", tziAUSEastern, []time.Time{
+			time.Date(2013, 4, 6, 16, 0, 0, 0, time.UTC),
+			time.Date(2013, 10, 5, 16, 0, 0, 0, time.UTC),
+		}, []int{39600, 36000, 39600}},
+		{"midnight", tziMidnight, []time.Time{
+			time.Date(2013, 3, 28, 22, 0, 0, 0, time.UTC),
+			time.Date(2013, 10, 24, 22, 0, 0, 0, time.UTC),
+		}, []int{7200, 10800, 7200}},
+	}
+	for _, tt := range tests {
+		loc, err := tt.tzi.Location(tt.name)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if loc.String() != tt.name {
+			t.Errorf("%s: location named %q", tt.name, loc)
+		}
+		for i, tr := range tt.transitions {
+			if _, off := tr.Add(-time.Second).In(loc).Zone(); off != tt.offsets[i] {
+				t.Errorf("%s: offset before %v = %d, want %d", tt.name, tr, off, tt.offsets[i])
+			}
+			if _, off := tr.In(loc).Zone(); off != tt.offsets[i+1] {
+				t.Errorf("%s: offset at %v = %d, want %d", tt.name, tr, off, tt.offsets[i+1])
+			}
+		}
+	}
+
+	loc, err := tziWEurope.Location("W. Europe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := time.Date(2013, 7, 1, 0, 0, 0, 0, loc).Zone(); name != "+02" {
+		t.Errorf("summer abbreviation %q, want +02", name)
+	}
+	if name, _ := time.Date(2013, 1, 1, 0, 0, 0, 0, loc).Zone(); name != "+01" {
+		t.Errorf("winter abbreviation %q, want +01", name)
+	}
+}
+
+func TestDynamicTZLocation(t *testing.T) {
+	var tzi DYNAMIC_TIME_ZONE_INFORMATION
+	tzi.TIME_ZONE_INFORMATION = tziWEurope
+	copy(tzi.TimeZoneKeyName[:], utf16Units("W. Europe Standard Time"))
+
+	loc, err := tzi.Location()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.String() != "Europe/Berlin" {
+		t.Errorf("location named %q, want Europe/Berlin", loc)
+	}
+	if _, off := time.Date(2013, 7, 1, 0, 0, 0, 0, loc).Zone(); off != 7200 {
+		t.Errorf("summer offset %d, want 7200", off)
+	}
+
+	tzi.DynamicDaylightTimeDisabled = 1
+	copy(tzi.TimeZoneKeyName[:], utf16Units("Custom Zone\x00"))
+	if loc, err = tzi.Location(); err != nil {
+		t.Fatal(err)
+	}
+	if loc.String() != "Custom Zone" {
+		t.Errorf("location named %q, want Custom Zone", loc)
+	}
+	if _, off := time.Date(2013, 7, 1, 0, 0, 0, 0, loc).Zone(); off != 3600 {
+		t.Errorf("offset with daylight time disabled %d, want 3600", off)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"unsafe"
+)
+
+// ProcessEntry describes a process in a toolhelp snapshot.
+type ProcessEntry struct {
+	PID          uint32
+	ParentPID    uint32
+	Threads      uint32
+	PriorityBase int32
+	Exe          string
+}
+
+// ThreadEntry describes a thread in a toolhelp snapshot.
+type ThreadEntry struct {
+	TID          uint32
+	OwnerPID     uint32
+	BasePriority int32
+}
+
+// ModuleEntry describes a module loaded into a process.
+type ModuleEntry struct {
+	PID    uint32
+	Name   string
+	Path   string
+	Base   uintptr
+	Size   uint32
+	Handle HMODULE
+}
+
+// snapshot takes a toolhelp snapshot, retrying while it fails with
+// ERROR_BAD_LENGTH, which module snapshots report when the target is
+// loading or unloading modules.
+func snapshot(flags, pid DWORD) (HANDLE, error) {
+	for i := 0; i < 8; i++ {
+		h := CreateToolhelp32Snapshot(flags, pid)
+		if h != INVALID_HANDLE_VALUE {
+			return h, nil
+		}
+		if lastError != ERROR_BAD_LENGTH {
+			break
+		}
+	}
+
+	return 0, lastError
+}
+
+// walkDone converts the error left by a failed First or Next call to
+// the result of a walk.
+func walkDone() error {
+	if lastError == ERROR_NO_MORE_FILES {
+		return nil
+	}
+
+	return lastError
+}
+
+// WalkProcesses calls fn for each running process until fn returns
+// false.
+func WalkProcesses(fn func(p *ProcessEntry) bool) error {
+	h, err := snapshot(TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return err
+	}
+	defer CloseHandle(h)
+
+	var pe PROCESSENTRY32
+	pe.Size = DWORD(unsafe.Sizeof(pe))
+	for ok := Process32First(h, &pe); ok; ok = Process32Next(h, &pe) {
+		p := &ProcessEntry{
+			PID:          uint32(pe.ProcessID),
+			ParentPID:    uint32(pe.ParentProcessID),
+			Threads:      uint32(pe.Threads),
+			PriorityBase: pe.PriClassBase,
+			Exe:          utf16ToString(pe.ExeFile[:]),
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+
+	return walkDone()
+}
+
+// WalkThreads calls fn for each thread of process pid, or of every
+// process if pid is 0, until fn returns false.
+func WalkThreads(pid uint32, fn func(t *ThreadEntry) bool) error {
+	h, err := snapshot(TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer CloseHandle(h)
+
+	var te THREADENTRY32
+	te.Size = DWORD(unsafe.Sizeof(te))
+	for ok := Thread32First(h, &te); ok; ok = Thread32Next(h, &te) {
+		if pid != 0 && uint32(te.OwnerProcessID) != pid {
+			continue
+		}
+		t := &ThreadEntry{
+			TID:          uint32(te.ThreadID),
+			OwnerPID:     uint32(te.OwnerProcessID),
+			BasePriority: te.BasePri,
+		}
+		if !fn(t) {
+			return nil
+		}
+	}
+
+	return walkDone()
+}
+
+// WalkModules calls fn for each module of process pid, 0 meaning the
+// current process, until fn returns false. 32-bit modules of WOW64
+// processes are included.
+func WalkModules(pid uint32, fn func(m *ModuleEntry) bool) error {
+	h, err := snapshot(TH32CS_SNAPMODULE|TH32CS_SNAPMODULE32, DWORD(pid))
+	if err != nil {
+		return err
+	}
+	defer CloseHandle(h)
+
+	var me MODULEENTRY32
+	me.Size = DWORD(unsafe.Sizeof(me))
+	for ok := Module32First(h, &me); ok; ok = Module32Next(h, &me) {
+		m := &ModuleEntry{
+			PID:    uint32(me.ProcessID),
+			Name:   utf16ToString(me.ModuleName[:]),
+			Path:   utf16ToString(me.ExePath[:]),
+			Base:   me.ModBaseAddr,
+			Size:   uint32(me.ModBaseSize),
+			Handle: me.Module,
+		}
+		if !fn(m) {
+			return nil
+		}
+	}
+
+	return walkDone()
+}
+
+// FindProcess returns the snapshot entry of process pid.
+func FindProcess(pid uint32) (*ProcessEntry, error) {
+	var found *ProcessEntry
+	err := WalkProcesses(func(p *ProcessEntry) bool {
+		if p.PID == pid {
+			found = p
+		}
+
+		return found == nil
+	})
+	if err == nil && found == nil {
+		err = ERROR_INVALID_PARAMETER
+	}
+
+	return found, err
+}
+
+// WindowProcess returns the process that owns window h.
+func WindowProcess(h HWND) (*ProcessEntry, error) {
+	var pid DWORD
+	if GetWindowThreadProcessId(h, &pid) == 0 {
+		return nil, lastError
+	}
+
+	return FindProcess(uint32(pid))
+}
+
+// ProcessWindows returns the top-level windows owned by process pid.
+func ProcessWindows(pid uint32) ([]HWND, error) {
+	var windows []HWND
+	ok := EnumWindows(func(h HWND) bool {
+		var owner DWORD
+		GetWindowThreadProcessId(h, &owner)
+		if uint32(owner) == pid {
+			windows = append(windows, h)
+		}
+
+		return true
+	})
+	if !ok {
+		return nil, lastError
+	}
+
+	return windows, nil
+}
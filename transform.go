@@ -0,0 +1,400 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import "math"
+
+// Transform models the coordinate spaces of a device context: the world
+// transform and the page space given by the mapping mode and the window
+// and viewport origins and extents, combined the way GDI combines them.
+// A world point is first mapped to a page point by the world transform,
+// then to a device point as
+//
+//	(page - windowOrg) * viewportExt / windowExt + viewportOrg
+//
+// and rounded to the nearest integer. The metric and English mapping
+// modes derive their extents from the physical size of the device.
+type Transform struct {
+	graphicsMode int32
+	world        XFORM
+
+	mapMode     int32
+	windowOrg   POINT
+	viewportOrg POINT
+	windowExt   SIZE
+	viewportExt SIZE
+
+	// Device size in pixels and millimetres, as returned by
+	// GetDeviceCaps(HORZRES) and GetDeviceCaps(HORZSIZE).
+	res  SIZE
+	size SIZE
+}
+
+// NewTransform returns the MM_TEXT transform of a device horzRes by
+// vertRes pixels and horzSize by vertSize millimetres.
+func NewTransform(horzRes, vertRes, horzSize, vertSize int32) *Transform {
+	return &Transform{
+		graphicsMode: GM_COMPATIBLE,
+		world:        IdentityXFORM(),
+		mapMode:      MM_TEXT,
+		windowExt:    SIZE{1, 1},
+		viewportExt:  SIZE{1, 1},
+		res:          SIZE{horzRes, vertRes},
+		size:         SIZE{horzSize, vertSize},
+	}
+}
+
+// TransformFromDC reads the coordinate spaces of a device context.
+func TransformFromDC(hdc HDC) (*Transform, error) {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		t := *dc.xform
+		return &t, nil
+	}
+
+	t := &Transform{
+		res:  SIZE{GetDeviceCaps(hdc, HORZRES), GetDeviceCaps(hdc, VERTRES)},
+		size: SIZE{GetDeviceCaps(hdc, HORZSIZE), GetDeviceCaps(hdc, VERTSIZE)},
+	}
+	t.graphicsMode = GetGraphicsMode(hdc)
+	t.mapMode = GetMapMode(hdc)
+	if t.graphicsMode == 0 || t.mapMode == 0 || !GetWorldTransform(hdc, &t.world) ||
+		!GetWindowOrgEx(hdc, &t.windowOrg) || !GetViewportOrgEx(hdc, &t.viewportOrg) ||
+		!GetWindowExtEx(hdc, &t.windowExt) || !GetViewportExtEx(hdc, &t.viewportExt) {
+		return nil, lastError
+	}
+
+	return t, nil
+}
+
+func (t *Transform) GraphicsMode() int32 {
+	return t.graphicsMode
+}
+
+// SetGraphicsMode sets the graphics mode and returns the previous one,
+// or 0 on failure. The world transform can only be changed in
+// GM_ADVANCED, and GM_COMPATIBLE can only be restored once it has been
+// reset to the identity.
+func (t *Transform) SetGraphicsMode(mode int32) int32 {
+	old := t.graphicsMode
+	switch mode {
+	case GM_COMPATIBLE:
+		if t.world != IdentityXFORM() {
+			return 0
+		}
+	case GM_ADVANCED:
+	default:
+		return 0
+	}
+	t.graphicsMode = mode
+
+	return old
+}
+
+func (t *Transform) WorldTransform() XFORM {
+	return t.world
+}
+
+// SetWorldTransform sets the world transform. It fails outside
+// GM_ADVANCED and for transforms that cannot be inverted.
+func (t *Transform) SetWorldTransform(x XFORM) bool {
+	if t.graphicsMode != GM_ADVANCED {
+		return false
+	}
+	if _, ok := x.Invert(); !ok {
+		return false
+	}
+	t.world = x
+
+	return true
+}
+
+// ModifyWorldTransform changes the world transform as described by
+// mode, one of the MWT_ constants. With MWT_LEFTMULTIPLY x is applied
+// before the current transform, with MWT_RIGHTMULTIPLY after it.
+func (t *Transform) ModifyWorldTransform(x XFORM, mode DWORD) bool {
+	switch mode {
+	case MWT_IDENTITY:
+		if t.graphicsMode != GM_ADVANCED {
+			return false
+		}
+		t.world = IdentityXFORM()
+		return true
+	case MWT_LEFTMULTIPLY:
+		return t.SetWorldTransform(x.Multiply(t.world))
+	case MWT_RIGHTMULTIPLY:
+		return t.SetWorldTransform(t.world.Multiply(x))
+	}
+
+	return false
+}
+
+func (t *Transform) MapMode() int32 {
+	return t.mapMode
+}
+
+// SetMapMode sets the mapping mode and returns the previous one, or 0 if
+// mode is not valid. All modes except MM_ANISOTROPIC reset the extents;
+// setting MM_ISOTROPIC or MM_ANISOTROPIC again keeps them.
+func (t *Transform) SetMapMode(mode int32) int32 {
+	old := t.mapMode
+	if mode == old && (mode == MM_ISOTROPIC || mode == MM_ANISOTROPIC) {
+		return old
+	}
+
+	switch mode {
+	case MM_TEXT:
+		t.windowExt = SIZE{1, 1}
+		t.viewportExt = SIZE{1, 1}
+	case MM_LOMETRIC, MM_ISOTROPIC:
+		t.setMetricExt(10, 1)
+	case MM_HIMETRIC:
+		t.setMetricExt(100, 1)
+	case MM_LOENGLISH:
+		t.setMetricExt(1000, 254)
+	case MM_HIENGLISH:
+		t.setMetricExt(10000, 254)
+	case MM_TWIPS:
+		t.setMetricExt(14400, 254)
+	case MM_ANISOTROPIC:
+	default:
+		return 0
+	}
+	t.mapMode = mode
+
+	return old
+}
+
+// setMetricExt maps the physical size of the device, scaled by num/den
+// logical units per millimetre, onto its resolution with y growing up.
+func (t *Transform) setMetricExt(num, den int32) {
+	t.windowExt = SIZE{mulDiv(t.size.Cx, num, den), mulDiv(t.size.Cy, num, den)}
+	t.viewportExt = SIZE{t.res.Cx, -t.res.Cy}
+}
+
+func (t *Transform) WindowOrg() POINT {
+	return t.windowOrg
+}
+
+func (t *Transform) ViewportOrg() POINT {
+	return t.viewportOrg
+}
+
+func (t *Transform) WindowExt() SIZE {
+	return t.windowExt
+}
+
+func (t *Transform) ViewportExt() SIZE {
+	return t.viewportExt
+}
+
+// SetWindowOrg sets the window origin and returns the previous one.
+func (t *Transform) SetWindowOrg(x, y int32) POINT {
+	old := t.windowOrg
+	t.windowOrg = POINT{x, y}
+
+	return old
+}
+
+// SetViewportOrg sets the viewport origin and returns the previous one.
+func (t *Transform) SetViewportOrg(x, y int32) POINT {
+	old := t.viewportOrg
+	t.viewportOrg = POINT{x, y}
+
+	return old
+}
+
+// OffsetWindowOrg moves the window origin and returns the previous one.
+func (t *Transform) OffsetWindowOrg(dx, dy int32) POINT {
+	return t.SetWindowOrg(t.windowOrg.X+dx, t.windowOrg.Y+dy)
+}
+
+// OffsetViewportOrg moves the viewport origin and returns the previous
+// one.
+func (t *Transform) OffsetViewportOrg(dx, dy int32) POINT {
+	return t.SetViewportOrg(t.viewportOrg.X+dx, t.viewportOrg.Y+dy)
+}
+
+// SetWindowExt sets the window extents and returns the previous ones.
+// The extents can only be changed in MM_ISOTROPIC and MM_ANISOTROPIC
+// and are ignored in other modes; zero extents are rejected.
+func (t *Transform) SetWindowExt(cx, cy int32) (SIZE, bool) {
+	return t.setExt(&t.windowExt, cx, cy)
+}
+
+// SetViewportExt sets the viewport extents like SetWindowExt. In
+// MM_ISOTROPIC one of them is then reduced to keep logical units square
+// on the device.
+func (t *Transform) SetViewportExt(cx, cy int32) (SIZE, bool) {
+	return t.setExt(&t.viewportExt, cx, cy)
+}
+
+func (t *Transform) setExt(ext *SIZE, cx, cy int32) (SIZE, bool) {
+	old := *ext
+	if t.mapMode != MM_ISOTROPIC && t.mapMode != MM_ANISOTROPIC {
+		return old, true
+	}
+	if cx == 0 || cy == 0 {
+		return old, false
+	}
+
+	*ext = SIZE{cx, cy}
+	t.fixIsotropic()
+
+	return old, true
+}
+
+// ScaleWindowExt multiplies the window extents by xn/xd and yn/yd and
+// returns the previous ones. It has the same restrictions as
+// SetWindowExt.
+func (t *Transform) ScaleWindowExt(xn, xd, yn, yd int32) (SIZE, bool) {
+	return t.scaleExt(&t.windowExt, xn, xd, yn, yd)
+}
+
+// ScaleViewportExt multiplies the viewport extents like ScaleWindowExt.
+func (t *Transform) ScaleViewportExt(xn, xd, yn, yd int32) (SIZE, bool) {
+	return t.scaleExt(&t.viewportExt, xn, xd, yn, yd)
+}
+
+func (t *Transform) scaleExt(ext *SIZE, xn, xd, yn, yd int32) (SIZE, bool) {
+	old := *ext
+	if t.mapMode != MM_ISOTROPIC && t.mapMode != MM_ANISOTROPIC {
+		return old, true
+	}
+	if xn == 0 || xd == 0 || yn == 0 || yd == 0 {
+		return old, false
+	}
+
+	ext.Cx = ext.Cx * xn / xd
+	ext.Cy = ext.Cy * yn / yd
+	if ext.Cx == 0 {
+		ext.Cx = 1
+	}
+	if ext.Cy == 0 {
+		ext.Cy = 1
+	}
+	t.fixIsotropic()
+
+	return old, true
+}
+
+// fixIsotropic shrinks the viewport extent on the axis where a logical
+// unit is physically larger, so units have the same size on both axes.
+func (t *Transform) fixIsotropic() {
+	if t.mapMode != MM_ISOTROPIC {
+		return
+	}
+
+	xdim := math.Abs(float64(t.viewportExt.Cx) * float64(t.size.Cx) /
+		(float64(t.res.Cx) * float64(t.windowExt.Cx)))
+	ydim := math.Abs(float64(t.viewportExt.Cy) * float64(t.size.Cy) /
+		(float64(t.res.Cy) * float64(t.windowExt.Cy)))
+
+	if xdim > ydim {
+		t.viewportExt.Cx = fixIsotropicExt(t.viewportExt.Cx, ydim/xdim)
+	} else {
+		t.viewportExt.Cy = fixIsotropicExt(t.viewportExt.Cy, xdim/ydim)
+	}
+}
+
+func fixIsotropicExt(ext int32, scale float64) int32 {
+	v := gdiRound(float64(ext) * scale)
+	if v == 0 {
+		if ext < 0 {
+			return -1
+		}
+		return 1
+	}
+
+	return v
+}
+
+// LPtoDP converts logical (world) points to device points in place.
+func (t *Transform) LPtoDP(pts []POINT) {
+	t.matrix().apply(pts)
+}
+
+// DPtoLP converts device points to logical (world) points in place.
+func (t *Transform) DPtoLP(pts []POINT) {
+	m, _ := t.matrix().invert()
+	m.apply(pts)
+}
+
+// matrix returns the world to device mapping.
+func (t *Transform) matrix() affine {
+	return t.world.affine().then(t.pageMatrix())
+}
+
+// pageMatrix returns the page to device mapping.
+func (t *Transform) pageMatrix() affine {
+	sx := float64(t.viewportExt.Cx) / float64(t.windowExt.Cx)
+	sy := float64(t.viewportExt.Cy) / float64(t.windowExt.Cy)
+
+	return affine{
+		sx, 0,
+		0, sy,
+		float64(t.viewportOrg.X) - sx*float64(t.windowOrg.X),
+		float64(t.viewportOrg.Y) - sy*float64(t.windowOrg.Y),
+	}
+}
+
+// affine is a 2D transform laid out like XFORM: a point maps to
+// (x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]).
+type affine [6]float64
+
+func (m affine) apply(pts []POINT) {
+	for i, p := range pts {
+		x, y := float64(p.X), float64(p.Y)
+		pts[i] = POINT{
+			gdiRound(x*m[0] + y*m[2] + m[4]),
+			gdiRound(x*m[1] + y*m[3] + m[5]),
+		}
+	}
+}
+
+// then returns the transform applying m, then n.
+func (m affine) then(n affine) affine {
+	return affine{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// invert returns the inverse of m, or false if m is singular.
+func (m affine) invert() (affine, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-12 {
+		return affine{}, false
+	}
+
+	var r affine
+	r[0] = m[3] / det
+	r[1] = -m[1] / det
+	r[2] = -m[2] / det
+	r[3] = m[0] / det
+	r[4] = -m[4]*r[0] - m[5]*r[2]
+	r[5] = -m[4]*r[1] - m[5]*r[3]
+
+	return r, true
+}
+
+// gdiRound rounds halfway cases up, as GDI does when mapping points.
+func gdiRound(v float64) int32 {
+	return int32(math.Floor(v + 0.5))
+}
+
+// mulDiv returns a*b/c rounded to the nearest integer, like MulDiv.
+func mulDiv(a, b, c int32) int32 {
+	n := int64(a) * int64(b)
+	d := int64(c)
+	if (n < 0) != (d < 0) {
+		return int32((n - d/2) / d)
+	}
+
+	return int32((n + d/2) / d)
+}
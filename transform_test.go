@@ -0,0 +1,154 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import "testing"
+
+func TestGdiRound(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want int32
+	}{
+		{0, 0},
+		{0.4, 0},
+		{0.5, 1},
+		{2.5, 3},
+		{-0.4, 0},
+		{-0.5, 0},
+		{-0.6, -1},
+		{-1.5, -1},
+		{-2.5, -2},
+		{-2.51, -3},
+	}
+	for _, tt := range tests {
+		if got := gdiRound(tt.v); got != tt.want {
+			t.Errorf("gdiRound(%v) = %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		a, b, c int32
+		want    int32
+	}{
+		{6, 4, 3, 8},
+		{3, 1, 2, 2},
+		{-3, 1, 2, -2},
+		{5, 1, 4, 1},
+		{-5, 1, 4, -1},
+		{7, -1, 2, -4},
+		{-7, 1, -2, 4},
+		{1 << 30, 6, 8, 3 << 28},
+		{-1 << 30, 6, 8, -3 << 28},
+	}
+	for _, tt := range tests {
+		if got := mulDiv(tt.a, tt.b, tt.c); got != tt.want {
+			t.Errorf("mulDiv(%d, %d, %d) = %d, want %d", tt.a, tt.b, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestTransformSetMapMode(t *testing.T) {
+	// A device of 1000 by 500 pixels and 250 by 125 millimetres.
+	tests := []struct {
+		mode                   int32
+		windowExt, viewportExt SIZE
+	}{
+		{MM_TEXT, SIZE{1, 1}, SIZE{1, 1}},
+		{MM_LOMETRIC, SIZE{2500, 1250}, SIZE{1000, -500}},
+		{MM_HIMETRIC, SIZE{25000, 12500}, SIZE{1000, -500}},
+		{MM_LOENGLISH, SIZE{984, 492}, SIZE{1000, -500}},
+		{MM_HIENGLISH, SIZE{9843, 4921}, SIZE{1000, -500}},
+		{MM_TWIPS, SIZE{14173, 7087}, SIZE{1000, -500}},
+		{MM_ISOTROPIC, SIZE{2500, 1250}, SIZE{1000, -500}},
+		{MM_ANISOTROPIC, SIZE{1, 1}, SIZE{1, 1}},
+	}
+	for _, tt := range tests {
+		tr := NewTransform(1000, 500, 250, 125)
+		if old := tr.SetMapMode(tt.mode); old != MM_TEXT {
+			t.Errorf("SetMapMode(%d) returned %d, want MM_TEXT", tt.mode, old)
+		}
+		if got := tr.WindowExt(); got != tt.windowExt {
+			t.Errorf("mode %d: window extents %v, want %v", tt.mode, got, tt.windowExt)
+		}
+		if got := tr.ViewportExt(); got != tt.viewportExt {
+			t.Errorf("mode %d: viewport extents %v, want %v", tt.mode, got, tt.viewportExt)
+		}
+	}
+
+	tr := NewTransform(1000, 500, 250, 125)
+	if old := tr.SetMapMode(0); old != 0 || tr.MapMode() != MM_TEXT {
+		t.Errorf("SetMapMode(0) returned %d and set mode %d", old, tr.MapMode())
+	}
+
+	// Setting MM_ANISOTROPIC again keeps the extents; the extents of
+	// other modes cannot be changed.
+	tr.SetMapMode(MM_ANISOTROPIC)
+	tr.SetWindowExt(3, 7)
+	tr.SetMapMode(MM_ANISOTROPIC)
+	if got := tr.WindowExt(); got != (SIZE{3, 7}) {
+		t.Errorf("window extents after MM_ANISOTROPIC again = %v, want {3 7}", got)
+	}
+	tr.SetMapMode(MM_LOMETRIC)
+	if _, ok := tr.SetWindowExt(3, 7); !ok || tr.WindowExt() != (SIZE{2500, 1250}) {
+		t.Errorf("SetWindowExt in MM_LOMETRIC changed the extents to %v", tr.WindowExt())
+	}
+}
+
+func TestTransformIsotropic(t *testing.T) {
+	tests := []struct {
+		name                   string
+		res, size              SIZE
+		windowExt, viewportExt SIZE
+		want                   SIZE
+	}{
+		{"square pixels", SIZE{1000, 500}, SIZE{250, 125}, SIZE{100, 100}, SIZE{400, 100}, SIZE{100, 100}},
+		{"square pixels y", SIZE{1000, 500}, SIZE{250, 125}, SIZE{100, 100}, SIZE{100, 400}, SIZE{100, 100}},
+		{"negative", SIZE{1000, 500}, SIZE{250, 125}, SIZE{100, 100}, SIZE{400, -100}, SIZE{100, -100}},
+		{"tall pixels", SIZE{1000, 500}, SIZE{250, 250}, SIZE{100, 100}, SIZE{100, 100}, SIZE{100, 50}},
+		{"wide window", SIZE{1000, 500}, SIZE{250, 125}, SIZE{200, 100}, SIZE{100, 100}, SIZE{100, 50}},
+		{"clamped", SIZE{1000, 500}, SIZE{250, 125}, SIZE{1000, 1}, SIZE{1, -1}, SIZE{1, -1}},
+	}
+	for _, tt := range tests {
+		tr := NewTransform(tt.res.Cx, tt.res.Cy, tt.size.Cx, tt.size.Cy)
+		tr.SetMapMode(MM_ISOTROPIC)
+		tr.SetWindowExt(tt.windowExt.Cx, tt.windowExt.Cy)
+		tr.SetViewportExt(tt.viewportExt.Cx, tt.viewportExt.Cy)
+		if got := tr.ViewportExt(); got != tt.want {
+			t.Errorf("%s: viewport extents %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	tr := NewTransform(1000, 500, 250, 125)
+	tr.SetMapMode(MM_ISOTROPIC)
+	if _, ok := tr.SetViewportExt(0, 10); ok {
+		t.Error("SetViewportExt accepted a zero extent")
+	}
+}
+
+func TestTransformLPtoDP(t *testing.T) {
+	tr := NewTransform(1000, 500, 250, 125)
+	tr.SetMapMode(MM_ANISOTROPIC)
+	tr.SetWindowExt(2, 2)
+	tr.SetViewportExt(1, -1)
+	tr.SetViewportOrg(10, 20)
+
+	// Halfway cases round up, also for negative results.
+	pts := []POINT{{0, 0}, {1, 1}, {-1, -1}, {-3, 3}, {4, -4}}
+	tr.LPtoDP(pts)
+	want := []POINT{{10, 20}, {11, 20}, {10, 21}, {9, 19}, {12, 22}}
+	for i := range pts {
+		if pts[i] != want[i] {
+			t.Errorf("LPtoDP point %d = %v, want %v", i, pts[i], want[i])
+		}
+	}
+
+	dev := []POINT{{12, 22}, {9, 19}}
+	tr.DPtoLP(dev)
+	if dev[0] != (POINT{4, -4}) || dev[1] != (POINT{-2, 2}) {
+		t.Errorf("DPtoLP = %v, want [{4 -4} {-2 2}]", dev)
+	}
+}
@@ -5,26 +5,31 @@
 package winapi
 
 type (
-	HANDLE    uintptr
-	HWND      HANDLE
-	HMENU     HANDLE
-	HMODULE   HANDLE
-	HINSTANCE HANDLE
-	HDC       HANDLE
-	HRGN      HANDLE
-	HBRUSH    HANDLE
-	HICON     HANDLE
-	HCURSOR   HANDLE
-	HPEN      HANDLE
-	HPALETTE  HANDLE
-	HBITMAP   HANDLE
-	HFONT     HANDLE
-	WPARAM    uintptr
-	LPARAM    uintptr
-	UINT      uint32
-	BOOL      int32
-	DWORD     uint32
-	LRESULT   int
-	COLORREF  uint32
-	LANGID    uint16
+	HANDLE       uintptr
+	HWND         HANDLE
+	HMENU        HANDLE
+	HMODULE      HANDLE
+	HINSTANCE    HANDLE
+	HDC          HANDLE
+	HRGN         HANDLE
+	HBRUSH       HANDLE
+	HICON        HANDLE
+	HCURSOR      HANDLE
+	HPEN         HANDLE
+	HPALETTE     HANDLE
+	HBITMAP      HANDLE
+	HFONT        HANDLE
+	HENHMETAFILE HANDLE
+	HMETAFILE    HANDLE
+	HGLOBAL      HANDLE
+	HGDIOBJ      HANDLE
+	WPARAM       uintptr
+	LPARAM       uintptr
+	UINT         uint32
+	WORD         uint16
+	BOOL         int32
+	DWORD        uint32
+	LRESULT      int
+	COLORREF     uint32
+	LANGID       uint16
 )
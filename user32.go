@@ -5,45 +5,48 @@
 package winapi
 
 import (
-	"syscall"
 	"unicode/utf16"
 	"unsafe"
 )
 
 var (
-	modUser32              = syscall.NewLazyDLL("user32.dll")
-	procBeginPaint         = modUser32.NewProc("BeginPaint")
-	procCreateDialogParam  = modUser32.NewProc("CreateDialogParamW")
-	procCreateWindowEx     = modUser32.NewProc("CreateWindowExW")
-	procDefWindowProc      = modUser32.NewProc("DefWindowProcW")
-	procDestroyWindow      = modUser32.NewProc("DestroyWindow")
-	procDialogBoxParam     = modUser32.NewProc("DialogBoxParamW")
-	procDispatchMessage    = modUser32.NewProc("DispatchMessageW")
-	procEndDialog          = modUser32.NewProc("EndDialog")
-	procEndPaint           = modUser32.NewProc("EndPaint")
-	procGetDC              = modUser32.NewProc("GetDC")
-	procGetDlgItem         = modUser32.NewProc("GetDlgItem")
-	procGetMessage         = modUser32.NewProc("GetMessageW")
-	procGetWindowLong      = modUser32.NewProc("GetWindowLongW")
-	procGetWindowLongPtr   = modUser32.NewProc("GetWindowLongPtrW")
-	procLoadCursor         = modUser32.NewProc("LoadCursorW")
-	procLoadIcon           = modUser32.NewProc("LoadIconW")
-	procLoadMenu           = modUser32.NewProc("LoadMenuW")
-	procLoadString         = modUser32.NewProc("LoadStringW")
-	procMessageBox         = modUser32.NewProc("MessageBoxW")
-	procUnregisterClass    = modUser32.NewProc("UnregisterClassW")
-	procPostMessage        = modUser32.NewProc("PostMessageW")
-	procPostQuitMessage    = modUser32.NewProc("PostQuitMessage")
-	procRegisterClassEx    = modUser32.NewProc("RegisterClassExW")
-	procReleaseDC          = modUser32.NewProc("ReleaseDC")
-	procSendMessage        = modUser32.NewProc("SendMessageW")
-	procSendDlgItemMessage = modUser32.NewProc("SendDlgItemMessageW")
-	procSetMenu            = modUser32.NewProc("SetMenu")
-	procSetWindowLong      = modUser32.NewProc("SetWindowLongW")
-	procSetWindowLongPtr   = modUser32.NewProc("SetWindowLongPtrW")
-	procShowWindow         = modUser32.NewProc("ShowWindow")
-	procTranslateMessage   = modUser32.NewProc("TranslateMessage")
-	procUpdateWindow       = modUser32.NewProc("UpdateWindow")
+	modUser32                    = newLazyDLL("user32.dll")
+	procBeginPaint               = modUser32.NewProc("BeginPaint")
+	procCreateDialogParam        = modUser32.NewProc("CreateDialogParamW")
+	procCreateWindowEx           = modUser32.NewProc("CreateWindowExW")
+	procDefWindowProc            = modUser32.NewProc("DefWindowProcW")
+	procDestroyWindow            = modUser32.NewProc("DestroyWindow")
+	procDialogBoxParam           = modUser32.NewProc("DialogBoxParamW")
+	procDispatchMessage          = modUser32.NewProc("DispatchMessageW")
+	procEndDialog                = modUser32.NewProc("EndDialog")
+	procEndPaint                 = modUser32.NewProc("EndPaint")
+	procGetDC                    = modUser32.NewProc("GetDC")
+	procGetDlgItem               = modUser32.NewProc("GetDlgItem")
+	procGetMessage               = modUser32.NewProc("GetMessageW")
+	procGetWindowLong            = modUser32.NewProc("GetWindowLongW")
+	procGetWindowLongPtr         = modUser32.NewProc("GetWindowLongPtrW")
+	procLoadCursor               = modUser32.NewProc("LoadCursorW")
+	procLoadIcon                 = modUser32.NewProc("LoadIconW")
+	procLoadMenu                 = modUser32.NewProc("LoadMenuW")
+	procLoadString               = modUser32.NewProc("LoadStringW")
+	procMessageBox               = modUser32.NewProc("MessageBoxW")
+	procUnregisterClass          = modUser32.NewProc("UnregisterClassW")
+	procPostMessage              = modUser32.NewProc("PostMessageW")
+	procPostQuitMessage          = modUser32.NewProc("PostQuitMessage")
+	procRegisterClassEx          = modUser32.NewProc("RegisterClassExW")
+	procReleaseDC                = modUser32.NewProc("ReleaseDC")
+	procSendMessage              = modUser32.NewProc("SendMessageW")
+	procSendDlgItemMessage       = modUser32.NewProc("SendDlgItemMessageW")
+	procSetMenu                  = modUser32.NewProc("SetMenu")
+	procSetWindowLong            = modUser32.NewProc("SetWindowLongW")
+	procSetWindowLongPtr         = modUser32.NewProc("SetWindowLongPtrW")
+	procShowWindow               = modUser32.NewProc("ShowWindow")
+	procTranslateMessage         = modUser32.NewProc("TranslateMessage")
+	procUpdateWindow             = modUser32.NewProc("UpdateWindow")
+	procEnumWindows              = modUser32.NewProc("EnumWindows")
+	procGetWindowThreadProcessId = modUser32.NewProc("GetWindowThreadProcessId")
+	procDrawTextEx               = modUser32.NewProc("DrawTextExW")
+	procInvalidateRect           = modUser32.NewProc("InvalidateRect")
 )
 
 var is64Bit bool = false
@@ -176,6 +179,27 @@ func GetMessage(m *WinMSG, h HWND, min, max UINT) bool {
 	return PtrToBool(ret)
 }
 
+var enumWindowsCallback = newCallback(func(h HWND, id uintptr) uintptr {
+	fn, _ := lookupCallback(id).(func(HWND) bool)
+	if fn == nil {
+		return 0
+	}
+
+	return BoolToPtr(fn(h))
+})
+
+// EnumWindows calls fn for each top-level window until fn returns
+// false.
+func EnumWindows(fn func(h HWND) bool) bool {
+	id := registerCallback(fn)
+	defer unregisterCallback(id)
+
+	var ret uintptr
+	ret, _, lastError = procEnumWindows.Call(enumWindowsCallback, id)
+
+	return PtrToBool(ret)
+}
+
 func GetWindowLongPtr(h HWND, index int) (ret uintptr) {
 	if is64Bit {
 		ret, _, _ = procGetWindowLongPtr.Call(uintptr(h), uintptr(index))
@@ -186,6 +210,25 @@ func GetWindowLongPtr(h HWND, index int) (ret uintptr) {
 	return
 }
 
+// GetWindowThreadProcessId returns the id of the thread that created the
+// window and stores the id of its process in pid if it is not nil.
+func GetWindowThreadProcessId(h HWND, pid *DWORD) DWORD {
+	var ret uintptr
+	ret, _, lastError = procGetWindowThreadProcessId.Call(uintptr(h),
+		uintptr(unsafe.Pointer(pid)))
+
+	return DWORD(ret)
+}
+
+// InvalidateRect adds rc, or the whole client area if rc is nil, to
+// the update region of h.
+func InvalidateRect(h HWND, rc *RECT, erase bool) bool {
+	var ret uintptr
+	ret, _, lastError = procInvalidateRect.Call(uintptr(h), uintptr(unsafe.Pointer(rc)), BoolToPtr(erase))
+
+	return PtrToBool(ret)
+}
+
 func LoadCursor(instRes HINSTANCE, name string) HCURSOR {
 	var ret uintptr
 	ret, _, lastError = procLoadCursor.Call(uintptr(instRes), resourceNameToPtr(name))
@@ -307,6 +350,35 @@ func ReleaseDC(h HWND, hdc HDC) bool {
 	return ret == 1
 }
 
+// DrawText draws text formatted in rc with the DT_ flags of format and
+// returns the height of the text. With DT_CALCRECT it only measures the
+// text and sets rc to its extent. LayoutText describes the formatting.
+func DrawText(hdc HDC, text string, rc *RECT, format UINT) int32 {
+	return DrawTextEx(hdc, text, rc, format, nil)
+}
+
+// DrawTextEx is like DrawText with the margins and tab length of dtp,
+// which may be nil. The text is passed in a private buffer, so
+// DT_MODIFYSTRING has no visible effect.
+func DrawTextEx(hdc HDC, text string, rc *RECT, format UINT, dtp *DRAWTEXTPARAMS) int32 {
+	if dc := lookupSoftDC(hdc); dc != nil {
+		return dc.drawText(text, rc, format, dtp)
+	}
+
+	if dtp != nil {
+		dtp.CbSize = UINT(unsafe.Sizeof(*dtp))
+	}
+
+	// DT_MODIFYSTRING may add up to four characters and a NUL.
+	u := utf16Units(text)
+	buf := append(u, 0, 0, 0, 0, 0)
+
+	var ret uintptr
+	ret, _, lastError = procDrawTextEx.Call(uintptr(hdc), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(u)), uintptr(unsafe.Pointer(rc)), uintptr(format), uintptr(unsafe.Pointer(dtp)))
+
+	return int32(ret)
+}
+
 func SendMessage(m *MSG) LRESULT {
 	var ret uintptr
 	ret, _, lastError = procSendMessage.Call(uintptr(m.HWnd), uintptr(m.Msg),
@@ -1066,6 +1138,72 @@ const (
 	GWLP_USERDATA   = -21
 )
 
+// Standard clipboard formats
+const (
+	CF_TEXT            = 1
+	CF_BITMAP          = 2
+	CF_METAFILEPICT    = 3
+	CF_SYLK            = 4
+	CF_DIF             = 5
+	CF_TIFF            = 6
+	CF_OEMTEXT         = 7
+	CF_DIB             = 8
+	CF_PALETTE         = 9
+	CF_PENDATA         = 10
+	CF_RIFF            = 11
+	CF_WAVE            = 12
+	CF_UNICODETEXT     = 13
+	CF_ENHMETAFILE     = 14
+	CF_HDROP           = 15
+	CF_LOCALE          = 16
+	CF_DIBV5           = 17
+	CF_OWNERDISPLAY    = 0x0080
+	CF_DSPTEXT         = 0x0081
+	CF_DSPBITMAP       = 0x0082
+	CF_DSPMETAFILEPICT = 0x0083
+	CF_DSPENHMETAFILE  = 0x008E
+	CF_PRIVATEFIRST    = 0x0200
+	CF_PRIVATELAST     = 0x02FF
+	CF_GDIOBJFIRST     = 0x0300
+	CF_GDIOBJLAST      = 0x03FF
+)
+
 const (
   HWND_BROADCAST  = HWND(0xffff)
 )
+
+type DRAWTEXTPARAMS struct {
+	CbSize        UINT
+	ITabLength    int32
+	ILeftMargin   int32
+	IRightMargin  int32
+	UiLengthDrawn UINT
+}
+
+// DrawText formats
+const (
+	DT_TOP                  = 0x00000000
+	DT_LEFT                 = 0x00000000
+	DT_CENTER               = 0x00000001
+	DT_RIGHT                = 0x00000002
+	DT_VCENTER              = 0x00000004
+	DT_BOTTOM               = 0x00000008
+	DT_WORDBREAK            = 0x00000010
+	DT_SINGLELINE           = 0x00000020
+	DT_EXPANDTABS           = 0x00000040
+	DT_TABSTOP              = 0x00000080
+	DT_NOCLIP               = 0x00000100
+	DT_EXTERNALLEADING      = 0x00000200
+	DT_CALCRECT             = 0x00000400
+	DT_NOPREFIX             = 0x00000800
+	DT_INTERNAL             = 0x00001000
+	DT_EDITCONTROL          = 0x00002000
+	DT_PATH_ELLIPSIS        = 0x00004000
+	DT_END_ELLIPSIS         = 0x00008000
+	DT_MODIFYSTRING         = 0x00010000
+	DT_RTLREADING           = 0x00020000
+	DT_WORD_ELLIPSIS        = 0x00040000
+	DT_NOFULLWIDTHCHARBREAK = 0x00080000
+	DT_HIDEPREFIX           = 0x00100000
+	DT_PREFIXONLY           = 0x00200000
+)
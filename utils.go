@@ -6,7 +6,8 @@ package winapi
 
 import (
 	"strconv"
-	"syscall"
+	"sync"
+	"unicode/utf16"
 	"unsafe"
 )
 
@@ -15,7 +16,9 @@ func StringToUintptr(v string) uintptr {
 		return 0
 	}
 
-	return uintptr(unsafe.Pointer(syscall.StringToUTF16Ptr(v)))
+	u := append(utf16Units(v), 0)
+
+	return uintptr(unsafe.Pointer(&u[0]))
 }
 
 func UintptrToString(v uintptr) string {
@@ -23,13 +26,34 @@ func UintptrToString(v uintptr) string {
 		return ""
 	}
 
-	return syscall.UTF16ToString((*[1 << 29]uint16)(unsafe.Pointer(v))[0:])
+	return utf16ToString((*[1 << 29]uint16)(unsafe.Pointer(v))[0:])
 }
 
 func UTF16PtrToString(v *uint16) string {
 	return UintptrToString(uintptr(unsafe.Pointer(v)))
 }
 
+// utf16ToString returns the text of s up to its first NUL.
+func utf16ToString(s []uint16) string {
+	for i, c := range s {
+		if c == 0 {
+			s = s[:i]
+			break
+		}
+	}
+
+	return string(utf16.Decode(s))
+}
+
+// bytesAt returns a slice over n bytes of memory owned by Windows.
+func bytesAt(addr uintptr, n int) []byte {
+	if addr == 0 || n == 0 {
+		return nil
+	}
+
+	return unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), n)
+}
+
 func PtrToBool(v uintptr) (ret bool) {
 	if int(v) > 0 {
 		ret = true
@@ -80,3 +104,36 @@ func resourceNameToPtr(name string) uintptr {
 func ResourceIdToName(id int) string {
 	return strconv.Itoa(id)
 }
+
+// Windows callbacks created with syscall.NewCallback are never freed, so
+// each kind of callback is created once and finds the Go function to
+// run through an id passed in its LPARAM.
+var (
+	callbackMutex sync.Mutex
+	callbackFuncs = map[uintptr]interface{}{}
+	callbackLast  uintptr
+)
+
+func registerCallback(fn interface{}) uintptr {
+	callbackMutex.Lock()
+	defer callbackMutex.Unlock()
+
+	callbackLast++
+	callbackFuncs[callbackLast] = fn
+
+	return callbackLast
+}
+
+func lookupCallback(id uintptr) interface{} {
+	callbackMutex.Lock()
+	defer callbackMutex.Unlock()
+
+	return callbackFuncs[id]
+}
+
+func unregisterCallback(id uintptr) {
+	callbackMutex.Lock()
+	defer callbackMutex.Unlock()
+
+	delete(callbackFuncs, id)
+}
@@ -0,0 +1,128 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"unsafe"
+)
+
+var (
+	modVersion                  = newLazyDLL("version.dll")
+	procGetFileVersionInfoSizeW = modVersion.NewProc("GetFileVersionInfoSizeW")
+	procGetFileVersionInfoW     = modVersion.NewProc("GetFileVersionInfoW")
+	procVerQueryValueW          = modVersion.NewProc("VerQueryValueW")
+)
+
+type VS_FIXEDFILEINFO struct {
+	Signature        DWORD
+	StrucVersion     DWORD
+	FileVersionMS    DWORD
+	FileVersionLS    DWORD
+	ProductVersionMS DWORD
+	ProductVersionLS DWORD
+	FileFlagsMask    DWORD
+	FileFlags        DWORD
+	FileOS           DWORD
+	FileType         DWORD
+	FileSubtype      DWORD
+	FileDateMS       DWORD
+	FileDateLS       DWORD
+}
+
+// VS_FIXEDFILEINFO constants
+const (
+	VS_FFI_SIGNATURE     = 0xFEEF04BD
+	VS_FFI_STRUCVERSION  = 0x00010000
+	VS_FFI_FILEFLAGSMASK = 0x0000003F
+)
+
+// VS_FIXEDFILEINFO.FileFlags
+const (
+	VS_FF_DEBUG        = 0x01
+	VS_FF_PRERELEASE   = 0x02
+	VS_FF_PATCHED      = 0x04
+	VS_FF_PRIVATEBUILD = 0x08
+	VS_FF_INFOINFERRED = 0x10
+	VS_FF_SPECIALBUILD = 0x20
+)
+
+// VS_FIXEDFILEINFO.FileOS
+const (
+	VOS_UNKNOWN       = 0x00000000
+	VOS_DOS           = 0x00010000
+	VOS_OS216         = 0x00020000
+	VOS_OS232         = 0x00030000
+	VOS_NT            = 0x00040000
+	VOS__WINDOWS16    = 0x00000001
+	VOS__PM16         = 0x00000002
+	VOS__PM32         = 0x00000003
+	VOS__WINDOWS32    = 0x00000004
+	VOS_DOS_WINDOWS16 = 0x00010001
+	VOS_DOS_WINDOWS32 = 0x00010004
+	VOS_NT_WINDOWS32  = 0x00040004
+)
+
+// VS_FIXEDFILEINFO.FileType
+const (
+	VFT_UNKNOWN    = 0
+	VFT_APP        = 1
+	VFT_DLL        = 2
+	VFT_DRV        = 3
+	VFT_FONT       = 4
+	VFT_VXD        = 5
+	VFT_STATIC_LIB = 7
+)
+
+// VS_FIXEDFILEINFO.FileSubtype for VFT_DRV and VFT_FONT
+const (
+	VFT2_UNKNOWN               = 0x00
+	VFT2_DRV_PRINTER           = 0x01
+	VFT2_DRV_KEYBOARD          = 0x02
+	VFT2_DRV_LANGUAGE          = 0x03
+	VFT2_DRV_DISPLAY           = 0x04
+	VFT2_DRV_MOUSE             = 0x05
+	VFT2_DRV_NETWORK           = 0x06
+	VFT2_DRV_SYSTEM            = 0x07
+	VFT2_DRV_INSTALLABLE       = 0x08
+	VFT2_DRV_SOUND             = 0x09
+	VFT2_DRV_COMM              = 0x0A
+	VFT2_DRV_VERSIONED_PRINTER = 0x0C
+	VFT2_FONT_RASTER           = 0x01
+	VFT2_FONT_VECTOR           = 0x02
+	VFT2_FONT_TRUETYPE         = 0x03
+)
+
+func GetFileVersionInfoSize(filename string, handle *DWORD) DWORD {
+	var ret uintptr
+	ret, _, lastError = procGetFileVersionInfoSizeW.Call(StringToUintptr(filename), uintptr(unsafe.Pointer(handle)))
+
+	return DWORD(ret)
+}
+
+func GetFileVersionInfo(filename string, handle DWORD, data []byte) bool {
+	if len(data) == 0 {
+		lastError = ERROR_INVALID_PARAMETER
+		return false
+	}
+
+	var ret uintptr
+	ret, _, lastError = procGetFileVersionInfoW.Call(StringToUintptr(filename), uintptr(handle), uintptr(len(data)), uintptr(unsafe.Pointer(&data[0])))
+
+	return PtrToBool(ret)
+}
+
+// VerQueryValue looks up subBlock, e.g. `\StringFileInfo\040904b0\FileVersion`,
+// in a block returned by GetFileVersionInfo. The result points into block.
+func VerQueryValue(block []byte, subBlock string, buffer *uintptr, length *UINT) bool {
+	if len(block) == 0 {
+		lastError = ERROR_INVALID_PARAMETER
+		return false
+	}
+
+	var ret uintptr
+	ret, _, lastError = procVerQueryValueW.Call(uintptr(unsafe.Pointer(&block[0])), StringToUintptr(subBlock), uintptr(unsafe.Pointer(buffer)), uintptr(unsafe.Pointer(length)))
+
+	return PtrToBool(ret)
+}
@@ -0,0 +1,264 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Version is a four part file or product version, most significant part
+// first.
+type Version [4]uint16
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d", v[0], v[1], v[2], v[3])
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower than, equal
+// to or higher than w.
+func (v Version) Compare(w Version) int {
+	for i := range v {
+		switch {
+		case v[i] < w[i]:
+			return -1
+		case v[i] > w[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func (fi *VS_FIXEDFILEINFO) FileVersion() Version {
+	return makeVersion(fi.FileVersionMS, fi.FileVersionLS)
+}
+
+func (fi *VS_FIXEDFILEINFO) ProductVersion() Version {
+	return makeVersion(fi.ProductVersionMS, fi.ProductVersionLS)
+}
+
+func makeVersion(ms, ls DWORD) Version {
+	return Version{uint16(ms >> 16), uint16(ms), uint16(ls >> 16), uint16(ls)}
+}
+
+// Translation identifies a string table by language and code page.
+type Translation struct {
+	Lang     LANGID
+	CodePage uint16
+}
+
+// String returns the table name used in StringFileInfo, e.g. "040904b0".
+func (t Translation) String() string {
+	return fmt.Sprintf("%04x%04x", uint16(t.Lang), t.CodePage)
+}
+
+// VersionInfo is a decoded VS_VERSIONINFO resource.
+type VersionInfo struct {
+	// Fixed is nil if the resource has no VS_FIXEDFILEINFO.
+	Fixed *VS_FIXEDFILEINFO
+	// Strings holds the StringFileInfo tables.
+	Strings map[Translation]map[string]string
+	// Translations lists the languages the file declares in
+	// VarFileInfo\Translation, in order.
+	Translations []Translation
+}
+
+var ErrVersionInfoInvalid = errors.New("winapi: invalid version resource")
+
+// FileVersionInfo reads and decodes the version resource of a file.
+func FileVersionInfo(filename string) (*VersionInfo, error) {
+	var handle DWORD
+	size := GetFileVersionInfoSize(filename, &handle)
+	if size == 0 {
+		return nil, lastError
+	}
+
+	data := make([]byte, size)
+	if !GetFileVersionInfo(filename, 0, data) {
+		return nil, lastError
+	}
+
+	return ParseVersionInfo(data)
+}
+
+// Value returns the string named key, e.g. "ProductVersion", from the
+// first declared translation that has it, falling back to any table.
+func (vi *VersionInfo) Value(key string) (string, bool) {
+	for _, t := range vi.Translations {
+		if s, ok := vi.Strings[t][key]; ok {
+			return s, true
+		}
+	}
+	for _, table := range vi.Strings {
+		if s, ok := table[key]; ok {
+			return s, true
+		}
+	}
+
+	return "", false
+}
+
+// verNode is one entry of the resource tree. Every entry starts with a
+// header of three words: the total length in bytes, the value length
+// and the value type, followed by a NUL terminated UTF-16 key. The
+// value and the children follow, each aligned on 32 bits from the start
+// of the resource.
+type verNode struct {
+	key      string
+	text     bool
+	value    []byte
+	children [2]int
+}
+
+const (
+	verHeaderSize    = 6
+	verFixedInfoSize = 52
+)
+
+// ParseVersionInfo decodes a VS_VERSIONINFO resource as returned by
+// GetFileVersionInfo or found in the RT_VERSION resource of a module.
+func ParseVersionInfo(b []byte) (*VersionInfo, error) {
+	root, _, err := readVerNode(b, 0, len(b))
+	if err != nil {
+		return nil, err
+	}
+	if root.key != "VS_VERSION_INFO" {
+		return nil, ErrVersionInfoInvalid
+	}
+
+	vi := &VersionInfo{Strings: make(map[Translation]map[string]string)}
+	if len(root.value) >= verFixedInfoSize {
+		fi := new(VS_FIXEDFILEINFO)
+		binary.Read(bytes.NewReader(root.value), binary.LittleEndian, fi)
+		if fi.Signature != VS_FFI_SIGNATURE {
+			return nil, ErrVersionInfoInvalid
+		}
+		vi.Fixed = fi
+	}
+
+	err = walkVerNodes(b, root.children, func(n *verNode) error {
+		switch n.key {
+		case "StringFileInfo":
+			return walkVerNodes(b, n.children, vi.addStringTable(b))
+		case "VarFileInfo":
+			return walkVerNodes(b, n.children, vi.addVar)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vi, nil
+}
+
+func (vi *VersionInfo) addStringTable(b []byte) func(*verNode) error {
+	return func(table *verNode) error {
+		id, err := strconv.ParseUint(table.key, 16, 32)
+		if err != nil || len(table.key) != 8 {
+			return ErrVersionInfoInvalid
+		}
+
+		t := Translation{Lang: LANGID(id >> 16), CodePage: uint16(id)}
+		strs := vi.Strings[t]
+		if strs == nil {
+			strs = make(map[string]string)
+			vi.Strings[t] = strs
+		}
+
+		return walkVerNodes(b, table.children, func(s *verNode) error {
+			strs[s.key] = utf16BytesToString(s.value)
+			return nil
+		})
+	}
+}
+
+func (vi *VersionInfo) addVar(v *verNode) error {
+	if v.key != "Translation" {
+		return nil
+	}
+
+	for i := 0; i+4 <= len(v.value); i += 4 {
+		vi.Translations = append(vi.Translations, Translation{
+			Lang:     LANGID(binary.LittleEndian.Uint16(v.value[i:])),
+			CodePage: binary.LittleEndian.Uint16(v.value[i+2:]),
+		})
+	}
+
+	return nil
+}
+
+func walkVerNodes(b []byte, span [2]int, fn func(*verNode) error) error {
+	for off := span[0]; off < span[1]; {
+		n, next, err := readVerNode(b, off, span[1])
+		if err != nil {
+			return err
+		}
+		if next == off {
+			// Some linkers pad the tree with zero length entries.
+			return nil
+		}
+		if err := fn(&n); err != nil {
+			return err
+		}
+		off = next
+	}
+
+	return nil
+}
+
+// readVerNode decodes the entry at off, which must end by limit, and
+// returns the offset of the next sibling.
+func readVerNode(b []byte, off, limit int) (verNode, int, error) {
+	var n verNode
+	if off+verHeaderSize > limit {
+		return n, 0, ErrVersionInfoInvalid
+	}
+
+	length := int(binary.LittleEndian.Uint16(b[off:]))
+	if length == 0 {
+		return n, off, nil
+	}
+	end := off + length
+	if length < verHeaderSize || end > limit {
+		return n, 0, ErrVersionInfoInvalid
+	}
+
+	valueLen := int(binary.LittleEndian.Uint16(b[off+2:]))
+	n.text = binary.LittleEndian.Uint16(b[off+4:]) == 1
+
+	p := off + verHeaderSize
+	key := p
+	for ; p+1 < end && (b[p] != 0 || b[p+1] != 0); p += 2 {
+	}
+	if p+1 >= end {
+		return n, 0, ErrVersionInfoInvalid
+	}
+	n.key = utf16BytesToString(b[key:p])
+
+	p = verAlign(p + 2)
+	if n.text {
+		// The length of a text value is given in characters.
+		valueLen *= 2
+	}
+	if p+valueLen > end {
+		valueLen = max(end-p, 0)
+	}
+	if valueLen > 0 {
+		n.value = b[p : p+valueLen]
+	}
+
+	n.children = [2]int{min(verAlign(p+valueLen), end), end}
+
+	return n, min(verAlign(end), limit), nil
+}
+
+func verAlign(off int) int {
+	return (off + 3) &^ 3
+}
@@ -0,0 +1,167 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// testVersionInfo is laid out as the resource compiler writes a
+// VS_VERSIONINFO resource for file version 2.1.4.3, product version
+// 2.1.0.0, with English and German string tables;
+// testVersionInfoNoStrings is the same resource without its
+// StringFileInfo.
+const (
+	testVersionInfo = "" +
+		"000234000000560053005f00560045005200530049004f004e005f0049004e00" +
+		"46004f0000000000bd04effe0000010001000200030004000100020000000000" +
+		"3f0000000000000004000400010000000000000000000000000000005a010000" +
+		"000053007400720069006e006700460069006c00650049006e0066006f000000" +
+		"b000000000003000340030003900300034006200300000003a000d0001004300" +
+		"6f006d00700061006e0079004e0061006d006500000000004500780061006d00" +
+		"70006c006500200043006f007200700000000000300008000100460069006c00" +
+		"6500560065007200730069006f006e000000000032002e0031002e0034002e00" +
+		"330000002c0004000100500072006f0064007500630074005600650072007300" +
+		"69006f006e00000032002e003100000086000000000030003400300037003000" +
+		"34006500340000003a0009000100460069006c00650044006500730063007200" +
+		"69007000740069006f006e000000000042006500690073007000690065006c00" +
+		"00000000320007000100500072006f0064007500630074005600650072007300" +
+		"69006f006e00000032002e003100200044004500000000004800000000005600" +
+		"61007200460069006c00650049006e0066006f00000000002800080000005400" +
+		"720061006e0073006c006100740069006f006e00000000000904b0040704e404"
+
+	testVersionInfoNoStrings = "" +
+		"a40034000000560053005f00560045005200530049004f004e005f0049004e00" +
+		"46004f0000000000bd04effe0000010001000200030004000100020000000000" +
+		"3f00000000000000040004000100000000000000000000000000000048000000" +
+		"0000560061007200460069006c00650049006e0066006f000000000028000800" +
+		"00005400720061006e0073006c006100740069006f006e00000000000904b004" +
+		"0704e404"
+)
+
+func TestParseVersionInfo(t *testing.T) {
+	vi, err := ParseVersionInfo(mustHex(t, testVersionInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if vi.Fixed == nil {
+		t.Fatal("no VS_FIXEDFILEINFO")
+	}
+	if v := vi.Fixed.FileVersion(); v != (Version{2, 1, 4, 3}) {
+		t.Errorf("FileVersion = %v, want 2.1.4.3", v)
+	}
+	if v := vi.Fixed.ProductVersion(); v != (Version{2, 1, 0, 0}) {
+		t.Errorf("ProductVersion = %v, want 2.1.0.0", v)
+	}
+	if vi.Fixed.FileOS != VOS_NT_WINDOWS32 || vi.Fixed.FileType != VFT_APP {
+		t.Errorf("FileOS %#x, FileType %d, want VOS_NT_WINDOWS32, VFT_APP", vi.Fixed.FileOS, vi.Fixed.FileType)
+	}
+
+	en := Translation{Lang: 0x0409, CodePage: 1200}
+	de := Translation{Lang: 0x0407, CodePage: 1252}
+	if want := []Translation{en, de}; !reflect.DeepEqual(vi.Translations, want) {
+		t.Errorf("Translations = %v, want %v", vi.Translations, want)
+	}
+	strs := map[Translation]map[string]string{
+		en: {"CompanyName": "Example Corp", "FileVersion": "2.1.4.3", "ProductVersion": "2.1"},
+		de: {"FileDescription": "Beispiel", "ProductVersion": "2.1 DE"},
+	}
+	if !reflect.DeepEqual(vi.Strings, strs) {
+		t.Errorf("Strings = %v, want %v", vi.Strings, strs)
+	}
+	if en.String() != "040904b0" || de.String() != "040704e4" {
+		t.Errorf("table names %s, %s, want 040904b0, 040704e4", en, de)
+	}
+
+	// Value prefers the first declared translation and falls back to
+	// the other tables.
+	for key, want := range map[string]string{"ProductVersion": "2.1", "FileDescription": "Beispiel"} {
+		if got, ok := vi.Value(key); !ok || got != want {
+			t.Errorf("Value(%q) = %q, %v, want %q", key, got, ok, want)
+		}
+	}
+	if _, ok := vi.Value("LegalCopyright"); ok {
+		t.Error("Value found a string that is not there")
+	}
+}
+
+func TestParseVersionInfoNoStrings(t *testing.T) {
+	vi, err := ParseVersionInfo(mustHex(t, testVersionInfoNoStrings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vi.Fixed == nil || len(vi.Strings) != 0 || len(vi.Translations) != 2 {
+		t.Errorf("ParseVersionInfo = %+v, want fixed info and translations without strings", vi)
+	}
+	if _, ok := vi.Value("ProductVersion"); ok {
+		t.Error("Value found a string without a StringFileInfo")
+	}
+}
+
+func TestParseVersionInfoPadding(t *testing.T) {
+	// Trailing zero length entries, which some linkers add, end the
+	// tree.
+	b := append(mustHex(t, testVersionInfo), make([]byte, 8)...)
+	binary.LittleEndian.PutUint16(b, uint16(len(b)))
+	if _, err := ParseVersionInfo(b); err != nil {
+		t.Errorf("ParseVersionInfo with padding: %v", err)
+	}
+}
+
+func TestParseVersionInfoInvalid(t *testing.T) {
+	b := mustHex(t, testVersionInfo)
+	le := binary.LittleEndian
+	edit := func(f func(b []byte)) []byte {
+		c := append([]byte(nil), b...)
+		f(c)
+		return c
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"zero length", make([]byte, 8)},
+		{"header only", b[:verHeaderSize]},
+		{"length past end", b[:len(b)-1]},
+		{"length below header", edit(func(b []byte) { le.PutUint16(b, 4) })},
+		{"key", edit(func(b []byte) { b[6] = 'X' })},
+		{"signature", edit(func(b []byte) { b[40] = 0 })},
+		{"child past parent", edit(func(b []byte) { le.PutUint16(b[92:], 0xFFF0) })},
+		// The name of the first string table, "040904b0", starts at 134.
+		{"table name", edit(func(b []byte) { b[136] = 'x' })},
+	}
+	for _, tt := range tests {
+		if vi, err := ParseVersionInfo(tt.data); err != ErrVersionInfoInvalid {
+			t.Errorf("%s: ParseVersionInfo = %+v, %v, want ErrVersionInfoInvalid", tt.name, vi, err)
+		}
+	}
+
+	// No prefix of the resource decodes.
+	for n := 0; n < len(b); n++ {
+		if _, err := ParseVersionInfo(b[:n]); err != ErrVersionInfoInvalid {
+			t.Fatalf("ParseVersionInfo(b[:%d]) err = %v, want ErrVersionInfoInvalid", n, err)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		v, w Version
+		want int
+	}{
+		{Version{1, 2, 3, 4}, Version{1, 2, 3, 4}, 0},
+		{Version{1, 2, 3, 4}, Version{1, 2, 3, 5}, -1},
+		{Version{2, 0, 0, 0}, Version{1, 9, 9, 9}, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.v.Compare(tt.w); got != tt.want {
+			t.Errorf("%v.Compare(%v) = %d, want %d", tt.v, tt.w, got, tt.want)
+		}
+	}
+}
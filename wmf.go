@@ -0,0 +1,886 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"unsafe"
+)
+
+var ErrWMFInvalid = errors.New("winapi: invalid Windows metafile")
+
+// ALDUS_PLACEABLE_KEY starts a metafile with a placeable header.
+const ALDUS_PLACEABLE_KEY = 0x9AC6CDD7
+
+const (
+	wmfPlaceableSize = 22
+	wmfHeaderSize    = 18
+)
+
+// WMF is a Windows metafile as DecodeWMF returns it.
+type WMF struct {
+	// Placeable is the Aldus placeable header, or nil if there is none.
+	Placeable *WMFPlaceable
+	Header    WMFHeader
+	// Records are the records up to the META_EOF record, which is left
+	// out.
+	Records []WMFRecord
+}
+
+// WMFPlaceable is the Aldus placeable header, which gives the size of
+// the picture: Bounds in metafile units, of which there are Inch to the
+// inch.
+type WMFPlaceable struct {
+	Handle WORD
+	Bounds RECT
+	Inch   WORD
+}
+
+// WMFHeader is the META_HEADER of a metafile. Sizes are in 16-bit
+// words.
+type WMFHeader struct {
+	// Type is 1 for a metafile in memory and 2 for one on disk.
+	Type      WORD
+	Version   WORD
+	Size      DWORD
+	Objects   WORD
+	MaxRecord DWORD
+}
+
+// WMFRecord is a record of a Windows metafile. Records without a type of
+// their own are returned as *WMFRaw.
+type WMFRecord interface {
+	// Type returns the META_ function of the record.
+	Type() WORD
+	// play draws the record with p.
+	play(p *wmfPlayer)
+}
+
+// WMFPoint is one of the META_MOVETO, META_LINETO, META_SETWINDOWORG,
+// META_SETWINDOWEXT, META_SETVIEWPORTORG, META_SETVIEWPORTEXT,
+// META_OFFSETWINDOWORG and META_OFFSETVIEWPORTORG records.
+type WMFPoint struct {
+	Kind WORD
+	X, Y int32
+}
+
+func (r *WMFPoint) Type() WORD { return r.Kind }
+
+// WMFScale is the META_SCALEWINDOWEXT or META_SCALEVIEWPORTEXT record.
+type WMFScale struct {
+	Kind         WORD
+	XNum, XDenom int32
+	YNum, YDenom int32
+}
+
+func (r *WMFScale) Type() WORD { return r.Kind }
+
+// WMFPoly is the META_POLYGON or META_POLYLINE record.
+type WMFPoly struct {
+	Kind   WORD
+	Points []POINT
+}
+
+func (r *WMFPoly) Type() WORD { return r.Kind }
+
+// WMFPolyPolygon is the META_POLYPOLYGON record.
+type WMFPolyPolygon struct {
+	Polygons [][]POINT
+}
+
+func (r *WMFPolyPolygon) Type() WORD { return META_POLYPOLYGON }
+
+// WMFRect is one of the META_RECTANGLE, META_ELLIPSE,
+// META_INTERSECTCLIPRECT and META_EXCLUDECLIPRECT records.
+type WMFRect struct {
+	Kind WORD
+	Rect RECT
+}
+
+func (r *WMFRect) Type() WORD { return r.Kind }
+
+// WMFTextOut is the META_TEXTOUT record. Text is in the code page of the
+// character set of the font.
+type WMFTextOut struct {
+	X, Y int32
+	Text []byte
+}
+
+func (r *WMFTextOut) Type() WORD { return META_TEXTOUT }
+
+// WMFExtTextOut is the META_EXTTEXTOUT record. Rect is only stored with
+// ETO_OPAQUE or ETO_CLIPPED, and Dx, an advance for each byte of Text,
+// is optional.
+type WMFExtTextOut struct {
+	X, Y    int32
+	Options WORD
+	Rect    RECT
+	Text    []byte
+	Dx      []int32
+}
+
+func (r *WMFExtTextOut) Type() WORD { return META_EXTTEXTOUT }
+
+// WMFMode is one of the META_SETBKMODE, META_SETMAPMODE, META_SETROP2,
+// META_SETRELABS, META_SETPOLYFILLMODE, META_SETSTRETCHBLTMODE and
+// META_SETTEXTALIGN records.
+type WMFMode struct {
+	Kind WORD
+	Mode WORD
+}
+
+func (r *WMFMode) Type() WORD { return r.Kind }
+
+// WMFColor is the META_SETBKCOLOR or META_SETTEXTCOLOR record.
+type WMFColor struct {
+	Kind  WORD
+	Color COLORREF
+}
+
+func (r *WMFColor) Type() WORD { return r.Kind }
+
+// WMFSaveDC is the META_SAVEDC record.
+type WMFSaveDC struct{}
+
+func (r *WMFSaveDC) Type() WORD { return META_SAVEDC }
+
+// WMFRestoreDC is the META_RESTOREDC record.
+type WMFRestoreDC struct {
+	SavedDC int32
+}
+
+func (r *WMFRestoreDC) Type() WORD { return META_RESTOREDC }
+
+// WMFCreatePen is the META_CREATEPENINDIRECT record.
+type WMFCreatePen struct {
+	Pen LOGPEN
+}
+
+func (r *WMFCreatePen) Type() WORD { return META_CREATEPENINDIRECT }
+
+// WMFCreateBrush is the META_CREATEBRUSHINDIRECT record.
+type WMFCreateBrush struct {
+	Brush LOGBRUSH
+}
+
+func (r *WMFCreateBrush) Type() WORD { return META_CREATEBRUSHINDIRECT }
+
+// WMFCreateFont is the META_CREATEFONTINDIRECT record. The face name is
+// converted from the code page of the character set as ISO 8859-1.
+type WMFCreateFont struct {
+	Font LOGFONT
+}
+
+func (r *WMFCreateFont) Type() WORD { return META_CREATEFONTINDIRECT }
+
+// WMFObject is one of the META_SELECTOBJECT, META_DELETEOBJECT,
+// META_SELECTPALETTE and META_SELECTCLIPREGION records. Index is the
+// index of the object in the table of objects created by the metafile,
+// in which each object takes the lowest free index.
+type WMFObject struct {
+	Kind  WORD
+	Index WORD
+}
+
+func (r *WMFObject) Type() WORD { return r.Kind }
+
+// WMFPatBlt is the META_PATBLT record.
+type WMFPatBlt struct {
+	X, Y, Width, Height int32
+	Rop                 DWORD
+}
+
+func (r *WMFPatBlt) Type() WORD { return META_PATBLT }
+
+// WMFStretchDIB is one of the META_STRETCHDIB, META_DIBSTRETCHBLT and
+// META_DIBBITBLT records. DIB is a packed DIB, a BITMAPINFO followed by
+// the pixels, and is nil when the operation does not use a source. For
+// META_DIBBITBLT the source is the size of the destination.
+type WMFStretchDIB struct {
+	Kind                WORD
+	Rop                 DWORD
+	X, Y, Width, Height int32
+	XSrc, YSrc          int32
+	SrcWidth, SrcHeight int32
+	Usage               WORD
+	DIB                 []byte
+}
+
+func (r *WMFStretchDIB) Type() WORD { return r.Kind }
+
+// WMFRaw is a record of any other type. Params is the record without its
+// size and function.
+type WMFRaw struct {
+	Kind   WORD
+	Params []byte
+}
+
+func (r *WMFRaw) Type() WORD { return r.Kind }
+
+// DecodeWMF decodes a Windows metafile, with or without a placeable
+// header. The checksum of the placeable header is not checked, as it is
+// often wrong in files in the wild.
+func DecodeWMF(b []byte) (*WMF, error) {
+	m := &WMF{}
+	le := binary.LittleEndian
+	if len(b) >= wmfPlaceableSize && le.Uint32(b) == ALDUS_PLACEABLE_KEY {
+		m.Placeable = &WMFPlaceable{
+			Handle: WORD(le.Uint16(b[4:])),
+			Bounds: RECT{wmfInt(b[6:]), wmfInt(b[8:]), wmfInt(b[10:]), wmfInt(b[12:])},
+			Inch:   WORD(le.Uint16(b[14:])),
+		}
+		b = b[wmfPlaceableSize:]
+	}
+
+	if len(b) < wmfHeaderSize || le.Uint16(b[2:]) != wmfHeaderSize/2 {
+		return nil, ErrWMFInvalid
+	}
+	m.Header = WMFHeader{
+		Type:      WORD(le.Uint16(b)),
+		Version:   WORD(le.Uint16(b[4:])),
+		Size:      DWORD(le.Uint32(b[6:])),
+		Objects:   WORD(le.Uint16(b[10:])),
+		MaxRecord: DWORD(le.Uint32(b[12:])),
+	}
+	b = b[wmfHeaderSize:]
+
+	for {
+		if len(b) < 6 {
+			return nil, ErrWMFInvalid
+		}
+
+		size := uint64(le.Uint32(b)) * 2
+		fn := WORD(le.Uint16(b[4:]))
+		if size < 6 || size > uint64(len(b)) {
+			return nil, ErrWMFInvalid
+		}
+		if fn == META_EOF {
+			return m, nil
+		}
+
+		rec, err := decodeWMFRecord(fn, b[6:size])
+		if err != nil {
+			return nil, err
+		}
+		m.Records = append(m.Records, rec)
+		b = b[size:]
+	}
+}
+
+// wmfInt returns the signed 16-bit value at the start of b.
+func wmfInt(b []byte) int32 {
+	return int32(int16(binary.LittleEndian.Uint16(b)))
+}
+
+// wmfInts returns the first n signed 16-bit parameters of p, or false if
+// there are fewer.
+func wmfInts(p []byte, n int) ([]int32, bool) {
+	if len(p) < 2*n {
+		return nil, false
+	}
+
+	v := make([]int32, n)
+	for i := range v {
+		v[i] = wmfInt(p[2*i:])
+	}
+
+	return v, true
+}
+
+// wmfPoints returns n points of x, y pairs from the start of p.
+func wmfPoints(p []byte, n int) ([]POINT, bool) {
+	v, ok := wmfInts(p, 2*n)
+	if !ok {
+		return nil, false
+	}
+
+	pts := make([]POINT, n)
+	for i := range pts {
+		pts[i] = POINT{v[2*i], v[2*i+1]}
+	}
+
+	return pts, true
+}
+
+func decodeWMFRecord(fn WORD, p []byte) (WMFRecord, error) {
+	le := binary.LittleEndian
+	var rec WMFRecord
+	ok := true
+
+	// Parameters are stored in the reverse order of the arguments of the
+	// function the record calls.
+	switch fn {
+	case META_MOVETO, META_LINETO, META_SETWINDOWORG, META_SETWINDOWEXT,
+		META_SETVIEWPORTORG, META_SETVIEWPORTEXT, META_OFFSETWINDOWORG, META_OFFSETVIEWPORTORG:
+		var v []int32
+		if v, ok = wmfInts(p, 2); ok {
+			rec = &WMFPoint{fn, v[1], v[0]}
+		}
+	case META_SCALEWINDOWEXT, META_SCALEVIEWPORTEXT:
+		var v []int32
+		if v, ok = wmfInts(p, 4); ok {
+			rec = &WMFScale{fn, v[3], v[2], v[1], v[0]}
+		}
+	case META_POLYGON, META_POLYLINE:
+		var pts []POINT
+		if ok = len(p) >= 2; ok {
+			if pts, ok = wmfPoints(p[2:], int(le.Uint16(p))); ok {
+				rec = &WMFPoly{fn, pts}
+			}
+		}
+	case META_POLYPOLYGON:
+		rec, ok = decodeWMFPolyPolygon(p)
+	case META_RECTANGLE, META_ELLIPSE, META_INTERSECTCLIPRECT, META_EXCLUDECLIPRECT:
+		var v []int32
+		if v, ok = wmfInts(p, 4); ok {
+			rec = &WMFRect{fn, RECT{v[3], v[2], v[1], v[0]}}
+		}
+	case META_TEXTOUT:
+		rec, ok = decodeWMFTextOut(p)
+	case META_EXTTEXTOUT:
+		rec, ok = decodeWMFExtTextOut(p)
+	case META_SETBKMODE, META_SETMAPMODE, META_SETROP2, META_SETRELABS,
+		META_SETPOLYFILLMODE, META_SETSTRETCHBLTMODE, META_SETTEXTALIGN:
+		if ok = len(p) >= 2; ok {
+			rec = &WMFMode{fn, WORD(le.Uint16(p))}
+		}
+	case META_SETBKCOLOR, META_SETTEXTCOLOR:
+		if ok = len(p) >= 4; ok {
+			rec = &WMFColor{fn, COLORREF(le.Uint32(p))}
+		}
+	case META_SAVEDC:
+		rec = &WMFSaveDC{}
+	case META_RESTOREDC:
+		var v []int32
+		if v, ok = wmfInts(p, 1); ok {
+			rec = &WMFRestoreDC{v[0]}
+		}
+	case META_CREATEPENINDIRECT:
+		if ok = len(p) >= 10; ok {
+			rec = &WMFCreatePen{LOGPEN{
+				LopnStyle: UINT(le.Uint16(p)),
+				LopnWidth: POINT{wmfInt(p[2:]), wmfInt(p[4:])},
+				LopnColor: COLORREF(le.Uint32(p[6:])),
+			}}
+		}
+	case META_CREATEBRUSHINDIRECT:
+		if ok = len(p) >= 8; ok {
+			rec = &WMFCreateBrush{LOGBRUSH{
+				LbStyle: UINT(le.Uint16(p)),
+				LbColor: COLORREF(le.Uint32(p[2:])),
+				LbHatch: uintptr(le.Uint16(p[6:])),
+			}}
+		}
+	case META_CREATEFONTINDIRECT:
+		rec, ok = decodeWMFCreateFont(p)
+	case META_SELECTOBJECT, META_DELETEOBJECT, META_SELECTPALETTE, META_SELECTCLIPREGION:
+		if ok = len(p) >= 2; ok {
+			rec = &WMFObject{fn, WORD(le.Uint16(p))}
+		}
+	case META_PATBLT:
+		var v []int32
+		if ok = len(p) >= 12; ok {
+			v, _ = wmfInts(p[4:], 4)
+			rec = &WMFPatBlt{v[3], v[2], v[1], v[0], DWORD(le.Uint32(p))}
+		}
+	case META_STRETCHDIB, META_DIBSTRETCHBLT, META_DIBBITBLT:
+		rec, ok = decodeWMFStretchDIB(fn, p)
+	default:
+		rec = &WMFRaw{fn, append([]byte(nil), p...)}
+	}
+	if !ok {
+		return nil, ErrWMFInvalid
+	}
+
+	return rec, nil
+}
+
+func decodeWMFPolyPolygon(p []byte) (WMFRecord, bool) {
+	if len(p) < 2 {
+		return nil, false
+	}
+
+	n := int(binary.LittleEndian.Uint16(p))
+	counts, ok := wmfInts(p[2:], n)
+	if !ok {
+		return nil, false
+	}
+
+	r := &WMFPolyPolygon{}
+	p = p[2+2*n:]
+	for _, c := range counts {
+		c &= 0xFFFF
+		pts, ok := wmfPoints(p, int(c))
+		if !ok {
+			return nil, false
+		}
+		r.Polygons = append(r.Polygons, pts)
+		p = p[4*c:]
+	}
+
+	return r, true
+}
+
+func decodeWMFTextOut(p []byte) (WMFRecord, bool) {
+	if len(p) < 2 {
+		return nil, false
+	}
+
+	n := int(binary.LittleEndian.Uint16(p))
+	padded := (n + 1) &^ 1
+	v, ok := wmfInts(p[min(len(p), 2+padded):], 2)
+	if !ok {
+		return nil, false
+	}
+
+	return &WMFTextOut{v[1], v[0], append([]byte(nil), p[2:2+n]...)}, true
+}
+
+func decodeWMFExtTextOut(p []byte) (WMFRecord, bool) {
+	v, ok := wmfInts(p, 4)
+	if !ok {
+		return nil, false
+	}
+
+	r := &WMFExtTextOut{X: v[1], Y: v[0], Options: WORD(v[3])}
+	n := int(v[2] & 0xFFFF)
+	p = p[8:]
+	if r.Options&(ETO_OPAQUE|ETO_CLIPPED) != 0 {
+		rc, ok := wmfInts(p, 4)
+		if !ok {
+			return nil, false
+		}
+		r.Rect = RECT{rc[0], rc[1], rc[2], rc[3]}
+		p = p[8:]
+	}
+
+	padded := (n + 1) &^ 1
+	if len(p) < padded {
+		return nil, false
+	}
+	r.Text = append([]byte(nil), p[:n]...)
+	p = p[padded:]
+
+	if dx, ok := wmfInts(p, n); ok && n > 0 {
+		r.Dx = dx
+	}
+
+	return r, true
+}
+
+func decodeWMFCreateFont(p []byte) (WMFRecord, bool) {
+	if len(p) < 18 {
+		return nil, false
+	}
+
+	lf := LOGFONT{
+		LfHeight:         wmfInt(p),
+		LfWidth:          wmfInt(p[2:]),
+		LfEscapement:     wmfInt(p[4:]),
+		LfOrientation:    wmfInt(p[6:]),
+		LfWeight:         wmfInt(p[8:]),
+		LfItalic:         p[10],
+		LfUnderline:      p[11],
+		LfStrikeOut:      p[12],
+		LfCharSet:        p[13],
+		LfOutPrecision:   p[14],
+		LfClipPrecision:  p[15],
+		LfQuality:        p[16],
+		LfPitchAndFamily: p[17],
+	}
+	face := p[18:]
+	for i, c := range face {
+		if c == 0 || i == LF_FACESIZE-1 {
+			break
+		}
+		lf.LfFaceName[i] = uint16(c)
+	}
+
+	return &WMFCreateFont{lf}, true
+}
+
+func decodeWMFStretchDIB(fn WORD, p []byte) (WMFRecord, bool) {
+	// n is the number of 16-bit parameters before the DIB. A record
+	// without a DIB has a reserved parameter before the destination
+	// instead, and as many parameters as the high byte of its function.
+	n := 10
+	switch fn {
+	case META_STRETCHDIB:
+		n = 11
+	case META_DIBBITBLT:
+		n = 8
+	}
+
+	noDIB := len(p) == 2*int(fn>>8)
+	if noDIB && fn == META_STRETCHDIB {
+		return nil, false
+	}
+	v, ok := wmfInts(p, n)
+	if !ok {
+		return nil, false
+	}
+	le := binary.LittleEndian
+
+	r := &WMFStretchDIB{Kind: fn, Rop: DWORD(le.Uint32(p))}
+	i := 2
+	switch fn {
+	case META_STRETCHDIB:
+		r.Usage = WORD(v[i])
+		i++
+		fallthrough
+	case META_DIBSTRETCHBLT:
+		r.SrcHeight, r.SrcWidth = v[i], v[i+1]
+		i += 2
+	}
+	r.YSrc, r.XSrc = v[i], v[i+1]
+	i += 2
+	if noDIB {
+		i++
+		if len(p) < 2*(n+1) {
+			return nil, false
+		}
+		v, _ = wmfInts(p, n+1)
+	}
+	r.Height, r.Width, r.Y, r.X = v[i], v[i+1], v[i+2], v[i+3]
+	if fn == META_DIBBITBLT {
+		r.SrcWidth, r.SrcHeight = r.Width, r.Height
+	}
+	if !noDIB {
+		r.DIB = append([]byte(nil), p[2*n:]...)
+	}
+
+	return r, true
+}
+
+// wmfString converts text of a metafile, taking it to be ISO 8859-1.
+func wmfString(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+
+	return string(r)
+}
+
+// splitDIB splits a packed DIB into its BITMAPINFO, the color masks of
+// a BI_BITFIELDS DIB and its pixels.
+func splitDIB(dib []byte, usage WORD) (*BITMAPINFO, [3]DWORD, []byte, bool) {
+	var masks [3]DWORD
+	if len(dib) < 40 {
+		return nil, masks, nil, false
+	}
+
+	le := binary.LittleEndian
+	size := int(le.Uint32(dib))
+	bpp := int(le.Uint16(dib[14:]))
+	compression := le.Uint32(dib[16:])
+	colors := int(le.Uint32(dib[32:]))
+	if colors == 0 && bpp <= 8 {
+		colors = 1 << bpp
+	}
+	entry := 4
+	if usage == DIB_PAL_COLORS {
+		entry = 2
+	}
+
+	off := size + colors*entry
+	if size == 40 && compression == BI_BITFIELDS {
+		off += 12
+	}
+	if size < 40 || off > len(dib) {
+		return nil, masks, nil, false
+	}
+
+	// The masks follow a BITMAPINFOHEADER and are part of larger headers.
+	if compression == BI_BITFIELDS {
+		if off < 52 {
+			return nil, masks, nil, false
+		}
+		for i := range masks {
+			masks[i] = DWORD(le.Uint32(dib[40+4*i:]))
+		}
+	}
+
+	// Copy the header so that it is aligned.
+	bmi := make([]byte, max(off, int(unsafe.Sizeof(BITMAPINFO{}))))
+	copy(bmi, dib[:off])
+
+	return (*BITMAPINFO)(unsafe.Pointer(&bmi[0])), masks, dib[off:], true
+}
+
+// wmfPlayer holds the state of playing a metafile.
+type wmfPlayer struct {
+	hdc     HDC
+	objects []wmfObject
+}
+
+// wmfObject is an entry of the object table. h is 0 for objects that
+// cannot be created and free entries.
+type wmfObject struct {
+	h    HGDIOBJ
+	used bool
+	own  bool
+}
+
+// add puts h in the lowest free entry of the object table.
+func (p *wmfPlayer) add(h HGDIOBJ, own bool) {
+	obj := wmfObject{h, true, own && h != 0}
+	for i := range p.objects {
+		if !p.objects[i].used {
+			p.objects[i] = obj
+			return
+		}
+	}
+	p.objects = append(p.objects, obj)
+}
+
+func (p *wmfPlayer) object(i WORD) *wmfObject {
+	if int(i) >= len(p.objects) || !p.objects[i].used {
+		return nil
+	}
+
+	return &p.objects[i]
+}
+
+// Play draws m on hdc, which may be a SoftDC. With a placeable header
+// and a non-nil rc, the bounds of the picture are first mapped to rc
+// with MM_ANISOTROPIC. The state of hdc is restored and the objects the
+// metafile creates are deleted afterwards.
+//
+// The records drawn are those with a type of their own except the clip
+// rectangles, META_SETROP2, META_SETRELABS and META_SETTEXTALIGN. Text
+// is taken to be ISO 8859-1.
+func (m *WMF) Play(hdc HDC, rc *RECT) bool {
+	saved := SaveDC(hdc)
+	if saved == 0 {
+		return false
+	}
+
+	if b := m.Placeable; b != nil && rc != nil {
+		SetMapMode(hdc, MM_ANISOTROPIC)
+		SetWindowOrgEx(hdc, b.Bounds.Left, b.Bounds.Top, nil)
+		SetWindowExtEx(hdc, b.Bounds.Right-b.Bounds.Left, b.Bounds.Bottom-b.Bounds.Top, nil)
+		SetViewportOrgEx(hdc, rc.Left, rc.Top, nil)
+		SetViewportExtEx(hdc, rc.Right-rc.Left, rc.Bottom-rc.Top, nil)
+	}
+
+	p := &wmfPlayer{hdc: hdc}
+	for _, r := range m.Records {
+		r.play(p)
+	}
+
+	RestoreDC(hdc, saved)
+	for _, obj := range p.objects {
+		if obj.own {
+			DeleteObject(obj.h)
+		}
+	}
+
+	return true
+}
+
+func (r *WMFPoint) play(p *wmfPlayer) {
+	switch r.Kind {
+	case META_MOVETO:
+		MoveToEx(p.hdc, r.X, r.Y, nil)
+	case META_LINETO:
+		LineTo(p.hdc, r.X, r.Y)
+	case META_SETWINDOWORG:
+		SetWindowOrgEx(p.hdc, r.X, r.Y, nil)
+	case META_SETWINDOWEXT:
+		SetWindowExtEx(p.hdc, r.X, r.Y, nil)
+	case META_SETVIEWPORTORG:
+		SetViewportOrgEx(p.hdc, r.X, r.Y, nil)
+	case META_SETVIEWPORTEXT:
+		SetViewportExtEx(p.hdc, r.X, r.Y, nil)
+	case META_OFFSETWINDOWORG:
+		OffsetWindowOrgEx(p.hdc, r.X, r.Y, nil)
+	case META_OFFSETVIEWPORTORG:
+		OffsetViewportOrgEx(p.hdc, r.X, r.Y, nil)
+	}
+}
+
+func (r *WMFScale) play(p *wmfPlayer) {
+	if r.Kind == META_SCALEWINDOWEXT {
+		ScaleWindowExtEx(p.hdc, r.XNum, r.XDenom, r.YNum, r.YDenom, nil)
+	} else {
+		ScaleViewportExtEx(p.hdc, r.XNum, r.XDenom, r.YNum, r.YDenom, nil)
+	}
+}
+
+func (r *WMFPoly) play(p *wmfPlayer) {
+	if len(r.Points) == 0 {
+		return
+	}
+
+	if r.Kind == META_POLYGON {
+		Polygon(p.hdc, r.Points)
+	} else {
+		Polyline(p.hdc, r.Points)
+	}
+}
+
+// play fills the polygons as one area, as PolyPolygon does, by drawing
+// them as a path.
+func (r *WMFPolyPolygon) play(p *wmfPlayer) {
+	BeginPath(p.hdc)
+	for _, pts := range r.Polygons {
+		if len(pts) > 0 {
+			Polygon(p.hdc, pts)
+		}
+	}
+	EndPath(p.hdc)
+	StrokeAndFillPath(p.hdc)
+}
+
+func (r *WMFRect) play(p *wmfPlayer) {
+	switch r.Kind {
+	case META_RECTANGLE:
+		Rectangle(p.hdc, r.Rect.Left, r.Rect.Top, r.Rect.Right, r.Rect.Bottom)
+	case META_ELLIPSE:
+		Ellipse(p.hdc, r.Rect.Left, r.Rect.Top, r.Rect.Right, r.Rect.Bottom)
+	}
+}
+
+func (r *WMFTextOut) play(p *wmfPlayer) {
+	TextOut(p.hdc, r.X, r.Y, wmfString(r.Text))
+}
+
+func (r *WMFExtTextOut) play(p *wmfPlayer) {
+	var rc *RECT
+	if r.Options&(ETO_OPAQUE|ETO_CLIPPED) != 0 {
+		rc = &r.Rect
+	}
+	ExtTextOut(p.hdc, r.X, r.Y, UINT(r.Options), rc, wmfString(r.Text), r.Dx)
+}
+
+func (r *WMFMode) play(p *wmfPlayer) {
+	switch r.Kind {
+	case META_SETBKMODE:
+		SetBkMode(p.hdc, int32(r.Mode))
+	case META_SETMAPMODE:
+		SetMapMode(p.hdc, int32(r.Mode))
+	case META_SETPOLYFILLMODE:
+		SetPolyFillMode(p.hdc, int32(r.Mode))
+	case META_SETSTRETCHBLTMODE:
+		SetStretchBltMode(p.hdc, int32(r.Mode))
+	}
+}
+
+func (r *WMFColor) play(p *wmfPlayer) {
+	if r.Kind == META_SETBKCOLOR {
+		SetBkColor(p.hdc, r.Color)
+	} else {
+		SetTextColor(p.hdc, r.Color)
+	}
+}
+
+func (r *WMFSaveDC) play(p *wmfPlayer) {
+	SaveDC(p.hdc)
+}
+
+func (r *WMFRestoreDC) play(p *wmfPlayer) {
+	RestoreDC(p.hdc, r.SavedDC)
+}
+
+func (r *WMFCreatePen) play(p *wmfPlayer) {
+	p.add(HGDIOBJ(CreatePen(int32(r.Pen.LopnStyle), r.Pen.LopnWidth.X, r.Pen.LopnColor)), true)
+}
+
+func (r *WMFCreateBrush) play(p *wmfPlayer) {
+	switch r.Brush.LbStyle {
+	case BS_SOLID:
+		p.add(HGDIOBJ(CreateSolidBrush(r.Brush.LbColor)), true)
+	case BS_HATCHED:
+		p.add(HGDIOBJ(CreateHatchBrush(int32(r.Brush.LbHatch), r.Brush.LbColor)), true)
+	case BS_NULL:
+		p.add(GetStockObject(NULL_BRUSH), false)
+	default:
+		p.add(0, false)
+	}
+}
+
+func (r *WMFCreateFont) play(p *wmfPlayer) {
+	p.add(HGDIOBJ(CreateFontIndirect(&r.Font)), true)
+}
+
+func (r *WMFObject) play(p *wmfPlayer) {
+	obj := p.object(r.Index)
+	if obj == nil {
+		return
+	}
+
+	switch r.Kind {
+	case META_SELECTOBJECT:
+		if obj.h != 0 {
+			SelectObject(p.hdc, obj.h)
+		}
+	case META_DELETEOBJECT:
+		if obj.own {
+			DeleteObject(obj.h)
+		}
+		*obj = wmfObject{}
+	}
+}
+
+func (r *WMFPatBlt) play(p *wmfPlayer) {
+	PatBlt(p.hdc, r.X, r.Y, r.Width, r.Height, r.Rop)
+}
+
+func (r *WMFStretchDIB) play(p *wmfPlayer) {
+	if r.DIB == nil {
+		PatBlt(p.hdc, r.X, r.Y, r.Width, r.Height, r.Rop)
+		return
+	}
+
+	bmi, masks, bits, ok := splitDIB(r.DIB, r.Usage)
+	if !ok || len(bits) == 0 {
+		return
+	}
+	if dc := lookupSoftDC(p.hdc); dc != nil {
+		dc.stretchDIBits(r.X, r.Y, r.Width, r.Height, r.XSrc, r.YSrc, r.SrcWidth, r.SrcHeight, bits, &bmi.BmiHeader, masks, r.Rop)
+		return
+	}
+	StretchDIBits(p.hdc, r.X, r.Y, r.Width, r.Height, r.XSrc, r.YSrc, r.SrcWidth, r.SrcHeight, bits, bmi, UINT(r.Usage), r.Rop)
+}
+
+// play takes an entry of the object table for the objects it cannot
+// create, so that later records refer to the right objects.
+func (r *WMFRaw) play(p *wmfPlayer) {
+	switch r.Kind {
+	case META_CREATEPALETTE, META_CREATEPATTERNBRUSH, META_DIBCREATEPATTERNBRUSH, META_CREATEREGION:
+		p.add(0, false)
+	}
+}
+
+// ConvertWMF converts a Windows metafile, with or without a placeable
+// header, to an enhanced metafile with SetWinMetaFileBits. The size of
+// the picture is taken from the placeable header if there is one, and
+// from hdcRef otherwise.
+func ConvertWMF(data []byte, hdcRef HDC) HENHMETAFILE {
+	m, err := DecodeWMF(data)
+	if err != nil {
+		lastError = err
+		return 0
+	}
+
+	if m.Placeable == nil {
+		return SetWinMetaFileBits(data, hdcRef, nil)
+	}
+
+	b, inch := m.Placeable.Bounds, int32(m.Placeable.Inch)
+	if inch == 0 {
+		lastError = ErrWMFInvalid
+		return 0
+	}
+	mfp := &METAFILEPICT{
+		Mm:   MM_ANISOTROPIC,
+		XExt: mulDiv(b.Right-b.Left, 2540, inch),
+		YExt: mulDiv(b.Bottom-b.Top, 2540, inch),
+	}
+
+	return SetWinMetaFileBits(data[wmfPlaceableSize:], hdcRef, mfp)
+}
@@ -0,0 +1,204 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// testWMF is a placeable metafile of 1000 by 500 twips, laid out as GDI
+// writes one, with a record of each kind DecodeWMF decodes and one it
+// leaves raw.
+const testWMF = "" +
+	// placeable header
+	"d7cdc69a000000000000e803f401a00500000000ad50" +
+	// META_HEADER
+	"010009000003d90000000300240000000000" +
+	// META_SETWINDOWORG
+	"050000000b0200000000" +
+	// META_SETWINDOWEXT
+	"050000000c02f401e803" +
+	// META_CREATEPENINDIRECT
+	"08000000fa02000001000000ff000000" +
+	// META_SELECTOBJECT
+	"040000002d010000" +
+	// META_CREATEBRUSHINDIRECT
+	"07000000fc02000000ff00000000" +
+	// META_SELECTOBJECT
+	"040000002d010100" +
+	// META_CREATEFONTINDIRECT
+	"1c000000fb02f0ff000000000000bc020001000000000222417269616c000000" +
+	"000000000000000000000000000000000000000000000000" +
+	// META_SELECTOBJECT
+	"040000002d010200" +
+	// META_SETBKMODE
+	"0400000002010100" +
+	// META_SETTEXTCOLOR
+	"05000000090220408000" +
+	// META_RECTANGLE
+	"070000001b049001840364006400" +
+	// META_POLYLINE
+	"0a000000250303000a000a001400e2ff28000a00" +
+	// META_POLYPOLYGON
+	"120000003805020003000300000000000a00000000000a00320032003c003200" +
+	"32003c00" +
+	// META_TEXTOUT
+	"090000002105050048656c6c6f00c8006400" +
+	// META_EXTTEXTOUT
+	"0e000000320a2c0196000200040096002c01fa00400148690a000b00" +
+	// META_SAVEDC
+	"030000001e00" +
+	// META_PATBLT
+	"090000001d062100f00014001e0028003200" +
+	// META_RESTOREDC
+	"040000002701ffff" +
+	// META_DIBBITBLT
+	"0c00000040094200000000000000000014001e0005000600" +
+	// META_STRETCHDIB
+	"24000000430f2000cc00000001000100000000000800080046003c0028000000" +
+	"0100000001000000010018000000000004000000000000000000000000000000" +
+	"0000000030201000" +
+	// META_SETMAPPERFLAGS
+	"05000000310201000000" +
+	// META_DELETEOBJECT
+	"04000000f0010000" +
+	// META_EOF
+	"030000000000"
+
+func TestDecodeWMF(t *testing.T) {
+	m, err := DecodeWMF(mustHex(t, testWMF))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (&WMFPlaceable{Bounds: RECT{0, 0, 1000, 500}, Inch: 1440}); !reflect.DeepEqual(m.Placeable, want) {
+		t.Errorf("Placeable = %+v, want %+v", m.Placeable, want)
+	}
+	if want := (WMFHeader{Type: 1, Version: 0x0300, Size: 217, Objects: 3, MaxRecord: 36}); m.Header != want {
+		t.Errorf("Header = %+v, want %+v", m.Header, want)
+	}
+
+	font := LOGFONT{LfHeight: -16, LfWeight: FW_BOLD, LfUnderline: 1, LfQuality: 2, LfPitchAndFamily: 0x22}
+	copy(font.LfFaceName[:], utf16Units("Arial"))
+	want := []WMFRecord{
+		&WMFPoint{META_SETWINDOWORG, 0, 0},
+		&WMFPoint{META_SETWINDOWEXT, 1000, 500},
+		&WMFCreatePen{LOGPEN{LopnStyle: PS_SOLID, LopnWidth: POINT{1, 0}, LopnColor: RGB(255, 0, 0)}},
+		&WMFObject{META_SELECTOBJECT, 0},
+		&WMFCreateBrush{LOGBRUSH{LbStyle: BS_SOLID, LbColor: RGB(0, 255, 0)}},
+		&WMFObject{META_SELECTOBJECT, 1},
+		&WMFCreateFont{font},
+		&WMFObject{META_SELECTOBJECT, 2},
+		&WMFMode{META_SETBKMODE, TRANSPARENT},
+		&WMFColor{META_SETTEXTCOLOR, RGB(0x20, 0x40, 0x80)},
+		&WMFRect{META_RECTANGLE, RECT{100, 100, 900, 400}},
+		&WMFPoly{META_POLYLINE, []POINT{{10, 10}, {20, -30}, {40, 10}}},
+		&WMFPolyPolygon{[][]POINT{{{0, 0}, {10, 0}, {0, 10}}, {{50, 50}, {60, 50}, {50, 60}}}},
+		&WMFTextOut{100, 200, []byte("Hello")},
+		&WMFExtTextOut{X: 150, Y: 300, Options: ETO_CLIPPED, Rect: RECT{150, 300, 250, 320}, Text: []byte("Hi"), Dx: []int32{10, 11}},
+		&WMFSaveDC{},
+		&WMFPatBlt{50, 40, 30, 20, PATCOPY},
+		&WMFRestoreDC{-1},
+		&WMFStretchDIB{Kind: META_DIBBITBLT, Rop: BLACKNESS, X: 6, Y: 5, Width: 30, Height: 20, SrcWidth: 30, SrcHeight: 20},
+		&WMFStretchDIB{
+			Kind: META_STRETCHDIB, Rop: SRCCOPY, X: 60, Y: 70, Width: 8, Height: 8, SrcWidth: 1, SrcHeight: 1,
+			Usage: DIB_RGB_COLORS,
+			DIB:   mustHex(t, "28000000010000000100000001001800000000000400000000000000000000000000000000000000"+"30201000"),
+		},
+		&WMFRaw{META_SETMAPPERFLAGS, []byte{1, 0, 0, 0}},
+		&WMFObject{META_DELETEOBJECT, 0},
+	}
+	if len(m.Records) != len(want) {
+		t.Fatalf("%d records, want %d", len(m.Records), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(m.Records[i], want[i]) {
+			t.Errorf("record %d = %+v, want %+v", i, m.Records[i], want[i])
+		}
+	}
+
+	// Without the placeable header the records are the same.
+	m2, err := DecodeWMF(mustHex(t, testWMF)[wmfPlaceableSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m2.Placeable != nil || m2.Header != m.Header || !reflect.DeepEqual(m2.Records, m.Records) {
+		t.Errorf("without the placeable header: %+v, want no placeable header and the same records", m2)
+	}
+}
+
+func TestDecodeWMFTruncated(t *testing.T) {
+	b := mustHex(t, testWMF)
+	for n := 0; n < len(b); n++ {
+		if _, err := DecodeWMF(b[:n]); err != ErrWMFInvalid {
+			t.Fatalf("DecodeWMF(b[:%d]) err = %v, want ErrWMFInvalid", n, err)
+		}
+	}
+}
+
+func TestDecodeWMFCorrupt(t *testing.T) {
+	// The offsets of records in testWMF.
+	const (
+		header     = 22
+		windowOrg  = 40
+		rectangle  = 188
+		polyline   = 202
+		polyPoly   = 222
+		textOut    = 258
+		extTextOut = 276
+		patBlt     = 310
+		stretchDIB = 360
+	)
+	le := binary.LittleEndian
+	tests := []struct {
+		name string
+		edit func(b []byte)
+	}{
+		{"header size", func(b []byte) { le.PutUint16(b[header+2:], 8) }},
+		{"record size 0", func(b []byte) { le.PutUint32(b[windowOrg:], 0) }},
+		{"record size 2", func(b []byte) { le.PutUint32(b[windowOrg:], 2) }},
+		{"record past end", func(b []byte) { le.PutUint32(b[windowOrg:], 1<<31) }},
+		{"short rectangle", func(b []byte) { le.PutUint32(b[rectangle:], 5) }},
+		{"point count", func(b []byte) { le.PutUint16(b[polyline+6:], 100) }},
+		{"polygon count", func(b []byte) { le.PutUint16(b[polyPoly+6:], 100) }},
+		{"polygon point count", func(b []byte) { le.PutUint16(b[polyPoly+8:], 100) }},
+		{"text length", func(b []byte) { le.PutUint16(b[textOut+6:], 100) }},
+		{"ext text length", func(b []byte) { le.PutUint16(b[extTextOut+10:], 50) }},
+		{"short patblt", func(b []byte) { le.PutUint32(b[patBlt:], 8) }},
+		{"stretchdib without dib", func(b []byte) { le.PutUint32(b[stretchDIB:], 3+15) }},
+		{"no eof", func(b []byte) { le.PutUint16(b[len(b)-2:], META_SAVEDC) }},
+	}
+	for _, tt := range tests {
+		b := mustHex(t, testWMF)
+		tt.edit(b)
+		if m, err := DecodeWMF(b); err != ErrWMFInvalid {
+			t.Errorf("%s: DecodeWMF = %+v, %v, want ErrWMFInvalid", tt.name, m, err)
+		}
+	}
+}
+
+func TestSplitDIB(t *testing.T) {
+	hdr := "28000000010000000100000001002000030000000400000000000000000000000000000000000000"
+	masks := "0000ff0000ff0000ff000000"
+	dib := mustHex(t, hdr+masks+"10203000")
+
+	bmi, m, bits, ok := splitDIB(dib, DIB_RGB_COLORS)
+	if !ok {
+		t.Fatal("splitDIB failed")
+	}
+	if bmi.BmiHeader.BiCompression != BI_BITFIELDS || m != [3]DWORD{0xFF0000, 0xFF00, 0xFF} || len(bits) != 4 {
+		t.Errorf("splitDIB = %+v, %x, %x", bmi.BmiHeader, m, bits)
+	}
+
+	// The masks must be present.
+	if _, _, _, ok := splitDIB(mustHex(t, hdr+"0000ff00"), DIB_RGB_COLORS); ok {
+		t.Error("splitDIB accepted a BI_BITFIELDS DIB without masks")
+	}
+	if _, _, _, ok := splitDIB(dib[:39], DIB_RGB_COLORS); ok {
+		t.Error("splitDIB accepted a short header")
+	}
+}
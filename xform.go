@@ -0,0 +1,111 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import "math"
+
+// XFORM is a 2D affine transform. A point (x, y) maps to
+//
+//	(x*EM11 + y*EM21 + EDx, x*EM12 + y*EM22 + EDy)
+//
+// Transforms compose left to right: a.Multiply(b) applies a, then b.
+type XFORM struct {
+	EM11 float32
+	EM12 float32
+	EM21 float32
+	EM22 float32
+	EDx  float32
+	EDy  float32
+}
+
+func IdentityXFORM() XFORM {
+	return XFORM{EM11: 1, EM22: 1}
+}
+
+func TranslateXFORM(dx, dy float32) XFORM {
+	return XFORM{EM11: 1, EM22: 1, EDx: dx, EDy: dy}
+}
+
+func ScaleXFORM(sx, sy float32) XFORM {
+	return XFORM{EM11: sx, EM22: sy}
+}
+
+// RotateXFORM rotates by angle radians around the origin. As the y axis
+// of a device points down, positive angles turn clockwise on screen.
+func RotateXFORM(angle float64) XFORM {
+	sin, cos := math.Sincos(angle)
+
+	return XFORM{
+		EM11: float32(cos),
+		EM12: float32(sin),
+		EM21: float32(-sin),
+		EM22: float32(cos),
+	}
+}
+
+// ShearXFORM shears x by sx times y and y by sy times x.
+func ShearXFORM(sx, sy float32) XFORM {
+	return XFORM{EM11: 1, EM12: sy, EM21: sx, EM22: 1}
+}
+
+// Multiply returns the transform applying x, then y, as
+// CombineTransform does.
+func (x XFORM) Multiply(y XFORM) XFORM {
+	return x.affine().then(y.affine()).xform()
+}
+
+// Invert returns the inverse of x, or false if x is singular.
+func (x XFORM) Invert() (XFORM, bool) {
+	m, ok := x.affine().invert()
+
+	return m.xform(), ok
+}
+
+// Rotate returns x followed by a rotation of angle radians.
+func (x XFORM) Rotate(angle float64) XFORM {
+	return x.Multiply(RotateXFORM(angle))
+}
+
+// Scale returns x followed by a scaling.
+func (x XFORM) Scale(sx, sy float32) XFORM {
+	return x.Multiply(ScaleXFORM(sx, sy))
+}
+
+// Shear returns x followed by a shear.
+func (x XFORM) Shear(sx, sy float32) XFORM {
+	return x.Multiply(ShearXFORM(sx, sy))
+}
+
+// Translate returns x followed by a translation.
+func (x XFORM) Translate(dx, dy float32) XFORM {
+	return x.Multiply(TranslateXFORM(dx, dy))
+}
+
+// Apply maps p, rounding the result to the nearest integer.
+func (x XFORM) Apply(p POINT) POINT {
+	pts := []POINT{p}
+	x.affine().apply(pts)
+
+	return pts[0]
+}
+
+func (x XFORM) affine() affine {
+	return affine{
+		float64(x.EM11), float64(x.EM12),
+		float64(x.EM21), float64(x.EM22),
+		float64(x.EDx), float64(x.EDy),
+	}
+}
+
+func (m affine) xform() XFORM {
+	return XFORM{
+		EM11: float32(m[0]),
+		EM12: float32(m[1]),
+		EM21: float32(m[2]),
+		EM22: float32(m[3]),
+		EDx:  float32(m[4]),
+		EDy:  float32(m[5]),
+	}
+}
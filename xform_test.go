@@ -0,0 +1,124 @@
+// Copyright 2013 The winapi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package winapi
+
+import (
+	"math"
+	"testing"
+)
+
+func xformNear(a, b XFORM) bool {
+	near := func(x, y float32) bool { return math.Abs(float64(x-y)) < 1e-5 }
+
+	return near(a.EM11, b.EM11) && near(a.EM12, b.EM12) &&
+		near(a.EM21, b.EM21) && near(a.EM22, b.EM22) &&
+		near(a.EDx, b.EDx) && near(a.EDy, b.EDy)
+}
+
+func TestXFORMMultiply(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y XFORM
+		want XFORM
+	}{
+		{"identity", ScaleXFORM(2, 3), IdentityXFORM(), ScaleXFORM(2, 3)},
+		{"scale then translate", ScaleXFORM(2, 3), TranslateXFORM(5, -7), XFORM{EM11: 2, EM22: 3, EDx: 5, EDy: -7}},
+		{"translate then scale", TranslateXFORM(5, -7), ScaleXFORM(2, 3), XFORM{EM11: 2, EM22: 3, EDx: 10, EDy: -21}},
+		{"shear then scale", ShearXFORM(1, 0), ScaleXFORM(2, 3), XFORM{EM11: 2, EM21: 2, EM22: 3}},
+		{"quarter turns", RotateXFORM(math.Pi / 2), RotateXFORM(math.Pi / 2), ScaleXFORM(-1, -1)},
+	}
+	for _, tt := range tests {
+		if got := tt.x.Multiply(tt.y); !xformNear(got, tt.want) {
+			t.Errorf("%s: Multiply = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Applying the product equals applying one transform after the
+	// other.
+	x, y := RotateXFORM(math.Pi/2).Translate(10, 0), ScaleXFORM(2, -1)
+	p := POINT{3, 4}
+	if got, want := x.Multiply(y).Apply(p), y.Apply(x.Apply(p)); got != want {
+		t.Errorf("product maps %v to %v, want %v", p, got, want)
+	}
+	if got := RotateXFORM(math.Pi / 2).Apply(POINT{1, 0}); got != (POINT{0, 1}) {
+		t.Errorf("quarter turn maps {1 0} to %v, want {0 1}", got)
+	}
+}
+
+func TestXFORMInvert(t *testing.T) {
+	tests := []XFORM{
+		IdentityXFORM(),
+		TranslateXFORM(-3, 8),
+		ScaleXFORM(4, -0.5),
+		ShearXFORM(0.5, -2),
+		RotateXFORM(1).Scale(2, 3).Translate(-40, 25),
+	}
+	for _, x := range tests {
+		inv, ok := x.Invert()
+		if !ok {
+			t.Errorf("%+v: Invert failed", x)
+			continue
+		}
+		if got := x.Multiply(inv); !xformNear(got, IdentityXFORM()) {
+			t.Errorf("%+v times its inverse = %+v", x, got)
+		}
+		if got := inv.Multiply(x); !xformNear(got, IdentityXFORM()) {
+			t.Errorf("inverse of %+v times it = %+v", x, got)
+		}
+		back, _ := inv.Invert()
+		if !xformNear(back, x) {
+			t.Errorf("inverse of inverse of %+v = %+v", x, back)
+		}
+	}
+
+	for _, x := range []XFORM{{}, ScaleXFORM(0, 1), {EM11: 1, EM12: 2, EM21: 2, EM22: 4}} {
+		if _, ok := x.Invert(); ok {
+			t.Errorf("Invert of singular %+v succeeded", x)
+		}
+	}
+}
+
+func TestTransformWorld(t *testing.T) {
+	tr := NewTransform(1000, 500, 250, 125)
+	if tr.SetWorldTransform(ScaleXFORM(2, 2)) {
+		t.Error("SetWorldTransform succeeded in GM_COMPATIBLE")
+	}
+	if old := tr.SetGraphicsMode(GM_ADVANCED); old != GM_COMPATIBLE {
+		t.Errorf("SetGraphicsMode returned %d, want GM_COMPATIBLE", old)
+	}
+	if tr.SetWorldTransform(ScaleXFORM(0, 2)) {
+		t.Error("SetWorldTransform accepted a singular transform")
+	}
+
+	tr.SetWorldTransform(ScaleXFORM(2, 2))
+	tr.ModifyWorldTransform(TranslateXFORM(1, 0), MWT_LEFTMULTIPLY)
+	if got, want := tr.WorldTransform(), (XFORM{EM11: 2, EM22: 2, EDx: 2}); got != want {
+		t.Errorf("after MWT_LEFTMULTIPLY world = %+v, want %+v", got, want)
+	}
+	tr.ModifyWorldTransform(TranslateXFORM(1, 0), MWT_RIGHTMULTIPLY)
+	if got, want := tr.WorldTransform(), (XFORM{EM11: 2, EM22: 2, EDx: 3}); got != want {
+		t.Errorf("after MWT_RIGHTMULTIPLY world = %+v, want %+v", got, want)
+	}
+
+	// The world transform is applied before the page space.
+	tr.SetViewportOrg(5, 5)
+	pts := []POINT{{1, 1}}
+	tr.LPtoDP(pts)
+	if pts[0] != (POINT{10, 7}) {
+		t.Errorf("LPtoDP = %v, want {10 7}", pts[0])
+	}
+	tr.DPtoLP(pts)
+	if pts[0] != (POINT{1, 1}) {
+		t.Errorf("DPtoLP = %v, want {1 1}", pts[0])
+	}
+
+	if tr.SetGraphicsMode(GM_COMPATIBLE) != 0 {
+		t.Error("SetGraphicsMode(GM_COMPATIBLE) succeeded with a world transform")
+	}
+	tr.ModifyWorldTransform(XFORM{}, MWT_IDENTITY)
+	if tr.SetGraphicsMode(GM_COMPATIBLE) != GM_ADVANCED {
+		t.Error("SetGraphicsMode(GM_COMPATIBLE) failed after MWT_IDENTITY")
+	}
+}